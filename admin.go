@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+
+	"gin-demo-api/audit"
+	"gin-demo-api/db"
+	"gin-demo-api/models"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+func createAdminCommand() *cobra.Command {
+	var email string
+	cmd := &cobra.Command{
+		Use:   "create-admin <username>",
+		Short: "Create an admin user, or promote an existing user to admin",
+		Long:  "Creates a user with the admin role. If the username already exists, that user is promoted instead and --email is ignored.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := openDatabase(); err != nil {
+				return err
+			}
+
+			var user models.User
+			err := db.DB.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Where("username = ?", args[0]).Limit(1).Find(&user).Error; err != nil {
+					return err
+				}
+				if user.ID == 0 {
+					if email == "" {
+						return fmt.Errorf("user %q does not exist; pass --email to create it", args[0])
+					}
+					user = models.User{Username: args[0], Email: email, Role: models.RoleAdmin}
+					if err := tx.Create(&user).Error; err != nil {
+						return err
+					}
+					return audit.Record(tx, nil, audit.ActionCreate, "user", user.ID, nil, user)
+				}
+
+				before := user
+				if err := tx.Model(&user).Update("role", models.RoleAdmin).Error; err != nil {
+					return err
+				}
+				return audit.Record(tx, nil, audit.ActionUpdate, "user", user.ID, before, user)
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "User %q (ID %d) is an admin\n", user.Username, user.ID)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&email, "email", "", "Email address for a new user")
+	return cmd
+}
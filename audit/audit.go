@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"gin-demo-api/models"
+
+	"gorm.io/gorm"
+)
+
+// Actions recorded in the audit trail
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// ignoredFields are bookkeeping or derived JSON fields left out of diffs
+var ignoredFields = map[string]bool{
+	"id":               true,
+	"created_at":       true,
+	"updated_at":       true,
+	"todos":            true,
+	"mentions":         true,
+	"description_html": true,
+}
+
+// Record writes an audit event using tx, so it commits or rolls back with the change itself.
+// before is nil for creates and after is nil for deletes.
+func Record(tx *gorm.DB, actorID *uint, action, entityType string, entityID uint, before, after interface{}) error {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return err
+	}
+	// Nothing changed, nothing to record
+	if action == ActionUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	event := models.AuditEvent{
+		ActorID:    actorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+	}
+	return tx.Create(&event).Error
+}
+
+// Diff compares the JSON representations of before and after field by field
+func Diff(before, after interface{}) (models.AuditChanges, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := models.AuditChanges{}
+	for name, value := range afterFields {
+		if !reflect.DeepEqual(beforeFields[name], value) {
+			changes[name] = models.FieldChange{Before: beforeFields[name], After: value}
+		}
+	}
+	for name, value := range beforeFields {
+		if _, ok := afterFields[name]; !ok {
+			changes[name] = models.FieldChange{Before: value, After: nil}
+		}
+	}
+	return changes, nil
+}
+
+// jsonFields flattens a model into its top-level JSON fields
+func jsonFields(entity interface{}) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if entity == nil {
+		return fields, nil
+	}
+
+	b, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	for name := range ignoredFields {
+		delete(fields, name)
+	}
+	return fields, nil
+}
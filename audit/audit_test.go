@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+
+	"gin-demo-api/models"
+)
+
+func TestDiff(t *testing.T) {
+	before := models.Todo{ID: 1, Item: "Buy milk", UserID: 1, Description: "2 litres", DescriptionHTML: "<p>2 litres</p>"}
+	after := before
+	after.ID = 2 // Bookkeeping fields are ignored
+	after.Completed = true
+	after.Description = ""
+	after.DescriptionHTML = "" // So are derived ones
+
+	changes, err := Diff(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.AuditChanges{
+		"completed":   {Before: false, After: true},
+		"description": {Before: "2 litres", After: ""},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("update diff = %+v, want %+v", changes, want)
+	}
+
+	// Creates have no before, deletes no after
+	changes, err = Diff(nil, models.Todo{Item: "Buy milk", UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes["item"] != (models.FieldChange{Before: nil, After: "Buy milk"}) || changes["id"] != (models.FieldChange{}) {
+		t.Errorf("create diff = %+v, want every field but the ignored ones from nil", changes)
+	}
+	changes, err = Diff(models.Todo{Item: "Buy milk", UserID: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes["item"] != (models.FieldChange{Before: "Buy milk", After: nil}) {
+		t.Errorf("delete diff = %+v, want every field to nil", changes)
+	}
+
+	if changes, _ := Diff(before, before); len(changes) != 0 {
+		t.Errorf("diff of an unchanged todo = %+v, want none", changes)
+	}
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gin-demo-api/db"
+	"gin-demo-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// Context key holding the API key a request was authenticated with
+	apiKeyKey = "auth.apiKey"
+	// Context key holding whether the request's API key may change data
+	writeAllowedKey = "auth.writeAllowed"
+
+	// APIKeyHeader is the alternative to "Authorization: Bearer" for sending a key
+	APIKeyHeader = "X-API-Key"
+
+	// keyPrefix starts every key, so leaked keys are easy to search for
+	keyPrefix = "gda_"
+	// visiblePrefixLength is how much of a key is stored in the clear to recognise it
+	visiblePrefixLength = len(keyPrefix) + 8
+	// lastUsedPrecision limits how often last_used_at is written for a busy key
+	lastUsedPrecision = time.Minute
+)
+
+// ErrInvalidAPIKey is returned for unknown, malformed and revoked keys
+var ErrInvalidAPIKey = errors.New("Invalid API key")
+
+// versionPrefix matches the version segment of a route, e.g. /v1
+var versionPrefix = regexp.MustCompile(`^/v\d+`)
+
+// readableRoutes may only read despite their method, like GraphQL queries, which are
+// POSTed just as mutations are. Their handlers check WriteAllowed before changing data.
+var readableRoutes = map[string]bool{"POST /graphql": true}
+
+// NewAPIKey generates a key, returning it with its visible prefix and the hash to store
+func NewAPIKey() (key, prefix, hash string) {
+	key = newToken(keyPrefix)
+	return key, key[:visiblePrefixLength], HashAPIKey(key)
+}
+
+// HashAPIKey returns the stored form of a key or session token. They are random,
+// so a plain SHA-256 is enough; a slow password hash would only slow every request.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// newToken returns a random bearer token starting with prefix
+func newToken(prefix string) string {
+	secret := make([]byte, 24)
+	rand.Read(secret)
+	return prefix + hex.EncodeToString(secret)
+}
+
+// ValidScope reports whether scope is one of models.APIKeyScopes
+func ValidScope(scope string) bool {
+	return slices.Contains(models.APIKeyScopes, scope)
+}
+
+// IdentifyKey resolves an API key to its stored record, for any transport, and
+// records that it was used
+func IdentifyKey(ctx context.Context, key string) (models.APIKey, error) {
+	var apiKey models.APIKey
+	if !strings.HasPrefix(key, keyPrefix) {
+		return apiKey, ErrInvalidAPIKey
+	}
+	// Revoked keys are soft-deleted, and keys of deleted users stop working too
+	err := db.DB.WithContext(ctx).
+		Joins("JOIN users ON users.id = api_keys.user_id AND users.deleted_at IS NULL").
+		Where("api_keys.hash = ?", HashAPIKey(key)).
+		First(&apiKey).Error
+	if err != nil {
+		return apiKey, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= lastUsedPrecision {
+		apiKey.LastUsedAt = &now
+		db.DB.WithContext(ctx).Model(&apiKey).UpdateColumn("last_used_at", now)
+	}
+	return apiKey, nil
+}
+
+// ScopesAllow reports whether any of the scopes permits a request. path is the
+// route without its version prefix; write is true for requests that change data.
+func ScopesAllow(scopes []string, path string, write bool) bool {
+	todos := path == "/todos" || strings.HasPrefix(path, "/todos/") || path == "/ws/todos"
+	for _, scope := range scopes {
+		switch scope {
+		case models.ScopeAll:
+			return true
+		case models.ScopeRead:
+			if !write {
+				return true
+			}
+		case models.ScopeTodos:
+			if todos {
+				return true
+			}
+		case models.ScopeTodosRead:
+			if todos && !write {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// APIKeyID returns the ID of the API key the request was authenticated with, if any
+func APIKeyID(c *gin.Context) (uint, bool) {
+	key, ok := c.Get(apiKeyKey)
+	if !ok {
+		return 0, false
+	}
+	return key.(models.APIKey).ID, true
+}
+
+// WriteAllowed reports whether the request may change data on its route. Only API keys
+// without a writing scope for the route are refused; the route decides for everyone else.
+func WriteAllowed(c *gin.Context) bool {
+	allowed, ok := c.Get(writeAllowedKey)
+	return !ok || allowed.(bool)
+}
+
+// requestKey returns the API key or session token sent as a bearer token, in X-API-Key,
+// or as a WebSocket subprotocol
+func requestKey(c *gin.Context) string {
+	if scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		return key
+	}
+	return protocolToken(c.Request)
+}
+
+// authenticateKey identifies the caller from an API key and checks its scopes against the route
+func authenticateKey(c *gin.Context, key string) {
+	apiKey, err := IdentifyKey(c.Request.Context(), key)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Unknown routes are left to answer 404
+	path := versionPrefix.ReplaceAllString(c.FullPath(), "")
+	write := c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead
+	if readableRoutes[c.Request.Method+" "+path] {
+		write = false
+	}
+	if path != "" && !ScopesAllow(apiKey.Scopes, path, write) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key scope does not allow this request"})
+		return
+	}
+
+	c.Set(userIDKey, apiKey.UserID)
+	c.Set(apiKeyKey, apiKey)
+	c.Set(writeAllowedKey, ScopesAllow(apiKey.Scopes, path, true))
+	c.Next()
+}
@@ -0,0 +1,183 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gin-demo-api/auth"
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// createKey issues an API key with the given scopes for a test
+func createKey(t *testing.T, userID uint, scopes ...string) string {
+	t.Helper()
+	key, prefix, hash := auth.NewAPIKey()
+	if err := db.DB.Create(&models.APIKey{UserID: userID, Name: "test", Prefix: prefix, Hash: hash, Scopes: scopes}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// bearer returns the Authorization header for a token
+func bearer(token string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+// testRouter answers GET /whoami with the authenticated user's ID behind the given middleware
+func testRouter(middleware ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(auth.Authenticate())
+	router.GET("/whoami", append(middleware, func(c *gin.Context) {
+		id, _ := auth.UserID(c)
+		c.String(http.StatusOK, strconv.FormatUint(uint64(id), 10))
+	})...)
+	return router
+}
+
+// get sends GET /whoami with the given headers
+func get(router http.Handler, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestUserIDHeaderIsOffByDefault(t *testing.T) {
+	testdb.Open(t)
+	user := testdb.CreateUser(t, models.User{Username: "alice", Email: "alice@example.com", Role: models.RoleUser})
+	router := testRouter(auth.RequireUser())
+	header := map[string]string{"X-User-ID": strconv.FormatUint(uint64(user.ID), 10)}
+
+	if w := get(router, header); w.Code != http.StatusUnauthorized {
+		t.Errorf("X-User-ID by default: got %d, want 401", w.Code)
+	}
+
+	auth.TrustUserIDHeader = true
+	defer func() { auth.TrustUserIDHeader = false }()
+	if w := get(router, header); w.Code != http.StatusOK || w.Body.String() != header["X-User-ID"] {
+		t.Errorf("X-User-ID in development: got %d %q", w.Code, w.Body.String())
+	}
+	if w := get(router, map[string]string{"X-User-ID": "999"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown user: got %d, want 401", w.Code)
+	}
+}
+
+func TestSessionToken(t *testing.T) {
+	testdb.Open(t)
+	user := testdb.CreateUser(t, models.User{Username: "alice", Email: "alice@example.com", Role: models.RoleUser})
+	token, session := testdb.Session(t, user.ID, false)
+	router := testRouter(auth.RequireUser())
+
+	if w := get(router, map[string]string{"Authorization": "Bearer " + token}); w.Code != http.StatusOK {
+		t.Fatalf("session token: got %d %s", w.Code, w.Body)
+	}
+	if w := get(router, map[string]string{"Authorization": "Bearer gds_wrong"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown token: got %d, want 401", w.Code)
+	}
+	if w := get(router, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous: got %d, want 401", w.Code)
+	}
+
+	if err := db.DB.Delete(&session).Error; err != nil {
+		t.Fatal(err)
+	}
+	auth.ForgetUserSessions(user.ID)
+	if w := get(router, map[string]string{"Authorization": "Bearer " + token}); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked session: got %d, want 401", w.Code)
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	testdb.Open(t)
+	now := time.Now()
+	user := testdb.CreateUser(t, models.User{Username: "alice", Email: "alice@example.com", Role: models.RoleUser})
+	admin := testdb.CreateUser(t, models.User{Username: "root", Email: "root@example.com", Role: models.RoleAdmin, TwoFactorEnabledAt: &now})
+	newAdmin := testdb.CreateUser(t, models.User{Username: "bob", Email: "bob@example.com", Role: models.RoleAdmin})
+	userSession, _ := testdb.Session(t, user.ID, true)
+	confirmed, _ := testdb.Session(t, admin.ID, true)
+	unconfirmed, _ := testdb.Session(t, admin.ID, false)
+	withoutTwoFactor, _ := testdb.Session(t, newAdmin.ID, false)
+	router := testRouter(auth.RequireAdmin())
+
+	defer func(roles []string) { auth.TwoFactorRoles = roles }(auth.TwoFactorRoles)
+	auth.TwoFactorRoles = []string{models.RoleAdmin}
+	auth.TrustUserIDHeader = true
+	defer func() { auth.TrustUserIDHeader = false }()
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"anonymous", nil, http.StatusUnauthorized},
+		{"regular user", bearer(userSession), http.StatusForbidden},
+		{"admin's API key", bearer(createKey(t, admin.ID, models.ScopeAll)), http.StatusForbidden},
+		{"admin's X-User-ID", map[string]string{"X-User-ID": strconv.FormatUint(uint64(admin.ID), 10)}, http.StatusForbidden},
+		{"session without second factor", bearer(unconfirmed), http.StatusForbidden},
+		{"admin without two-factor authentication", bearer(withoutTwoFactor), http.StatusForbidden},
+		{"session confirmed with second factor", bearer(confirmed), http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := get(router, tt.headers); w.Code != tt.want {
+			t.Errorf("%s: got %d %s, want %d", tt.name, w.Code, w.Body, tt.want)
+		}
+	}
+
+	// Without the policy any admin session will do, but still no API key
+	auth.TwoFactorRoles = nil
+	if w := get(router, bearer(withoutTwoFactor)); w.Code != http.StatusOK {
+		t.Errorf("session without policy: got %d, want 200", w.Code)
+	}
+	if w := get(router, bearer(createKey(t, newAdmin.ID, models.ScopeAll))); w.Code != http.StatusForbidden {
+		t.Errorf("API key without policy: got %d, want 403", w.Code)
+	}
+
+	// Diagnostics demand a second factor even without the policy
+	confirmedRouter := testRouter(auth.RequireConfirmedAdmin())
+	if w := get(confirmedRouter, bearer(withoutTwoFactor)); w.Code != http.StatusForbidden {
+		t.Errorf("diagnostics without two-factor authentication: got %d, want 403", w.Code)
+	}
+	if w := get(confirmedRouter, bearer(unconfirmed)); w.Code != http.StatusForbidden {
+		t.Errorf("diagnostics with an unconfirmed session: got %d, want 403", w.Code)
+	}
+	if w := get(confirmedRouter, bearer(confirmed)); w.Code != http.StatusOK {
+		t.Errorf("diagnostics with a confirmed session: got %d, want 200", w.Code)
+	}
+}
+
+func TestRequireSession(t *testing.T) {
+	testdb.Open(t)
+	user := testdb.CreateUser(t, models.User{Username: "alice", Email: "alice@example.com", Role: models.RoleUser})
+	session, _ := testdb.Session(t, user.ID, false)
+	router := testRouter(auth.RequireSession())
+
+	auth.TrustUserIDHeader = true
+	defer func() { auth.TrustUserIDHeader = false }()
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"anonymous", nil, http.StatusUnauthorized},
+		{"API key", bearer(createKey(t, user.ID, models.ScopeAll)), http.StatusForbidden},
+		{"X-User-ID", map[string]string{"X-User-ID": strconv.FormatUint(uint64(user.ID), 10)}, http.StatusForbidden},
+		{"session", bearer(session), http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := get(router, tt.headers); w.Code != tt.want {
+			t.Errorf("%s: got %d %s, want %d", tt.name, w.Code, w.Body, tt.want)
+		}
+	}
+}
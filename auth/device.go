@@ -0,0 +1,37 @@
+package auth
+
+import "strings"
+
+// browsers and systems are matched against a user agent in order; the first match wins,
+// so more specific tokens (Edge and Opera also say Chrome, Android also says Linux) come first
+var (
+	browsers = []struct{ token, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"},
+		{"Safari/", "Safari"}, {"curl/", "curl"}, {"grpc-go/", "gRPC client"}, {"Go-http-client/", "Go client"},
+	}
+	systems = []struct{ token, name string }{
+		{"Windows", "Windows"}, {"Android", "Android"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"},
+		{"Mac OS X", "macOS"}, {"CrOS", "ChromeOS"}, {"Linux", "Linux"},
+	}
+)
+
+// DescribeDevice turns a user agent into a short description like "Firefox on Linux",
+// for users to recognise their sessions
+func DescribeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+	browser, _, _ := strings.Cut(userAgent, "/") // Falls back to the product name
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, s := range systems {
+		if strings.Contains(userAgent, s.token) {
+			return browser + " on " + s.name
+		}
+	}
+	return browser
+}
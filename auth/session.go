@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"gin-demo-api/db"
+	"gin-demo-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// Context key holding the session a request was authenticated with
+	sessionKey = "auth.session"
+
+	// sessionPrefix starts every session token, telling them apart from API keys
+	sessionPrefix = "gds_"
+
+	// lastActivePrecision limits how often last_active_at is written for a busy session
+	lastActivePrecision = time.Minute
+)
+
+// SessionCacheTTL is how long a session looked up in the database is trusted without
+// checking again. Revoking a session drops it from this server's cache at once; other
+// servers notice within the TTL. Zero disables the cache.
+var SessionCacheTTL = 30 * time.Second
+
+// cachedSession is a session the middleware looked up, and until when to trust it
+type cachedSession struct {
+	session models.Session
+	until   time.Time
+}
+
+// sessionCache holds recently used sessions by token hash, sparing a query per request
+var sessionCache = struct {
+	sync.Mutex
+	entries   map[string]cachedSession
+	lastSweep time.Time
+}{entries: map[string]cachedSession{}}
+
+// ErrInvalidSession is returned for unknown, expired and logged-out session tokens
+var ErrInvalidSession = errors.New("Invalid or expired session")
+
+// NewSessionToken generates a session token, returning it with the hash to store
+func NewSessionToken() (token, hash string) {
+	token = newToken(sessionPrefix)
+	return token, HashAPIKey(token)
+}
+
+// IsSessionToken reports whether a bearer token is a session token rather than an API key
+func IsSessionToken(token string) bool {
+	return strings.HasPrefix(token, sessionPrefix)
+}
+
+// IdentifySession resolves a session token to its stored record, for any transport, and
+// records the activity from ip
+func IdentifySession(ctx context.Context, token, ip string) (models.Session, error) {
+	hash := HashAPIKey(token)
+	now := time.Now()
+	session, ok := cachedLookup(hash, now)
+	if !ok {
+		err := db.DB.WithContext(ctx).
+			Joins("JOIN users ON users.id = sessions.user_id AND users.deleted_at IS NULL").
+			Where("sessions.token_hash = ? AND sessions.expires_at > ?", hash, now).
+			First(&session).Error
+		if err != nil {
+			return session, ErrInvalidSession
+		}
+	}
+
+	if now.Sub(session.LastActiveAt) >= lastActivePrecision {
+		session.LastActiveAt, session.IP = now, ip
+		db.DB.WithContext(ctx).Model(&session).UpdateColumns(map[string]interface{}{"last_active_at": now, "ip": ip})
+	}
+	cacheSession(hash, session, now)
+	return session, nil
+}
+
+// ForgetUserSessions drops the user's sessions from the cache, so the next request
+// checks the database again, e.g. after the user was deleted or a session revoked
+func ForgetUserSessions(userID uint) {
+	forgetSessions(func(session models.Session) bool { return session.UserID == userID })
+}
+
+// cachedLookup returns a cached session that is still trusted and not expired
+func cachedLookup(hash string, now time.Time) (models.Session, bool) {
+	sessionCache.Lock()
+	defer sessionCache.Unlock()
+	entry, ok := sessionCache.entries[hash]
+	if !ok || now.After(entry.until) || now.After(entry.session.ExpiresAt) {
+		return models.Session{}, false
+	}
+	return entry.session, true
+}
+
+// cacheSession stores a session, sweeping out stale entries now and then
+func cacheSession(hash string, session models.Session, now time.Time) {
+	if SessionCacheTTL <= 0 {
+		return
+	}
+	sessionCache.Lock()
+	defer sessionCache.Unlock()
+	if now.Sub(sessionCache.lastSweep) >= SessionCacheTTL {
+		for key, entry := range sessionCache.entries {
+			if now.After(entry.until) {
+				delete(sessionCache.entries, key)
+			}
+		}
+		sessionCache.lastSweep = now
+	}
+	until := now.Add(SessionCacheTTL)
+	if entry, ok := sessionCache.entries[hash]; ok && now.Before(entry.until) {
+		until = entry.until // Refreshing the activity does not extend the trust
+	}
+	sessionCache.entries[hash] = cachedSession{session: session, until: until}
+}
+
+// forgetSessions drops the cached sessions matching the predicate
+func forgetSessions(match func(models.Session) bool) {
+	sessionCache.Lock()
+	defer sessionCache.Unlock()
+	for key, entry := range sessionCache.entries {
+		if match(entry.session) {
+			delete(sessionCache.entries, key)
+		}
+	}
+}
+
+// SessionID returns the ID of the session the request was authenticated with, if any
+func SessionID(c *gin.Context) (uint, bool) {
+	session, ok := c.Get(sessionKey)
+	if !ok {
+		return 0, false
+	}
+	return session.(models.Session).ID, true
+}
+
+// RequireSession rejects requests that were not authenticated with a session token,
+// for account settings that a leaked API key must not be able to change
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := UserID(c); !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+		if _, ok := SessionID(c); !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "A session token is required; API keys cannot use this route"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// authenticateSession identifies the caller from a session token; sessions can do
+// everything their user can
+func authenticateSession(c *gin.Context, token string) {
+	session, err := IdentifySession(c.Request.Context(), token, c.ClientIP())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Set(userIDKey, session.UserID)
+	c.Set(sessionKey, session)
+	c.Next()
+}
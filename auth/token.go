@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, forged, expired or already used
+var ErrInvalidToken = errors.New("Invalid or expired token")
+
+// TokenSecret signs emailed tokens. When it is not configured a random key is
+// used, so tokens stop working when the server restarts.
+var TokenSecret = randomSecret()
+
+// tokenClaims is the signed payload of a token
+type tokenClaims struct {
+	Purpose string `json:"p"`
+	UserID  uint   `json:"u"`
+	State   string `json:"s"`
+	Expires int64  `json:"e"`
+}
+
+// SignToken returns a token for one purpose and user, valid for ttl. state should
+// change once the token has been used (e.g. the password hash), which makes it single-use.
+func SignToken(purpose string, userID uint, state string, ttl time.Duration) string {
+	payload, _ := json.Marshal(tokenClaims{Purpose: purpose, UserID: userID, State: state, Expires: time.Now().Add(ttl).Unix()})
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign(encoded))
+}
+
+// VerifyToken checks a token's signature, purpose and expiry and returns the user
+// and state it was issued for; the caller compares the state with the current one
+func VerifyToken(token, purpose string) (uint, string, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, "", ErrInvalidToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, sign(encoded)) {
+		return 0, "", ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, "", ErrInvalidToken
+	}
+
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Purpose != purpose || time.Now().Unix() > claims.Expires {
+		return 0, "", ErrInvalidToken
+	}
+	return claims.UserID, claims.State, nil
+}
+
+// sign returns the HMAC-SHA256 of a token payload
+func sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, TokenSecret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+func randomSecret() []byte {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return secret
+}
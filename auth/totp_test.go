@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// The SHA-1 test vectors of RFC 6238, appendix B, cut to 6 digits
+var totpVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+// The RFC's key, "12345678901234567890", base32-encoded
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	for _, v := range totpVectors {
+		if got := totpCode([]byte("12345678901234567890"), v.unix/30); got != v.code {
+			t.Errorf("totpCode at %d = %s, want %s", v.unix, got, v.code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	for _, v := range totpVectors {
+		now := time.Unix(v.unix, 0)
+		step, ok := ValidateTOTP(rfcSecret, v.code, now, 0)
+		if !ok || step != v.unix/30 {
+			t.Errorf("ValidateTOTP(%s) at %d = %d, %v; want step %d", v.code, v.unix, step, ok, v.unix/30)
+		}
+		// Used codes, and older ones, are rejected
+		if _, ok := ValidateTOTP(rfcSecret, v.code, now, step); ok {
+			t.Errorf("ValidateTOTP(%s) at %d accepted a used code", v.code, v.unix)
+		}
+	}
+
+	now := time.Unix(1111111111, 0)
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		at     time.Time
+		ok     bool
+	}{
+		{"lowercase secret, code with a space", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", "050 471", now, true},
+		{"one step late", rfcSecret, "050471", now.Add(30 * time.Second), true},
+		{"one step early", rfcSecret, "050471", now.Add(-30 * time.Second), true},
+		{"two steps late", rfcSecret, "050471", now.Add(60 * time.Second), false},
+		{"wrong code", rfcSecret, "050472", now, false},
+		{"8 digits", rfcSecret, "14050471", now, false},
+		{"invalid secret", "not base32!", "050471", now, false},
+	}
+	for _, tt := range tests {
+		if _, ok := ValidateTOTP(tt.secret, tt.code, tt.at, 0); ok != tt.ok {
+			t.Errorf("%s: ValidateTOTP = %v, want %v", tt.name, ok, tt.ok)
+		}
+	}
+}
+
+func TestRecoveryCodeHash(t *testing.T) {
+	code, hash := NewRecoveryCode()
+	if len(code) != 19 || strings.Count(code, "-") != 3 {
+		t.Errorf("code %q, want four groups of four", code)
+	}
+	// Codes match however they are typed
+	for _, typed := range []string{code, strings.ToUpper(code), strings.ReplaceAll(code, "-", " "), strings.ReplaceAll(code, "-", "")} {
+		if HashRecoveryCode(typed) != hash {
+			t.Errorf("HashRecoveryCode(%q) does not match %q", typed, code)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gin-demo-api/db"
+	"gin-demo-api/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// BearerProtocolPrefix starts a WebSocket subprotocol carrying a bearer token,
+	// "bearer.<token>". Browsers cannot set headers on a handshake, but they can
+	// offer subprotocols; the server never selects this one.
+	BearerProtocolPrefix = "bearer."
+
+	// TicketParam is the query parameter of a WebSocket handshake holding a ticket
+	TicketParam = "ticket"
+
+	// purposeWebSocketTicket marks tokens issued by NewWebSocketTicket
+	purposeWebSocketTicket = "ws-ticket"
+)
+
+// WebSocketTicketTTL is how long a WebSocket ticket can be used
+var WebSocketTicketTTL = 30 * time.Second
+
+// redeemedTickets holds the tickets used on this server until they expire, making them
+// single-use
+var redeemedTickets = struct {
+	sync.Mutex
+	entries map[string]time.Time
+}{entries: map[string]time.Time{}}
+
+// NewWebSocketTicket returns a ticket that authenticates one WebSocket handshake as
+// the user's session, within WebSocketTicketTTL. Unlike the session token, it is safe
+// to put in a URL, which may end up in logs.
+func NewWebSocketTicket(userID, sessionID uint) string {
+	return SignToken(purposeWebSocketTicket, userID, strconv.FormatUint(uint64(sessionID), 10), WebSocketTicketTTL)
+}
+
+// HasCredentials reports whether a request claims an identity that Authenticate checks
+func HasCredentials(r *http.Request) bool {
+	return r.Header.Get("Authorization") != "" || r.Header.Get(APIKeyHeader) != "" || r.Header.Get("X-User-ID") != "" ||
+		protocolToken(r) != "" || (websocket.IsWebSocketUpgrade(r) && r.URL.Query().Get(TicketParam) != "")
+}
+
+// protocolToken returns the bearer token offered as a subprotocol of a WebSocket handshake
+func protocolToken(r *http.Request) string {
+	if !websocket.IsWebSocketUpgrade(r) {
+		return ""
+	}
+	for _, protocol := range websocket.Subprotocols(r) {
+		if token, ok := strings.CutPrefix(protocol, BearerProtocolPrefix); ok {
+			return token
+		}
+	}
+	return ""
+}
+
+// authenticateTicket identifies the caller of a WebSocket handshake from a ticket, as
+// the session it was issued to
+func authenticateTicket(c *gin.Context, ticket string) {
+	session, err := redeemTicket(c.Request.Context(), ticket)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Set(userIDKey, session.UserID)
+	c.Set(sessionKey, session)
+	c.Next()
+}
+
+// redeemTicket checks a ticket, marks it used and returns its session, which must still be valid
+func redeemTicket(ctx context.Context, ticket string) (models.Session, error) {
+	userID, state, err := VerifyToken(ticket, purposeWebSocketTicket)
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	redeemedTickets.Lock()
+	now := time.Now()
+	for key, expires := range redeemedTickets.entries {
+		if now.After(expires) {
+			delete(redeemedTickets.entries, key)
+		}
+	}
+	_, used := redeemedTickets.entries[ticket]
+	if !used {
+		redeemedTickets.entries[ticket] = now.Add(WebSocketTicketTTL)
+	}
+	redeemedTickets.Unlock()
+	if used {
+		return models.Session{}, ErrInvalidToken
+	}
+
+	var session models.Session
+	err = db.DB.WithContext(ctx).
+		Joins("JOIN users ON users.id = sessions.user_id AND users.deleted_at IS NULL").
+		Where("sessions.id = ? AND sessions.user_id = ? AND sessions.expires_at > ?", state, userID, now).
+		First(&session).Error
+	if err != nil {
+		return models.Session{}, ErrInvalidSession
+	}
+	return session, nil
+}
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"gin-demo-api/models"
+)
+
+// Login signs in with a username or email address and a password (POST /auth/login).
+// Pass the returned Token to WithToken. Users with two-factor authentication get a
+// Challenge instead; complete it with VerifyTwoFactor.
+func (c *Client) Login(ctx context.Context, login, password string) (*models.LoginResult, error) {
+	var result models.LoginResult
+	input := models.PasswordLogin{Login: login, Password: password}
+	if err := c.do(ctx, http.MethodPost, "/auth/login", nil, input, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// VerifyTwoFactor completes a login challenge with a code from the authenticator app
+// or a recovery code (POST /auth/2fa/verify)
+func (c *Client) VerifyTwoFactor(ctx context.Context, challenge, code string) (*models.LoginResult, error) {
+	var result models.LoginResult
+	input := models.TwoFactorLogin{Challenge: challenge, Code: code}
+	if err := c.do(ctx, http.MethodPost, "/auth/2fa/verify", nil, input, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Logout revokes the session the client's token belongs to (DELETE /me/sessions/current)
+func (c *Client) Logout(ctx context.Context) error {
+	return c.do(ctx, http.MethodDelete, "/me/sessions/current", nil, nil, nil)
+}
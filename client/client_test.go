@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gin-demo-api/config"
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+	"gin-demo-api/router"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// startServer serves the full router over a fresh SQLite database. Requests and
+// responses are validated against docs/swagger.json, so a client call that does
+// not match the document fails with a 400 or 500.
+func startServer(t *testing.T) string {
+	t.Helper()
+	testdb.Open(t)
+
+	gin.SetMode(gin.TestMode)
+	cfg := config.Load()
+	cfg.OpenAPI.ValidateResponses = true
+	cfg.RateLimit.Default = "off"
+	handler, err := router.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestUsersAndTodos(t *testing.T) {
+	server := startServer(t)
+	ctx := context.Background()
+
+	user, err := New(server).CreateUser(ctx, UserInput{Username: "alice", Email: "alice@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Accounts are changed by their own user
+	token, _ := testdb.Session(t, user.ID, false)
+	api := New(server, WithToken(token))
+	if _, err := New(server).UpdateUser(ctx, user.ID, UserInput{Email: "alice@new.example.com"}); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("anonymous UpdateUser: got %v, want ErrUnauthorized", err)
+	}
+	if user, err = api.UpdateUser(ctx, user.ID, UserInput{Email: "alice@new.example.com"}); err != nil || user.Email != "alice@new.example.com" {
+		t.Fatalf("UpdateUser = %+v, %v", user, err)
+	}
+	if _, err := api.CreateUser(ctx, UserInput{Username: "alice", Email: "other@example.com"}); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("duplicate username: got %v, want ErrBadRequest", err)
+	}
+
+	for _, item := range []string{"Buy milk", "Walk the dog", "Call mum", "Pay rent", "Water plants"} {
+		if _, err := api.CreateTodo(ctx, TodoInput{Item: item, Description: "- **" + item + "**", UserID: user.ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	todo, err := api.UpdateTodo(ctx, 2, TodoUpdate{Completed: Bool(true), Item: String("Walk the cat")})
+	if err != nil || !todo.Completed || todo.Item != "Walk the cat" {
+		t.Fatalf("UpdateTodo = %+v, %v", todo, err)
+	}
+	if todo, err = api.GetTodo(ctx, 2); err != nil || todo.Item != "Walk the cat" {
+		t.Fatalf("GetTodo = %+v, %v", todo, err)
+	}
+
+	// Todos pages through every open todo
+	var items []string
+	for todo, err := range api.Todos(ctx, TodoListOptions{UserIDs: []uint{user.ID}, Completed: Bool(false), Limit: 2}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, todo.Item)
+	}
+	if len(items) != 4 || items[0] != "Buy milk" || items[3] != "Water plants" {
+		t.Errorf("Todos = %q, want the 4 open todos in ID order", items)
+	}
+
+	users, err := api.ListUsers(ctx)
+	if err != nil || len(users) != 1 || len(users[0].Todos) != 5 {
+		t.Fatalf("ListUsers = %+v, %v", users, err)
+	}
+	if results, err := api.SearchTodos(ctx, "rent", 10); err != nil || len(results) != 1 || results[0].Item != "Pay rent" {
+		t.Errorf("SearchTodos = %+v, %v", results, err)
+	}
+	if history, err := api.TodoHistory(ctx, 2); err != nil || len(history) != 2 {
+		t.Errorf("TodoHistory = %+v, %v; want the create and the update", history, err)
+	}
+
+	if err := api.DeleteTodo(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.GetTodo(ctx, 2); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetTodo after delete: got %v, want ErrNotFound", err)
+	}
+	if err := api.DeleteUser(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(server).GetUser(ctx, user.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetUser after delete: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestLoginAndLogout(t *testing.T) {
+	api := New(startServer(t))
+	ctx := context.Background()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	user := models.User{Username: "alice", Email: "alice@example.com", Role: models.RoleUser, PasswordHash: string(hash)}
+	if err := db.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Login(ctx, "alice", "wrong"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("wrong password: got %v, want ErrUnauthorized", err)
+	}
+	login, err := api.Login(ctx, "alice@example.com", "correct horse")
+	if err != nil || login.Token == "" {
+		t.Fatalf("Login = %+v, %v", login, err)
+	}
+
+	session := New(api.baseURL, WithToken(login.Token), WithRetries(0))
+	if err := session.Logout(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Logout(ctx); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("after logout: got %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	attempts := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts[r.Method]++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":"Rate limit exceeded"}`))
+	}))
+	defer server.Close()
+
+	api := New(server.URL, WithRetries(2), WithBackoff(time.Millisecond))
+	_, err := api.GetTodo(context.Background(), 1)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "Rate limit exceeded" || !errors.Is(err, ErrRateLimited) {
+		t.Errorf("GetTodo: got %v, want the 429 as an *APIError", err)
+	}
+	api.CreateTodo(context.Background(), TodoInput{Item: "Buy milk", UserID: 1})
+
+	if attempts[http.MethodGet] != 3 || attempts[http.MethodPost] != 1 {
+		t.Errorf("attempts = %v, want 3 GETs and 1 POST", attempts)
+	}
+}
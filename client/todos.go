@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"gin-demo-api/models"
+)
+
+// TodoInput is the body of CreateTodo
+type TodoInput struct {
+	Item        string `json:"item"`
+	Description string `json:"description,omitempty"`
+	Completed   bool   `json:"completed,omitempty"`
+	UserID      uint   `json:"user_id"`
+}
+
+// TodoUpdate is the body of UpdateTodo; nil fields are left unchanged
+type TodoUpdate struct {
+	Item        *string `json:"item,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Completed   *bool   `json:"completed,omitempty"`
+	UserID      *uint   `json:"user_id,omitempty"`
+}
+
+// TodoListOptions filters and pages ListTodos; zero values mean no restriction
+type TodoListOptions struct {
+	UserIDs   []uint
+	Completed *bool
+	Limit     int // Page size; Todos defaults to 100
+	Offset    int
+}
+
+// CreateTodo creates a todo (POST /todos)
+func (c *Client) CreateTodo(ctx context.Context, input TodoInput) (*models.Todo, error) {
+	var todo models.Todo
+	if err := c.do(ctx, http.MethodPost, "/todos", nil, input, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// ListTodos returns one page of todos ordered by ID (GET /todos)
+func (c *Client) ListTodos(ctx context.Context, opts TodoListOptions) ([]models.Todo, error) {
+	query := url.Values{}
+	if len(opts.UserIDs) > 0 {
+		ids := make([]string, len(opts.UserIDs))
+		for i, id := range opts.UserIDs {
+			ids[i] = strconv.FormatUint(uint64(id), 10)
+		}
+		query.Set("user_id", strings.Join(ids, ","))
+	}
+	if opts.Completed != nil {
+		query.Set("completed", strconv.FormatBool(*opts.Completed))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		query.Set("offset", strconv.Itoa(opts.Offset))
+	}
+
+	var todos []models.Todo
+	if err := c.do(ctx, http.MethodGet, "/todos", query, nil, &todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// Todos iterates over all matching todos, fetching them a page at a time.
+// Iteration stops after the first error.
+func (c *Client) Todos(ctx context.Context, opts TodoListOptions) iter.Seq2[models.Todo, error] {
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+	return func(yield func(models.Todo, error) bool) {
+		for {
+			page, err := c.ListTodos(ctx, opts)
+			if err != nil {
+				yield(models.Todo{}, err)
+				return
+			}
+			for _, todo := range page {
+				if !yield(todo, nil) {
+					return
+				}
+			}
+			if len(page) < opts.Limit {
+				return
+			}
+			opts.Offset += len(page)
+		}
+	}
+}
+
+// GetTodo returns one todo (GET /todos/:id)
+func (c *Client) GetTodo(ctx context.Context, id uint) (*models.Todo, error) {
+	var todo models.Todo
+	if err := c.do(ctx, http.MethodGet, idPath("/todos", id), nil, nil, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// UpdateTodo changes the fields set in update (PATCH /todos/:id)
+func (c *Client) UpdateTodo(ctx context.Context, id uint, update TodoUpdate) (*models.Todo, error) {
+	var todo models.Todo
+	if err := c.do(ctx, http.MethodPatch, idPath("/todos", id), nil, update, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// DeleteTodo soft-deletes a todo (DELETE /todos/:id)
+func (c *Client) DeleteTodo(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodDelete, idPath("/todos", id), nil, nil, nil)
+}
+
+// SearchTodos runs a ranked full-text search (GET /todos/search)
+func (c *Client) SearchTodos(ctx context.Context, q string, limit int) ([]models.TodoSearchResult, error) {
+	query := url.Values{"q": {q}}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var results []models.TodoSearchResult
+	if err := c.do(ctx, http.MethodGet, "/todos/search", query, nil, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// TodoHistory returns the audit events of a todo, oldest first (GET /todos/:id/history)
+func (c *Client) TodoHistory(ctx context.Context, id uint) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	if err := c.do(ctx, http.MethodGet, idPath("/todos", id)+"/history", nil, nil, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"gin-demo-api/models"
+)
+
+// UserInput is the body of CreateUser and UpdateUser; empty fields are left unchanged on update
+type UserInput struct {
+	Username string `json:"username,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
+
+// CreateUser creates a user (POST /users)
+func (c *Client) CreateUser(ctx context.Context, input UserInput) (*models.User, error) {
+	var user models.User
+	if err := c.do(ctx, http.MethodPost, "/users", nil, input, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListUsers returns all users with their todos (GET /users)
+func (c *Client) ListUsers(ctx context.Context) ([]models.User, error) {
+	var users []models.User
+	if err := c.do(ctx, http.MethodGet, "/users", nil, nil, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetUser returns one user with their todos (GET /users/:id)
+func (c *Client) GetUser(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := c.do(ctx, http.MethodGet, idPath("/users", id), nil, nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUser changes the username and/or email (PATCH /users/:id)
+func (c *Client) UpdateUser(ctx context.Context, id uint, input UserInput) (*models.User, error) {
+	var user models.User
+	if err := c.do(ctx, http.MethodPatch, idPath("/users", id), nil, input, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// DeleteUser soft-deletes a user, keeping their todos (DELETE /users/:id)
+func (c *Client) DeleteUser(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodDelete, idPath("/users", id), nil, nil, nil)
+}
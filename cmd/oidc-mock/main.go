@@ -0,0 +1,43 @@
+// Command oidc-mock is an OpenID Connect provider for local development. It
+// approves every login without asking, as the user given by the login_hint
+// parameter or $MOCK_EMAIL, so the API's OIDC login can be tried without a
+// real identity provider.
+//
+//	go run ./cmd/oidc-mock
+//	OIDC_PROVIDERS=mock OIDC_MOCK_ISSUER=http://localhost:9000 OIDC_MOCK_CLIENT_ID=todo go run .
+//	open http://localhost:8080/v1/auth/oidc/mock/login
+//
+// The provider itself is sso/ssotest, which the API's tests use too.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"gin-demo-api/sso/ssotest"
+)
+
+func main() {
+	addr := flag.String("addr", envOr("MOCK_ADDR", ":9000"), "listen address")
+	issuer := flag.String("issuer", envOr("MOCK_ISSUER", "http://localhost:9000"), "issuer URL, as the API reaches this server")
+	flag.Parse()
+
+	provider, err := ssotest.New(*issuer)
+	if err != nil {
+		log.Fatal(err)
+	}
+	provider.DefaultEmail = envOr("MOCK_EMAIL", provider.DefaultEmail)
+	provider.Logger = log.Default()
+
+	log.Printf("Mock OIDC provider %s listening on %s", provider.Issuer, *addr)
+	log.Fatal(http.ListenAndServe(*addr, provider))
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
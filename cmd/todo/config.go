@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// config is stored as JSON in the user's config directory
+type config struct {
+	Server string `json:"server"`
+	Token  string `json:"token,omitempty"`
+	UserID uint   `json:"user_id,omitempty"`
+}
+
+// configKeys are the keys accepted by "todo config set"
+var configKeys = []string{"server", "token", "user-id"}
+
+// configPath returns $TODO_CONFIG or <user config dir>/todo/config.json
+func configPath() (string, error) {
+	if path := os.Getenv("TODO_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "todo", "config.json"), nil
+}
+
+// loadConfig reads the config file; a missing file gives the defaults
+func loadConfig() (config, error) {
+	cfg := config{Server: "http://localhost:8080"}
+	path, err := configPath()
+	if err != nil {
+		return cfg, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// saveConfig writes the config file, readable only by the user since it may hold a token
+func saveConfig(cfg config) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+func configCommand() *cobra.Command {
+	cmd := &cobra.Command{Use: "config", Short: "Show or change the local configuration"}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "Print the configuration and where it is stored",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			path, _ := configPath()
+			if cfg.Token != "" {
+				cfg.Token = "********"
+			}
+			if outputFlag == "json" {
+				return printJSON(cfg)
+			}
+			fmt.Fprintf(stdout, "file:    %s\nserver:  %s\ntoken:   %s\nuser-id: %d\n", path, cfg.Server, cfg.Token, cfg.UserID)
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:       "set <key> <value>",
+		Short:     "Set server, token or user-id",
+		Args:      cobra.ExactArgs(2),
+		ValidArgs: configKeys,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			switch args[0] {
+			case "server":
+				cfg.Server = args[1]
+			case "token":
+				cfg.Token = args[1]
+			case "user-id":
+				id, err := strconv.ParseUint(args[1], 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid user ID %q", args[1])
+				}
+				cfg.UserID = uint(id)
+			default:
+				return fmt.Errorf("unknown key %q, expected one of %v", args[0], configKeys)
+			}
+			return saveConfig(cfg)
+		},
+	})
+
+	return cmd
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func loginCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "login <username-or-email>",
+		Short: "Log in with a password and store the session token",
+		Long:  "Asks for the password (and a two-factor code if the account has one), then saves the session token and user ID to the config file.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			input := bufio.NewReader(stdin)
+			password, err := promptPassword(input)
+			if err != nil {
+				return err
+			}
+			result, err := api.Login(cmd.Context(), args[0], password)
+			if err != nil {
+				return err
+			}
+			if result.TwoFactorRequired {
+				code, err := prompt(input, "Two-factor code: ")
+				if err != nil {
+					return err
+				}
+				if result, err = api.VerifyTwoFactor(cmd.Context(), result.Challenge, code); err != nil {
+					return err
+				}
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if serverFlag != "" {
+				cfg.Server = serverFlag
+			}
+			cfg.Token = result.Token
+			cfg.UserID = result.User.ID
+			if err := saveConfig(cfg); err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "Logged in as %s until %s\n", result.User.Username, result.ExpiresAt.Local().Format("2006-01-02 15:04"))
+			return nil
+		},
+	}
+}
+
+func logoutCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "Revoke the stored session token and remove it from the config file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if cfg.Token == "" {
+				return errors.New("not logged in")
+			}
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(cfg.Token, "gds_") {
+				if err := api.Logout(cmd.Context()); err != nil {
+					return err
+				}
+			}
+			cfg.Token, cfg.UserID = "", 0
+			return saveConfig(cfg)
+		},
+	}
+}
+
+// promptPassword reads the password without echoing it when stdin is a terminal
+func promptPassword(input *bufio.Reader) (string, error) {
+	file, ok := stdin.(*os.File)
+	if !ok || !term.IsTerminal(int(file.Fd())) {
+		return prompt(input, "Password: ")
+	}
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := term.ReadPassword(int(file.Fd()))
+	fmt.Fprintln(os.Stderr)
+	return string(password), err
+}
+
+// prompt asks for one line of input on stderr, so it stays out of piped output
+func prompt(input *bufio.Reader, label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	line, err := input.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
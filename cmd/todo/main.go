@@ -0,0 +1,88 @@
+// Command todo manages todos and users through the API.
+//
+//	todo config set server http://localhost:8080
+//	todo login alice
+//	todo add "Buy milk" --description "- oat milk"
+//	todo list --open
+//	todo complete 3
+//
+// Run "todo completion --help" to set up shell completion.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gin-demo-api/client"
+
+	"github.com/spf13/cobra"
+)
+
+// Global flags; empty values fall back to the config file
+var (
+	serverFlag string
+	tokenFlag  string
+	userFlag   uint
+	outputFlag string
+)
+
+func main() {
+	if err := rootCommand().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+// rootCommand builds the command tree, with every flag back at its default
+func rootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:           "todo",
+		Short:         "Manage todos and users from the command line",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.PersistentFlags().StringVar(&serverFlag, "server", "", "API base URL (default from config, then http://localhost:8080)")
+	root.PersistentFlags().StringVar(&tokenFlag, "token", "", "Session token or API key")
+	root.PersistentFlags().UintVar(&userFlag, "user-id", 0, "Owner of new todos and of --mine (default: the user of \"todo login\")")
+	root.PersistentFlags().StringVarP(&outputFlag, "output", "o", "table", "Output format: table or json")
+	root.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{"table", "json"}, cobra.ShellCompDirectiveNoFileComp))
+
+	root.AddCommand(todoCommands()...)
+	root.AddCommand(userCommand(), configCommand(), loginCommand(), logoutCommand())
+	return root
+}
+
+// newClient builds an API client from the flags and config file
+func newClient() (*client.Client, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if serverFlag != "" {
+		cfg.Server = serverFlag
+	}
+	if tokenFlag != "" {
+		cfg.Token = tokenFlag
+	}
+
+	var opts []client.Option
+	if cfg.Token != "" {
+		opts = append(opts, client.WithToken(cfg.Token))
+	}
+	return client.New(cfg.Server, opts...), nil
+}
+
+// currentUser returns the user to own new todos, by default the one logged in with "todo login"
+func currentUser() (uint, error) {
+	if userFlag != 0 {
+		return userFlag, nil
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return 0, err
+	}
+	if cfg.UserID == 0 {
+		return 0, fmt.Errorf("no user set: run \"todo login\" or pass --user-id")
+	}
+	return cfg.UserID, nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	serverconfig "gin-demo-api/config"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+	"gin-demo-api/router"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// startServer serves the full router over a fresh database and points the CLI's
+// config file at a temporary directory
+func startServer(t *testing.T) string {
+	t.Helper()
+	testdb.Open(t)
+	t.Setenv("TODO_CONFIG", filepath.Join(t.TempDir(), "config.json"))
+
+	gin.SetMode(gin.TestMode)
+	cfg := serverconfig.Load()
+	cfg.RateLimit.Default = "off"
+	handler, err := router.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// run executes one todo command line with input on stdin and returns what it printed
+func run(t *testing.T, input string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	stdin, stdout = strings.NewReader(input), &out
+	t.Cleanup(func() { stdin, stdout = os.Stdin, os.Stdout })
+
+	root := rootCommand()
+	root.SetArgs(args)
+	err := root.Execute()
+	return out.String(), err
+}
+
+// mustRun is run for commands expected to succeed
+func mustRun(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := run(t, "", args...)
+	if err != nil {
+		t.Fatalf("todo %s: %v", strings.Join(args, " "), err)
+	}
+	return out
+}
+
+func TestTodoCommands(t *testing.T) {
+	server := startServer(t)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	alice := testdb.CreateUser(t, models.User{Username: "alice", PasswordHash: string(hash)})
+
+	mustRun(t, "config", "set", "server", server)
+	if _, err := run(t, "wrong\n", "login", "alice"); err == nil {
+		t.Fatal("login with a wrong password succeeded")
+	}
+	if out, err := run(t, "correct horse\n", "login", "alice"); err != nil || !strings.Contains(out, "Logged in as alice") {
+		t.Fatalf("login: %q, %v", out, err)
+	}
+	cfg, _ := loadConfig()
+	if cfg.Token == "" || cfg.UserID != alice.ID {
+		t.Fatalf("config after login = %+v, want alice's token and ID", cfg)
+	}
+	if out := mustRun(t, "config", "show"); !strings.Contains(out, "token:   ********") || strings.Contains(out, cfg.Token) {
+		t.Errorf("config show does not mask the token:\n%s", out)
+	}
+
+	// Todos are created for the logged-in user and printed as a table
+	if out := mustRun(t, "add", "Buy", "milk", "-d", "- oat"); !strings.Contains(out, "Buy milk") || !strings.Contains(out, "[ ]") {
+		t.Errorf("add printed:\n%s", out)
+	}
+	mustRun(t, "add", "Walk the dog")
+
+	var todos []models.Todo
+	if err := json.Unmarshal([]byte(mustRun(t, "list", "--mine", "-o", "json")), &todos); err != nil {
+		t.Fatal(err)
+	}
+	if len(todos) != 2 || todos[0].Description != "- oat" || todos[0].UserID != alice.ID {
+		t.Fatalf("list -o json = %+v, want alice's two todos", todos)
+	}
+
+	if out := mustRun(t, "complete", "1"); !strings.Contains(out, "[x]") {
+		t.Errorf("complete printed:\n%s", out)
+	}
+	if out := mustRun(t, "list", "--done"); !strings.Contains(out, "Buy milk") || strings.Contains(out, "Walk the dog") {
+		t.Errorf("list --done:\n%s", out)
+	}
+	if out := mustRun(t, "list", "--open"); strings.Contains(out, "Buy milk") || !strings.Contains(out, "Walk the dog") {
+		t.Errorf("list --open:\n%s", out)
+	}
+	if _, err := run(t, "", "list", "--done", "--open"); err == nil {
+		t.Error("list --done --open succeeded")
+	}
+
+	if out := mustRun(t, "edit", "2", "--item", "Walk the cat"); !strings.Contains(out, "Walk the cat") {
+		t.Errorf("edit printed:\n%s", out)
+	}
+	if _, err := run(t, "", "edit", "2"); err == nil || !strings.Contains(err.Error(), "nothing to change") {
+		t.Errorf("edit without flags: got %v", err)
+	}
+	if _, err := run(t, "", "complete", "x"); err == nil || !strings.Contains(err.Error(), `invalid ID "x"`) {
+		t.Errorf("complete x: got %v", err)
+	}
+
+	mustRun(t, "delete", "1", "2")
+	if out := mustRun(t, "list", "-o", "json"); strings.TrimSpace(out) != "[]" {
+		t.Errorf("list after delete: %s", out)
+	}
+	if _, err := run(t, "", "delete", "1"); err == nil || !strings.Contains(err.Error(), "todo 1") {
+		t.Errorf("deleting a deleted todo: got %v", err)
+	}
+
+	// Logging out revokes the session and forgets it
+	token := cfg.Token
+	mustRun(t, "logout")
+	if cfg, _ := loadConfig(); cfg.Token != "" || cfg.UserID != 0 {
+		t.Errorf("config after logout = %+v", cfg)
+	}
+	if _, err := run(t, "", "--token", token, "add", "--user-id", "1", "Too late"); err == nil {
+		t.Error("the revoked token still works")
+	}
+	if _, err := run(t, "", "logout"); err == nil || err.Error() != "not logged in" {
+		t.Errorf("second logout: got %v", err)
+	}
+}
+
+func TestUserCommands(t *testing.T) {
+	server := startServer(t)
+	_, token := testdb.Login(t, "root")
+
+	args := func(args ...string) []string {
+		return append([]string{"--server", server, "--token", token}, args...)
+	}
+	if out := mustRun(t, args("user", "add", "bob", "bob@example.com")...); !strings.Contains(out, "bob@example.com") {
+		t.Errorf("user add printed:\n%s", out)
+	}
+	if out := mustRun(t, args("user", "list")...); !strings.Contains(out, "USERNAME") || !strings.Contains(out, "bob") || !strings.Contains(out, "root") {
+		t.Errorf("user list printed:\n%s", out)
+	}
+	if _, err := run(t, "", args("user", "add", "bob", "not-an-email")...); err == nil {
+		t.Error("user add with an invalid email succeeded")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gin-demo-api/models"
+)
+
+// Where commands read input and print results; tests replace them
+var (
+	stdin  io.Reader = os.Stdin
+	stdout io.Writer = os.Stdout
+)
+
+// printJSON writes v as indented JSON
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// printTable writes rows under a header, aligned in columns
+func printTable(header []string, rows [][]string) error {
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// printTodo writes one todo in the selected output format
+func printTodo(todo models.Todo) error {
+	if outputFlag == "json" {
+		return printJSON(todo)
+	}
+	return printTodos([]models.Todo{todo})
+}
+
+// printTodos writes a list of todos in the selected output format
+func printTodos(todos []models.Todo) error {
+	if outputFlag == "json" {
+		return printJSON(todos)
+	}
+	rows := make([][]string, len(todos))
+	for i, todo := range todos {
+		done := " "
+		if todo.Completed {
+			done = "x"
+		}
+		rows[i] = []string{
+			strconv.FormatUint(uint64(todo.ID), 10),
+			"[" + done + "]",
+			todo.Item,
+			strconv.FormatUint(uint64(todo.UserID), 10),
+			todo.UpdatedAt.Local().Format("2006-01-02 15:04"),
+		}
+	}
+	return printTable([]string{"ID", "DONE", "ITEM", "USER", "UPDATED"}, rows)
+}
+
+// printUser writes one user in the selected output format
+func printUser(user models.User) error {
+	if outputFlag == "json" {
+		return printJSON(user)
+	}
+	return printUsers([]models.User{user})
+}
+
+// printUsers writes a list of users in the selected output format
+func printUsers(users []models.User) error {
+	if outputFlag == "json" {
+		return printJSON(users)
+	}
+	rows := make([][]string, len(users))
+	for i, user := range users {
+		rows[i] = []string{
+			strconv.FormatUint(uint64(user.ID), 10),
+			user.Username,
+			user.Email,
+			strconv.Itoa(len(user.Todos)),
+		}
+	}
+	return printTable([]string{"ID", "USERNAME", "EMAIL", "TODOS"}, rows)
+}
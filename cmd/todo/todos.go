@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gin-demo-api/client"
+	"gin-demo-api/models"
+
+	"github.com/spf13/cobra"
+)
+
+func todoCommands() []*cobra.Command {
+	return []*cobra.Command{addCommand(), listCommand(), completeCommand(true), completeCommand(false), editCommand(), deleteCommand()}
+}
+
+func addCommand() *cobra.Command {
+	var description string
+	cmd := &cobra.Command{
+		Use:   "add <item>",
+		Short: "Add a todo for the current user",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			userID, err := currentUser()
+			if err != nil {
+				return err
+			}
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			todo, err := api.CreateTodo(cmd.Context(), client.TodoInput{Item: strings.Join(args, " "), Description: description, UserID: userID})
+			if err != nil {
+				return err
+			}
+			return printTodo(*todo)
+		},
+	}
+	cmd.Flags().StringVarP(&description, "description", "d", "", "Markdown description")
+	return cmd
+}
+
+func listCommand() *cobra.Command {
+	var (
+		userIDs          []uint
+		mine, done, open bool
+		limit            int
+	)
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List todos",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if done && open {
+				return fmt.Errorf("--done and --open are mutually exclusive")
+			}
+			opts := client.TodoListOptions{UserIDs: userIDs}
+			if mine {
+				userID, err := currentUser()
+				if err != nil {
+					return err
+				}
+				opts.UserIDs = append(opts.UserIDs, userID)
+			}
+			if done || open {
+				opts.Completed = client.Bool(done)
+			}
+
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			todos := []models.Todo{}
+			for todo, err := range api.Todos(cmd.Context(), opts) {
+				if err != nil {
+					return err
+				}
+				todos = append(todos, todo)
+				if limit > 0 && len(todos) == limit {
+					break
+				}
+			}
+			return printTodos(todos)
+		},
+	}
+	cmd.Flags().UintSliceVarP(&userIDs, "user", "u", nil, "Only todos of these user IDs")
+	cmd.Flags().BoolVarP(&mine, "mine", "m", false, "Only todos of the current user")
+	cmd.Flags().BoolVar(&done, "done", false, "Only completed todos")
+	cmd.Flags().BoolVar(&open, "open", false, "Only open todos")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 0, "Show at most this many todos")
+	return cmd
+}
+
+// completeCommand marks todos completed ("complete") or open again ("reopen")
+func completeCommand(completed bool) *cobra.Command {
+	use, short := "complete <id>...", "Mark todos as completed"
+	if !completed {
+		use, short = "reopen <id>...", "Mark todos as not completed"
+	}
+	return &cobra.Command{
+		Use:               use,
+		Short:             short,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeTodoIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			todos := []models.Todo{}
+			for _, id := range ids {
+				todo, err := api.UpdateTodo(cmd.Context(), id, client.TodoUpdate{Completed: client.Bool(completed)})
+				if err != nil {
+					return fmt.Errorf("todo %d: %w", id, err)
+				}
+				todos = append(todos, *todo)
+			}
+			return printTodos(todos)
+		},
+	}
+}
+
+func editCommand() *cobra.Command {
+	var (
+		item, description string
+		userID            uint
+	)
+	cmd := &cobra.Command{
+		Use:               "edit <id>",
+		Short:             "Change a todo's item, description or owner",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeTodoIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+			var update client.TodoUpdate
+			if cmd.Flags().Changed("item") {
+				update.Item = &item
+			}
+			if cmd.Flags().Changed("description") {
+				update.Description = &description
+			}
+			if cmd.Flags().Changed("owner") {
+				update.UserID = &userID
+			}
+			if update == (client.TodoUpdate{}) {
+				return fmt.Errorf("nothing to change: pass --item, --description or --owner")
+			}
+
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			todo, err := api.UpdateTodo(cmd.Context(), ids[0], update)
+			if err != nil {
+				return err
+			}
+			return printTodo(*todo)
+		},
+	}
+	cmd.Flags().StringVar(&item, "item", "", "New item text")
+	cmd.Flags().StringVarP(&description, "description", "d", "", "New Markdown description")
+	cmd.Flags().UintVar(&userID, "owner", 0, "New owner user ID")
+	return cmd
+}
+
+func deleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "delete <id>...",
+		Aliases:           []string{"rm"},
+		Short:             "Delete todos",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeTodoIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if err := api.DeleteTodo(cmd.Context(), id); err != nil {
+					return fmt.Errorf("todo %d: %w", id, err)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// completeTodoIDs offers the open todos' IDs, described by their item, for shell completion
+func completeTodoIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	api, err := newClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	todos, err := api.ListTodos(cmd.Context(), client.TodoListOptions{Completed: client.Bool(false), Limit: 500})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var completions []string
+	for _, todo := range todos {
+		completions = append(completions, fmt.Sprintf("%d\t%s", todo.ID, todo.Item))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// parseIDs parses ID arguments
+func parseIDs(args []string) ([]uint, error) {
+	ids := make([]uint, len(args))
+	for i, arg := range args {
+		id, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID %q", arg)
+		}
+		ids[i] = uint(id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+
+	"gin-demo-api/client"
+
+	"github.com/spf13/cobra"
+)
+
+func userCommand() *cobra.Command {
+	cmd := &cobra.Command{Use: "user", Short: "Manage users"}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "add <username> <email>",
+		Short: "Create a user",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			user, err := api.CreateUser(cmd.Context(), client.UserInput{Username: args[0], Email: args[1]})
+			if err != nil {
+				return err
+			}
+			return printUser(*user)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List users",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			users, err := api.ListUsers(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return printUsers(users)
+		},
+	})
+
+	var username, email string
+	edit := &cobra.Command{
+		Use:               "edit <id>",
+		Short:             "Change a user's username or email",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeUserIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+			if username == "" && email == "" {
+				return fmt.Errorf("nothing to change: pass --username or --email")
+			}
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			user, err := api.UpdateUser(cmd.Context(), ids[0], client.UserInput{Username: username, Email: email})
+			if err != nil {
+				return err
+			}
+			return printUser(*user)
+		},
+	}
+	edit.Flags().StringVar(&username, "username", "", "New username")
+	edit.Flags().StringVar(&email, "email", "", "New email")
+	cmd.AddCommand(edit)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:               "delete <id>...",
+		Aliases:           []string{"rm"},
+		Short:             "Delete users (their todos are kept)",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeUserIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if err := api.DeleteUser(cmd.Context(), id); err != nil {
+					return fmt.Errorf("user %d: %w", id, err)
+				}
+			}
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+// completeUserIDs offers user IDs, described by username, for shell completion
+func completeUserIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	api, err := newClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	users, err := api.ListUsers(cmd.Context())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var completions []string
+	for _, user := range users {
+		completions = append(completions, fmt.Sprintf("%d\t%s", user.ID, user.Username))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,277 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the runtime settings, read from environment variables
+type Config struct {
+	DatabasePath string        // SQLite database file
+	HTTPAddr     string        // Listen address of the HTTP server
+	Proxies      []string      // Reverse proxies (IPs or CIDRs) whose X-Forwarded-For is trusted for the client IP; none by default
+	GRPCAddr     string        // Listen address of the gRPC server; empty disables it
+	LegacySunset time.Time     // When the unversioned route aliases of /v1 are removed
+	PublicURL    string        // Base URL of the API as clients reach it, for links in emails
+	TokenSecret  string        // Key signing email verification and password reset tokens
+	SessionTTL   time.Duration // How long a login stays valid
+	SessionCache time.Duration // How long the auth middleware trusts a session it looked up; 0 disables the cache
+	DevUserID    bool          // Development only: accept the unverified X-User-ID header as authentication
+	OpenAPI      OpenAPIConfig
+	Tracing      TracingConfig
+	Log          LogConfig
+	Shutdown     ShutdownConfig
+	RateLimit    RateLimitConfig
+	Mail         MailConfig
+	Webhooks     WebhookConfig
+	OIDC         []OIDCProviderConfig
+	TwoFactor    TwoFactorConfig
+	Storage      StorageConfig
+	Attachments  AttachmentConfig
+}
+
+// TwoFactorConfig configures TOTP two-factor authentication
+type TwoFactorConfig struct {
+	Issuer        string   // Account label shown in authenticator apps
+	RequiredRoles []string // Roles that must use two-factor authentication for their privileges
+}
+
+// WebhookConfig controls where webhooks may deliver to
+type WebhookConfig struct {
+	AllowPrivateTargets bool // Allow loopback, private and link-local URLs, e.g. for local development
+}
+
+// MailConfig selects and configures how emails are sent
+type MailConfig struct {
+	Driver string // "log", "file" or "smtp"
+	From   string // Sender address, e.g. "Todos <no-reply@example.com>"
+	Dir    string // Directory the file driver writes .eml files to
+
+	// SMTP relay; STARTTLS is used when the server offers it
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// OIDCProviderConfig is an OpenID Connect identity provider users can sign in with
+type OIDCProviderConfig struct {
+	Name         string // Identifier used in URLs, e.g. "company"
+	DisplayName  string // Shown to users, e.g. "Company SSO"
+	Issuer       string // Issuer URL; the configuration is discovered from it
+	ClientID     string
+	ClientSecret string   // Empty for public clients, which rely on PKCE alone
+	Scopes       []string // Requested besides "openid"
+	Signup       bool     // Whether unknown users are created on first login
+}
+
+// StorageConfig selects and configures the blob store for attachments
+type StorageConfig struct {
+	Driver string // "local" or "s3"
+	Dir    string // Root directory for the local driver
+
+	// S3-compatible object storage (AWS S3, MinIO, ...)
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// AttachmentConfig limits what can be uploaded to a todo
+type AttachmentConfig struct {
+	MaxSize      int64    // Bytes
+	AllowedTypes []string // MIME types, "image/*" style wildcards allowed
+}
+
+// OpenAPIConfig controls validation against the OpenAPI document
+type OpenAPIConfig struct {
+	ValidateResponses bool // Replace responses that do not match the document with a 500
+}
+
+// TracingConfig selects where OpenTelemetry spans are sent
+type TracingConfig struct {
+	Exporter    string // "none", "stdout" or "otlp" (endpoint from OTEL_EXPORTER_OTLP_ENDPOINT)
+	ServiceName string
+}
+
+// LogConfig controls the structured log output
+type LogConfig struct {
+	Level     string        // "debug", "info", "warn" or "error"
+	Format    string        // "json" or "text"
+	SlowQuery time.Duration // Statements slower than this are logged as warnings; 0 disables
+}
+
+// ShutdownConfig controls how the server stops on SIGINT/SIGTERM
+type ShutdownConfig struct {
+	Delay   time.Duration // Time between failing /readyz and closing the listener
+	Timeout time.Duration // Time allowed for in-flight requests to finish
+}
+
+// RateLimitConfig holds the per-client request limits, e.g. "600/m"
+type RateLimitConfig struct {
+	Default      string   // Limit across the whole API; "off" disables rate limiting
+	Routes       []string // Overrides such as "POST /todos=60/m", with their own buckets
+	AuthFailures string   // Failed authentication attempts per client IP
+}
+
+// Load reads the configuration from the environment, falling back to defaults
+func Load() Config {
+	return Config{
+		DatabasePath: getEnv("DB_PATH", "test.db"),
+		HTTPAddr:     getEnv("HTTP_ADDR", "localhost:8080"),
+		Proxies:      getEnvList("TRUSTED_PROXIES", ""),
+		GRPCAddr:     getEnv("GRPC_ADDR", "localhost:9090"),
+		LegacySunset: getEnvDate("LEGACY_ROUTES_SUNSET", "2027-04-30"),
+		PublicURL:    strings.TrimSuffix(getEnv("PUBLIC_URL", "http://localhost:8080"), "/"),
+		TokenSecret:  getEnv("TOKEN_SECRET", ""),
+		SessionTTL:   getEnvDuration("SESSION_TTL", 30*24*time.Hour),
+		SessionCache: getEnvDuration("SESSION_CACHE_TTL", 30*time.Second),
+		DevUserID:    getEnvBool("DEV_TRUST_USER_ID_HEADER", false),
+		OpenAPI: OpenAPIConfig{
+			// Always on under GIN_MODE=test so tests catch drift from the annotations
+			ValidateResponses: getEnvBool("OPENAPI_VALIDATE_RESPONSES", getEnv("GIN_MODE", "") == "test"),
+		},
+		Tracing: TracingConfig{
+			Exporter:    getEnv("TRACING_EXPORTER", "none"),
+			ServiceName: getEnv("OTEL_SERVICE_NAME", "gin-demo-api"),
+		},
+		Log: LogConfig{
+			Level:     getEnv("LOG_LEVEL", "info"),
+			Format:    getEnv("LOG_FORMAT", "json"),
+			SlowQuery: getEnvDuration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+		},
+		Shutdown: ShutdownConfig{
+			Delay:   getEnvDuration("SHUTDOWN_DELAY", 0),
+			Timeout: getEnvDuration("SHUTDOWN_TIMEOUT", 10*time.Second),
+		},
+		RateLimit: RateLimitConfig{
+			Default:      getEnv("RATE_LIMIT", "600/m"),
+			Routes:       getEnvList("RATE_LIMIT_ROUTES", "POST /todos=60/m,POST /auth/password-reset=5/h,POST /auth/email-verification=5/h,POST /auth/2fa/verify=10/m,POST /auth/login=10/m"),
+			AuthFailures: getEnv("RATE_LIMIT_AUTH_FAILURES", "20/m"),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "log"),
+			From:         getEnv("MAIL_FROM", "Gin Demo API <no-reply@localhost>"),
+			Dir:          getEnv("MAIL_DIR", "mail"),
+			SMTPAddr:     getEnv("SMTP_ADDR", "localhost:25"),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		},
+		Webhooks: WebhookConfig{
+			AllowPrivateTargets: getEnvBool("WEBHOOK_ALLOW_PRIVATE_TARGETS", false),
+		},
+		OIDC: loadOIDCProviders(),
+		TwoFactor: TwoFactorConfig{
+			Issuer:        getEnv("TOTP_ISSUER", "Gin Demo API"),
+			RequiredRoles: getEnvList("TWO_FACTOR_REQUIRED_ROLES", "admin"),
+		},
+		Storage: StorageConfig{
+			Driver:      getEnv("STORAGE_DRIVER", "local"),
+			Dir:         getEnv("STORAGE_DIR", "uploads"),
+			S3Endpoint:  getEnv("S3_ENDPOINT", "https://s3.amazonaws.com"),
+			S3Region:    getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:    getEnv("S3_BUCKET", ""),
+			S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+		},
+		Attachments: AttachmentConfig{
+			MaxSize:      getEnvInt("ATTACHMENT_MAX_SIZE", 10<<20),
+			AllowedTypes: getEnvList("ATTACHMENT_ALLOWED_TYPES", "image/*,application/pdf,text/plain,text/csv,application/json,application/zip"),
+		},
+	}
+}
+
+// loadOIDCProviders reads the providers named in OIDC_PROVIDERS, each configured
+// by OIDC_<NAME>_* variables
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range getEnvList("OIDC_PROVIDERS", "") {
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:         name,
+			DisplayName:  getEnv(prefix+"DISPLAY_NAME", name),
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       getEnvList(prefix+"SCOPES", "email,profile"),
+			Signup:       getEnvBool(prefix+"SIGNUP", true),
+		})
+	}
+	return providers
+}
+
+// Redacted returns a copy of the configuration that is safe to show, with secrets masked
+func (c Config) Redacted() Config {
+	redact := func(value string) string {
+		if value == "" {
+			return ""
+		}
+		return "[redacted]"
+	}
+	c.TokenSecret = redact(c.TokenSecret)
+	c.OIDC = append([]OIDCProviderConfig(nil), c.OIDC...)
+	for i := range c.OIDC {
+		c.OIDC[i].ClientSecret = redact(c.OIDC[i].ClientSecret)
+	}
+	c.Mail.SMTPPassword = redact(c.Mail.SMTPPassword)
+	c.Storage.S3AccessKey = redact(c.Storage.S3AccessKey)
+	c.Storage.S3SecretKey = redact(c.Storage.S3SecretKey)
+	return c
+}
+
+// getEnv returns the environment variable or the fallback when unset
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// getEnvInt parses an integer environment variable, using the fallback when unset or invalid
+func getEnvInt(key string, fallback int64) int64 {
+	value, err := strconv.ParseInt(getEnv(key, ""), 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvBool parses a boolean environment variable, using the fallback when unset or invalid
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(getEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvDuration parses a duration such as "200ms", using the fallback when unset or invalid
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(getEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvDate parses a YYYY-MM-DD environment variable as midnight UTC, using the fallback when unset or invalid
+func getEnvDate(key, fallback string) time.Time {
+	date, err := time.Parse(time.DateOnly, getEnv(key, fallback))
+	if err != nil {
+		date, _ = time.Parse(time.DateOnly, fallback)
+	}
+	return date
+}
+
+// getEnvList splits a comma-separated environment variable
+func getEnvList(key, fallback string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnv(key, fallback), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
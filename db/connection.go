@@ -1,29 +1,68 @@
 package db
 
 import (
-	"log"
+	"context"
+	"fmt"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 
 	"gin-demo-api/models"
 )
 
 var DB *gorm.DB
 
-// ConnectDatabase initializes the database connection and runs migrations
-func ConnectDatabase() {
-	database, err := gorm.Open(sqlite.Open("test.db"), &gorm.Config{})
+// Logger receives GORM's query log; set it before Open
+var Logger logger.Interface = logger.Default
 
+// Open connects DB to the SQLite database at path without migrating it
+func Open(path string) error {
+	database, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: Logger})
 	if err != nil {
-		log.Fatal("Failed to connect to database!")
+		return err
 	}
 
-	// AutoMigrate creates the table based on the Todo struct
-	err = database.AutoMigrate(&models.Todo{}, &models.User{})
+	DB = database
+	return nil
+}
+
+// schema lists the models whose tables Migrate manages
+func schema() []interface{} {
+	return []interface{}{&models.Todo{}, &models.User{}, &models.Comment{}, &models.Mention{}, &models.Attachment{}, &models.AuditEvent{}, &models.ChangeEvent{}, &models.Webhook{}, &models.WebhookDelivery{}, &models.APIKey{}, &models.Session{}, &models.Identity{}, &models.RecoveryCode{}, &models.TwoFactorChallenge{}}
+}
+
+// Migrate brings the schema of DB up to date
+func Migrate() error {
+	// AutoMigrate creates the tables based on the model structs
+	err := DB.AutoMigrate(schema()...)
 	if err != nil {
-		log.Fatal("Failed to run migrations!")
+		return err
 	}
 
-	DB = database
+	// Full-text index for GET /todos/search (SQLite FTS5 only)
+	setupTodoSearch(DB)
+	return nil
+}
+
+// CheckMigrations reports the first table or column of the models missing from the database,
+// e.g. when a newer binary runs against a database that was not migrated
+func CheckMigrations(ctx context.Context) error {
+	migrator := DB.WithContext(ctx).Migrator()
+	for _, model := range schema() {
+		statement := &gorm.Statement{DB: DB}
+		if err := statement.Parse(model); err != nil {
+			return err
+		}
+		table := statement.Schema.Table
+		if !migrator.HasTable(table) {
+			return fmt.Errorf("table %s is missing", table)
+		}
+		for _, field := range statement.Schema.Fields {
+			if field.DBName != "" && !migrator.HasColumn(model, field.DBName) {
+				return fmt.Errorf("column %s.%s is missing", table, field.DBName)
+			}
+		}
+	}
+	return nil
 }
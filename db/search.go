@@ -0,0 +1,100 @@
+package db
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// FTSEnabled reports whether the todos_fts full-text index is available.
+// It is false for non-SQLite drivers and for SQLite builds without FTS5
+// (build with `-tags sqlite_fts5` to enable it); search then falls back to LIKE.
+var FTSEnabled bool
+
+// todoSearchColumns lists the todos columns indexed by todos_fts
+var todoSearchColumns = []string{"item", "description"}
+
+// todoSearchTriggers are the triggers that keep todos_fts in sync with todos
+var todoSearchTriggers = []string{"todos_fts_ai", "todos_fts_ad", "todos_fts_au"}
+
+// setupTodoSearch creates the FTS5 index over todos and the triggers that keep it in sync
+func setupTodoSearch(database *gorm.DB) {
+	FTSEnabled = false
+	if database.Dialector.Name() != "sqlite" {
+		return
+	}
+
+	// A database indexed by an FTS5 build keeps its triggers when a build without FTS5
+	// opens it, and every write to todos would fail on them
+	var fts5 int64
+	database.Raw("SELECT COUNT(*) FROM pragma_module_list WHERE name = 'fts5'").Scan(&fts5)
+	if fts5 == 0 {
+		for _, trigger := range todoSearchTriggers {
+			if err := database.Exec("DROP TRIGGER IF EXISTS " + trigger).Error; err != nil {
+				slog.Error("Failed to drop full-text search trigger", "trigger", trigger, "err", err)
+			}
+		}
+		slog.Warn("Full-text search disabled, falling back to LIKE", "reason", "SQLite built without FTS5 (-tags sqlite_fts5)")
+		return
+	}
+
+	columns := strings.Join(todoSearchColumns, ", ")
+	createTable := fmt.Sprintf(
+		"CREATE VIRTUAL TABLE todos_fts USING fts5(%s, content='todos', content_rowid='id', tokenize='unicode61')",
+		columns,
+	)
+
+	// Recreate the index only when its definition changed (e.g. a column was added), or
+	// when its triggers are missing because a build without FTS5 wrote to todos since
+	var existing string
+	var triggers int64
+	database.Raw("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'todos_fts'").Scan(&existing)
+	database.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name IN ?", todoSearchTriggers).Scan(&triggers)
+
+	err := database.Transaction(func(tx *gorm.DB) error {
+		if existing != createTable || triggers != int64(len(todoSearchTriggers)) {
+			if err := tx.Exec("DROP TABLE IF EXISTS todos_fts").Error; err != nil {
+				return err
+			}
+			if err := tx.Exec(createTable).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("INSERT INTO todos_fts(todos_fts) VALUES ('rebuild')").Error; err != nil {
+				return err
+			}
+		}
+
+		oldValues := "old." + strings.Join(todoSearchColumns, ", old.")
+		newValues := "new." + strings.Join(todoSearchColumns, ", new.")
+		var statements []string
+		for _, trigger := range todoSearchTriggers {
+			statements = append(statements, "DROP TRIGGER IF EXISTS "+trigger)
+		}
+		statements = append(statements,
+			fmt.Sprintf(`CREATE TRIGGER todos_fts_ai AFTER INSERT ON todos BEGIN
+				INSERT INTO todos_fts(rowid, %[1]s) VALUES (new.id, %[2]s);
+			END`, columns, newValues),
+			fmt.Sprintf(`CREATE TRIGGER todos_fts_ad AFTER DELETE ON todos BEGIN
+				INSERT INTO todos_fts(todos_fts, rowid, %[1]s) VALUES ('delete', old.id, %[2]s);
+			END`, columns, oldValues),
+			fmt.Sprintf(`CREATE TRIGGER todos_fts_au AFTER UPDATE ON todos BEGIN
+				INSERT INTO todos_fts(todos_fts, rowid, %[1]s) VALUES ('delete', old.id, %[2]s);
+				INSERT INTO todos_fts(rowid, %[1]s) VALUES (new.id, %[3]s);
+			END`, columns, oldValues, newValues),
+		)
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Warn("Full-text search disabled, falling back to LIKE", "err", err)
+		return
+	}
+
+	FTSEnabled = true
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gin-demo-api/models"
+
+	"gorm.io/gorm/logger"
+)
+
+// TestSearchTriggersFollowTheBuild reopens a database whose search triggers do not fit
+// the running build, as when FTS5 and non-FTS5 builds share a database. Run it with
+// and without -tags sqlite_fts5.
+func TestSearchTriggersFollowTheBuild(t *testing.T) {
+	Logger = logger.Discard
+	if err := Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	user := models.User{Username: "alice", Email: "alice@example.com", Role: models.RoleUser}
+	if err := DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// A trigger writing to an index this build cannot open breaks every insert
+	DB.Exec("DROP TRIGGER todos_fts_ai")
+	DB.Exec("CREATE TRIGGER todos_fts_ai AFTER INSERT ON todos BEGIN INSERT INTO missing_fts(rowid) VALUES (new.id); END")
+	if err := DB.Create(&models.Todo{Item: "Buy milk", UserID: user.ID}).Error; err == nil {
+		t.Fatal("insert succeeded despite the broken trigger")
+	}
+	if err := Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := DB.Create(&models.Todo{Item: "Buy milk", UserID: user.ID}).Error; err != nil {
+		t.Fatalf("insert after setup: %v", err)
+	}
+	if !FTSEnabled {
+		return
+	}
+
+	// Todos written while the triggers were missing are indexed once they are back
+	for _, trigger := range todoSearchTriggers {
+		DB.Exec("DROP TRIGGER " + trigger)
+	}
+	if err := DB.Create(&models.Todo{Item: "Walk the dog", UserID: user.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	var ids []uint
+	DB.Raw("SELECT rowid FROM todos_fts WHERE todos_fts MATCH 'dog'").Scan(&ids)
+	if len(ids) != 1 {
+		t.Errorf("search for dog found %v, want the todo added without triggers", ids)
+	}
+}
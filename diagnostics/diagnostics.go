@@ -0,0 +1,155 @@
+// Package diagnostics serves the probes and introspection endpoints for operators:
+// /healthz (liveness), /readyz (readiness) and the admin-only /debug tree.
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"gin-demo-api/config"
+	"gin-demo-api/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readyTimeout bounds the database checks of /readyz
+const readyTimeout = 2 * time.Second
+
+var (
+	started  = time.Now()
+	draining atomic.Bool
+
+	errShuttingDown = errors.New("shutting down")
+)
+
+// Drain makes /readyz fail so load balancers stop sending traffic before shutdown
+func Drain() {
+	draining.Store(true)
+}
+
+// Register adds the diagnostics routes to router; guard protects /debug
+func Register(router gin.IRouter, cfg config.Config, guard gin.HandlerFunc) {
+	router.GET("/healthz", Healthz)
+	router.GET("/readyz", Readyz)
+
+	debugRoutes := router.Group("/debug", guard)
+	debugRoutes.GET("", Debug(cfg))
+	debugRoutes.GET("/pprof/*profile", profile)
+	debugRoutes.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+}
+
+// --- L I V E N E S S (GET /healthz) ------------------------------------------
+// Healthz answers 200 while the process is able to serve requests at all
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// --- R E A D I N E S S (GET /readyz) -----------------------------------------
+// Readyz answers 200 when the database is reachable and fully migrated, and 503 otherwise
+// or once the server has started shutting down
+func Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readyTimeout)
+	defer cancel()
+
+	checks := gin.H{}
+	ready := true
+	check := func(name string, err error) {
+		if err != nil {
+			checks[name] = err.Error()
+			ready = false
+			return
+		}
+		checks[name] = "ok"
+	}
+
+	if draining.Load() {
+		check("server", errShuttingDown)
+	}
+	sqlDB, err := db.DB.DB()
+	if err == nil {
+		err = sqlDB.PingContext(ctx)
+	}
+	check("database", err)
+	if err == nil {
+		check("migrations", db.CheckMigrations(ctx))
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": checks})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "checks": checks})
+}
+
+// --- D E B U G (GET /debug) --------------------------------------------------
+// Debug reports build info, the effective configuration (secrets redacted),
+// database connection pool stats and runtime figures
+func Debug(cfg config.Config) gin.HandlerFunc {
+	redacted := cfg.Redacted()
+	return func(c *gin.Context) {
+		var memory runtime.MemStats
+		runtime.ReadMemStats(&memory)
+
+		response := gin.H{
+			"build":  buildInfo(),
+			"config": redacted,
+			"runtime": gin.H{
+				"uptime":        time.Since(started).Round(time.Second).String(),
+				"started_at":    started,
+				"goroutines":    runtime.NumGoroutine(),
+				"heap_alloc":    memory.HeapAlloc,
+				"heap_objects":  memory.HeapObjects,
+				"gc_cycles":     memory.NumGC,
+				"gomaxprocs":    runtime.GOMAXPROCS(0),
+				"profiles_path": "/debug/pprof/",
+			},
+		}
+		if sqlDB, err := db.DB.DB(); err == nil {
+			response["database"] = sqlDB.Stats()
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// buildInfo describes the running binary from the information embedded by the Go toolchain
+func buildInfo() gin.H {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return gin.H{"go_version": runtime.Version()}
+	}
+	build := gin.H{
+		"go_version": info.GoVersion,
+		"module":     info.Main.Path,
+		"version":    info.Main.Version,
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision", "vcs.time", "vcs.modified", "-tags":
+			build[setting.Key] = setting.Value
+		}
+	}
+	return build
+}
+
+// profile serves the net/http/pprof index and profiles under /debug/pprof/
+func profile(c *gin.Context) {
+	switch c.Param("profile") {
+	case "/cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "/profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "/symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "/trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		// The index, and named profiles such as /heap and /goroutine
+		pprof.Index(c.Writer, c.Request)
+	}
+}
@@ -0,0 +1,59 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gin-demo-api/config"
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadyzFailsDuringShutdown(t *testing.T) {
+	testdb.Open(t)
+	t.Cleanup(func() { draining.Store(false) })
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	Register(router, config.Load(), func(c *gin.Context) { c.AbortWithStatus(http.StatusForbidden) })
+
+	get := func(path string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+	checks := func(body map[string]interface{}) map[string]interface{} {
+		checks, _ := body["checks"].(map[string]interface{})
+		return checks
+	}
+
+	if code, body := get("/readyz"); code != http.StatusOK || checks(body)["database"] != "ok" || checks(body)["migrations"] != "ok" {
+		t.Fatalf("before shutdown: %d %v, want 200 with every check ok", code, body)
+	}
+
+	// Once draining, the probe fails while the database is still fine, and liveness holds
+	Drain()
+	code, body := get("/readyz")
+	if code != http.StatusServiceUnavailable || body["status"] != "unavailable" || checks(body)["server"] != errShuttingDown.Error() {
+		t.Errorf("while draining: %d %v, want 503 with the server shutting down", code, body)
+	}
+	if checks(body)["database"] != "ok" {
+		t.Errorf("while draining: database check %v, want ok", checks(body)["database"])
+	}
+	if code, _ := get("/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz while draining: %d, want 200", code)
+	}
+
+	// An unreachable database fails the probe too
+	draining.Store(false)
+	sqlDB, _ := db.DB.DB()
+	sqlDB.Close()
+	if code, body := get("/readyz"); code != http.StatusServiceUnavailable || checks(body)["database"] == "ok" {
+		t.Errorf("with the database closed: %d %v, want 503", code, body)
+	}
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event describes a change to a todo or user
+type Event struct {
+	ID         uint64      `json:"id" example:"42"`             // Change log ID, increases monotonically
+	Type       string      `json:"type" example:"todo.updated"` // <entity>.<created|updated|completed|deleted>
+	EntityType string      `json:"entity_type" example:"todo"`
+	EntityID   uint        `json:"entity_id" example:"1"`
+	UserID     uint        `json:"user_id" example:"1"` // Owning user (the user itself for user events)
+	Data       interface{} `json:"data"`                // Entity state after the change, or before a delete
+	Time       time.Time   `json:"time" example:"2025-10-25T12:00:00Z"`
+}
+
+// Bus fans out published events to in-process subscribers
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// Subscription receives events from a Bus until it is closed.
+// A subscriber that falls more than its buffer behind is dropped (see Overflowed).
+type Subscription struct {
+	bus        *Bus
+	events     chan Event
+	done       chan struct{}
+	once       sync.Once
+	overflowed atomic.Bool
+}
+
+// Default is the bus the handlers publish to
+var Default = NewBus()
+
+// NewBus returns an empty bus
+func NewBus() *Bus {
+	return &Bus{subs: map[*Subscription]struct{}{}}
+}
+
+// Publish delivers the event to every subscriber without blocking
+func (b *Bus) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	var slow []*Subscription
+	b.mu.RLock()
+	for sub := range b.subs {
+		select {
+		case sub.events <- event:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range slow {
+		sub.overflowed.Store(true)
+		sub.Close()
+	}
+}
+
+// Subscribe registers a subscriber with room for buffer undelivered events
+func (b *Bus) Subscribe(buffer int) *Subscription {
+	sub := &Subscription{bus: b, events: make(chan Event, buffer), done: make(chan struct{})}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Events returns the channel of delivered events
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Done is closed once the subscription has been closed
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Overflowed reports whether the subscription was dropped for falling behind
+func (s *Subscription) Overflowed() bool {
+	return s.overflowed.Load()
+}
+
+// Close unregisters the subscription; it is safe to call more than once
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s)
+		s.bus.mu.Unlock()
+		close(s.done)
+	})
+}
@@ -0,0 +1,227 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-demo-api/auth"
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+	"gin-demo-api/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/testutil"
+)
+
+// createUser saves a user with the given number of todos, named <username>-1, <username>-2, ...
+func createUser(t *testing.T, username string, todos int) models.User {
+	t.Helper()
+	user := testdb.CreateUser(t, models.User{Username: username})
+	for i := 1; i <= todos; i++ {
+		todo := models.Todo{Item: fmt.Sprint(username, "-", i), UserID: user.ID}
+		if err := db.DB.Create(&todo).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return user
+}
+
+func TestUserTodosArePagedPerUser(t *testing.T) {
+	testdb.Open(t)
+	createUser(t, "alice", 3)
+	createUser(t, "bob", 4)
+	createUser(t, "carol", 0)
+
+	result := graphql.Do(graphql.Params{
+		Schema:        Schema,
+		RequestString: `{ users { username todos(limit: 2, offset: 1) { item } } }`,
+		Context:       withLoaders(context.Background()),
+	})
+	if len(result.Errors) > 0 {
+		t.Fatal(result.Errors)
+	}
+
+	got, _ := json.Marshal(result.Data)
+	want := `{"users":[` +
+		`{"todos":[{"item":"alice-2"},{"item":"alice-3"}],"username":"alice"},` +
+		`{"todos":[{"item":"bob-2"},{"item":"bob-3"}],"username":"bob"},` +
+		`{"todos":[],"username":"carol"}]}`
+	if string(got) != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestSubscriptionsOnlyDeliverOwnChanges(t *testing.T) {
+	testdb.Open(t)
+	alice := createUser(t, "alice", 0)
+	bob := createUser(t, "bob", 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	params := graphql.Params{Schema: Schema, RequestString: `subscription { todoChanged { type todo { item } } }`}
+
+	params.Context = withLoaders(ctx)
+	result := <-graphql.Subscribe(params)
+	if len(result.Errors) == 0 || result.Errors[0].Message != errUnauthenticated.Error() {
+		t.Fatalf("anonymous subscription: got %+v, want %q", result, errUnauthenticated)
+	}
+
+	params.Context = withActor(withLoaders(ctx), &alice.ID)
+	results := graphql.Subscribe(params)
+	// Give the subscription time to register with the bus
+	time.Sleep(50 * time.Millisecond)
+
+	for _, todo := range []models.Todo{{Item: "bob's", UserID: bob.ID}, {Item: "alice's", UserID: alice.ID}} {
+		if _, err := service.CreateTodo(ctx, nil, todo); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case result := <-results:
+		got, _ := json.Marshal(result)
+		if want := `{"data":{"todoChanged":{"todo":{"item":"alice's"},"type":"todo.created"}}}`; string(got) != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	case <-ctx.Done():
+		t.Fatal("no event delivered")
+	}
+}
+
+func TestReadScopedKeysCannotMutate(t *testing.T) {
+	testdb.Open(t)
+	alice := createUser(t, "alice", 1)
+	newKey := func(scope string) string {
+		key, prefix, hash := auth.NewAPIKey()
+		if err := db.DB.Create(&models.APIKey{UserID: alice.ID, Name: scope, Prefix: prefix, Hash: hash, Scopes: []string{scope}}).Error; err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	readKey, allKey := newKey(models.ScopeRead), newKey(models.ScopeAll)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(auth.Authenticate())
+	router.POST("/v1/graphql", Query)
+	router.GET("/v1/graphql", auth.RequireUser(), Subscribe)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	post := func(key, query string) (int, string) {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/graphql", strings.NewReader(`{"query":`+strconv.Quote(query)+`}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(auth.APIKeyHeader, key)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	mutation := fmt.Sprintf(`mutation { createTodo(item: "Buy milk", userId: %d) { id } }`, alice.ID)
+	todos := func() int64 {
+		var count int64
+		db.DB.Model(&models.Todo{}).Count(&count)
+		return count
+	}
+
+	if code, body := post(readKey, `{ todo(id: 1) { item } }`); code != http.StatusOK || !strings.Contains(body, `"alice-1"`) {
+		t.Errorf("query with a read key: status %d, %s", code, body)
+	}
+	if code, body := post(readKey, mutation); code != http.StatusForbidden || !strings.Contains(body, errReadOnly.Error()) {
+		t.Errorf("mutation with a read key: status %d, %s; want %d", code, body, http.StatusForbidden)
+	}
+	if code, body := post(allKey, mutation); code != http.StatusOK || strings.Contains(body, "errors") {
+		t.Errorf("mutation with an all key: status %d, %s", code, body)
+	}
+	if n := todos(); n != 2 {
+		t.Fatalf("%d todos, want 2", n)
+	}
+	// Operations over the limits are refused before they run
+	if code, body := post(allKey, `{ users { todos(limit: 500) { user { todos(limit: 500) { id } } } } }`); code != http.StatusOK || !strings.Contains(body, "complexity") {
+		t.Errorf("query over the complexity limit: status %d, %s", code, body)
+	}
+
+	// Over WebSocket, the upgrade is a GET, so the key's scope is checked per operation
+	header := http.Header{auth.APIKeyHeader: {readKey}}
+	dialer := websocket.Dialer{Subprotocols: []string{wsProtocol}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/v1/graphql", header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	payload, _ := json.Marshal(request{Query: mutation})
+	for _, message := range []wsMessage{{Type: "connection_init"}, {ID: "1", Type: "subscribe", Payload: payload}} {
+		if err := conn.WriteJSON(message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var ack, result wsMessage
+	conn.ReadJSON(&ack)
+	if err := conn.ReadJSON(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Type != "error" || !strings.Contains(string(result.Payload), errReadOnly.Error()) {
+		t.Errorf("mutation over WebSocket with a read key: got %s %s", result.Type, result.Payload)
+	}
+	if n := todos(); n != 2 {
+		t.Errorf("%d todos after the WebSocket mutation, want 2", n)
+	}
+}
+
+func TestUserTodosHaveADefaultPage(t *testing.T) {
+	testdb.Open(t)
+	createUser(t, "alice", defaultLimit+1)
+
+	result := graphql.Do(graphql.Params{
+		Schema:        Schema,
+		RequestString: `{ users { todos { id } } }`,
+		Context:       withLoaders(context.Background()),
+	})
+	if len(result.Errors) > 0 {
+		t.Fatal(result.Errors)
+	}
+	users := result.Data.(map[string]interface{})["users"].([]interface{})
+	if todos := users[0].(map[string]interface{})["todos"].([]interface{}); len(todos) != defaultLimit {
+		t.Errorf("got %d todos, want the default page of %d", len(todos), defaultLimit)
+	}
+}
+
+func TestOperationLimits(t *testing.T) {
+	tests := []struct {
+		name, query string
+		variables   map[string]interface{}
+		want        string // part of the error, or "" for none
+	}{
+		{"nested page", `{ users { id todos(completed: false) { id item } } }`, nil, ""},
+		{"introspection", testutil.IntrospectionQuery, nil, ""},
+		{"too deep", `{ todos { user { todos { user { todos { user { todos { user { id } } } } } } } } }`, nil, "nested 9 levels deep"},
+		{"too complex", `{ users { todos(limit: 500) { user { todos(limit: 500) { id } } } } }`, nil, "complexity"},
+		{"too complex through a variable", `query($n: Int) { users { todos(limit: $n) { item description completed } } }`, map[string]interface{}{"n": float64(500)}, "complexity"},
+		{"too deep through fragments", `{ todos { ...owner } } fragment owner on Todo { user { todos { user { todos { user { todos { user { id } } } } } } } }`, nil, "levels deep"},
+		{"fragment cycle", `{ todos { ...a } } fragment a on Todo { user { todos { ...a } } }`, nil, ""},
+		{"does not parse", `{ users {`, nil, ""},
+	}
+	for _, tt := range tests {
+		err := checkLimits(tt.query, "", tt.variables)
+		if tt.want == "" && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		} else if tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("%s: got %v, want an error about %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"gin-demo-api/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+const (
+	wsProtocol     = "graphql-transport-ws" // Sub-protocol of the graphql-ws library
+	wsWriteTimeout = 10 * time.Second       // Time allowed to write a message
+	wsInitTimeout  = 10 * time.Second       // Time allowed for connection_init
+	wsPingInterval = 25 * time.Second       // Keep-alive interval for idle connections
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	Subprotocols:    []string{wsProtocol},
+}
+
+// request is a GraphQL request as sent over HTTP and inside subscribe messages
+type request struct {
+	Query         string                 `json:"query" binding:"required" example:"{ users { id username todos(completed: false) { id item } } }"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// --- Q U E R Y (POST /graphql) ----------------------------------------------
+// @Summary Run a GraphQL query or mutation
+// @Description Executes a GraphQL operation against the User/Todo schema. Errors are reported in the errors array of a 200 response.
+// @Description Subscriptions (todoChanged, userChanged) are served to authenticated callers on GET /graphql over WebSocket using the graphql-transport-ws protocol.
+// @tags GraphQL
+// @Accept  json
+// @Produce  json
+// @Param request body graph.request true "GraphQL request"
+// @Success 200 {object} map[string]interface{} "data and/or errors"
+// @Failure 400 {object} map[string]interface{} "Invalid request body"
+// @Failure 403 {object} map[string]interface{} "API key scope does not allow mutations"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /graphql [post]
+func Query(c *gin.Context) {
+	var req request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := checkLimits(req.Query, req.OperationName, req.Variables); err != nil {
+		c.JSON(http.StatusOK, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+	switch operationType(req.Query, req.OperationName) {
+	case ast.OperationTypeSubscription:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subscriptions require a WebSocket connection to GET /graphql"})
+		return
+	case ast.OperationTypeMutation:
+		if !auth.WriteAllowed(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": errReadOnly.Error()})
+			return
+		}
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         Schema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        withLoaders(requestContext(c)),
+	})
+	c.JSON(http.StatusOK, result)
+}
+
+// wsMessage is a graphql-transport-ws protocol message
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsConn serialises writes to the socket and tracks running operations
+type wsConn struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+
+	opsMu sync.Mutex
+	ops   map[string]context.CancelFunc
+}
+
+// --- S U B S C R I B E (GET /graphql) ---------------------------------------
+// @Summary Run GraphQL subscriptions over WebSocket
+// @Description Upgrades to a WebSocket speaking graphql-transport-ws (the graphql-ws library). Send connection_init, then subscribe messages; each result arrives as a next message.
+// @Description Requires authentication on the upgrade request. todoChanged and userChanged deliver changes to the caller's own todos and user only.
+// @Description Queries and mutations are also accepted. A subscription that falls too far behind the event stream is completed by the server.
+// @tags GraphQL
+// @Security BearerAuth
+// @Security APIKey
+// @Success 101 {object} map[string]interface{} "Switching Protocols"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /graphql [get]
+func Subscribe(c *gin.Context) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already written an error response
+		return
+	}
+	defer conn.Close()
+	if conn.Subprotocol() != wsProtocol {
+		closeWith(conn, 4406, "Subprotocol not acceptable")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(requestContext(c))
+	defer cancel()
+	ws := &wsConn{conn: conn, ops: map[string]context.CancelFunc{}}
+
+	// Server-side keep-alive; the client answers control pings automatically
+	go func() {
+		ping := time.NewTicker(wsPingInterval)
+		defer ping.Stop()
+		for {
+			select {
+			case <-ping.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	conn.SetReadLimit(64 << 10)
+	conn.SetReadDeadline(time.Now().Add(wsInitTimeout))
+	acknowledged := false
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var message wsMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			closeWith(conn, 4400, "Invalid message")
+			return
+		}
+
+		switch message.Type {
+		case "connection_init":
+			if acknowledged {
+				closeWith(conn, 4429, "Too many initialisation requests")
+				return
+			}
+			acknowledged = true
+			conn.SetReadDeadline(time.Time{})
+			ws.write(wsMessage{Type: "connection_ack"})
+		case "ping":
+			ws.write(wsMessage{Type: "pong"})
+		case "pong":
+		case "subscribe":
+			if !acknowledged {
+				closeWith(conn, 4401, "Unauthorized")
+				return
+			}
+			var req request
+			if err := json.Unmarshal(message.Payload, &req); err != nil || message.ID == "" {
+				closeWith(conn, 4400, "Invalid subscribe message")
+				return
+			}
+			if !ws.start(ctx, message.ID, req) {
+				closeWith(conn, 4409, "Subscriber for "+message.ID+" already exists")
+				return
+			}
+		case "complete":
+			ws.stop(message.ID)
+		default:
+			closeWith(conn, 4400, "Unknown message type "+message.Type)
+			return
+		}
+	}
+}
+
+// start runs an operation in the background; it returns false if the ID is in use
+func (ws *wsConn) start(ctx context.Context, id string, req request) bool {
+	ws.opsMu.Lock()
+	if _, exists := ws.ops[id]; exists {
+		ws.opsMu.Unlock()
+		return false
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	ws.ops[id] = cancel
+	ws.opsMu.Unlock()
+
+	go func() {
+		defer cancel()
+		params := graphql.Params{
+			Schema:         Schema,
+			RequestString:  req.Query,
+			OperationName:  req.OperationName,
+			VariableValues: req.Variables,
+			Context:        withLoaders(ctx),
+		}
+
+		operation := operationType(req.Query, req.OperationName)
+		limitErr := checkLimits(req.Query, req.OperationName, req.Variables)
+		switch {
+		case limitErr != nil:
+			ws.send(ctx, id, &graphql.Result{Errors: gqlerrors.FormatErrors(limitErr)})
+		case operation == ast.OperationTypeMutation && readOnly(ctx):
+			ws.send(ctx, id, &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(errReadOnly.Error())}})
+		case operation != ast.OperationTypeSubscription:
+			ws.send(ctx, id, graphql.Do(params))
+		default:
+			// Keep draining after a cancel so the executor goroutine can finish
+			for result := range graphql.Subscribe(params) {
+				ws.send(ctx, id, result)
+			}
+		}
+
+		// Only report completion if the client did not end the operation itself
+		if ws.stop(id) {
+			ws.write(wsMessage{ID: id, Type: "complete"})
+		}
+	}()
+	return true
+}
+
+// stop cancels the operation with the given ID; it returns false if it was not running
+func (ws *wsConn) stop(id string) bool {
+	ws.opsMu.Lock()
+	defer ws.opsMu.Unlock()
+	cancel, ok := ws.ops[id]
+	if ok {
+		cancel()
+		delete(ws.ops, id)
+	}
+	return ok
+}
+
+// send writes one result as a next message, or as an error message if the operation failed before executing
+func (ws *wsConn) send(ctx context.Context, id string, result *graphql.Result) {
+	if ctx.Err() != nil {
+		return
+	}
+	if result.Data == nil && len(result.Errors) > 0 {
+		payload, _ := json.Marshal(result.Errors)
+		ws.write(wsMessage{ID: id, Type: "error", Payload: payload})
+		return
+	}
+	payload, _ := json.Marshal(result)
+	ws.write(wsMessage{ID: id, Type: "next", Payload: payload})
+}
+
+// write sends one message; write errors surface as read errors in Subscribe
+func (ws *wsConn) write(message wsMessage) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	ws.conn.WriteJSON(message)
+}
+
+// closeWith sends a close frame with a graphql-transport-ws close code
+func closeWith(conn *websocket.Conn, code int, reason string) {
+	message := websocket.FormatCloseMessage(code, reason)
+	conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(wsWriteTimeout))
+}
+
+// readOnlyKey marks the context of a caller whose API key may not run mutations
+type readOnlyKey struct{}
+
+// readOnly reports whether the context was marked by requestContext as not allowed to write
+func readOnly(ctx context.Context) bool {
+	return ctx.Value(readOnlyKey{}) != nil
+}
+
+// requestContext carries the caller and whether it may write into the resolvers. Each
+// operation adds loaders of its own, so a long-lived WebSocket never reuses a batch.
+func requestContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if !auth.WriteAllowed(c) {
+		ctx = context.WithValue(ctx, readOnlyKey{}, true)
+	}
+	if id, ok := auth.UserID(c); ok {
+		return withActor(ctx, &id)
+	}
+	return ctx
+}
+
+// operationType returns the type of the operation that will run, or "" if the document does not parse
+func operationType(query, operationName string) string {
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return ""
+	}
+	if operation := selectOperation(document, operationName); operation != nil {
+		return operation.Operation
+	}
+	return ""
+}
+
+// selectOperation returns the operation of a document that will run, or nil
+func selectOperation(document *ast.Document, operationName string) *ast.OperationDefinition {
+	for _, definition := range document.Definitions {
+		if operation, ok := definition.(*ast.OperationDefinition); ok {
+			if operationName == "" || (operation.Name != nil && operation.Name.Value == operationName) {
+				return operation
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// Limits on the shape of an operation, checked before it runs. Introspection fields
+// (__schema, __type, ...) are not counted, so schema explorers keep working.
+var (
+	MaxDepth      = 8     // Levels of nested fields; users { todos { id } } is 3 deep
+	MaxComplexity = 50000 // Estimated values resolved; see checkLimits
+)
+
+// checkLimits returns an error if the operation that will run is nested deeper than
+// MaxDepth or is estimated to resolve more than MaxComplexity values. Every field
+// counts once for each item of the lists around it, and a list is assumed to hold as
+// many items as its limit argument allows, or defaultLimit when it has none. Documents
+// that do not parse are left to graphql.Do to report.
+func checkLimits(query, operationName string, variables map[string]interface{}) error {
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil
+	}
+	operation := selectOperation(document, operationName)
+	if operation == nil {
+		return nil
+	}
+
+	var root *graphql.Object
+	switch operation.Operation {
+	case ast.OperationTypeQuery:
+		root = Schema.QueryType()
+	case ast.OperationTypeMutation:
+		root = Schema.MutationType()
+	case ast.OperationTypeSubscription:
+		root = Schema.SubscriptionType()
+	}
+	c := &costCounter{fragments: map[string]*ast.FragmentDefinition{}, variables: variables}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			c.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	complexity, depth := c.selections(root, operation.SelectionSet, map[string]bool{})
+	if depth > MaxDepth {
+		return fmt.Errorf("operation is nested %d levels deep, over the limit of %d", depth, MaxDepth)
+	}
+	if complexity > MaxComplexity {
+		return fmt.Errorf("operation complexity %d is over the limit of %d", complexity, MaxComplexity)
+	}
+	return nil
+}
+
+// costCounter walks the selections of one document
+type costCounter struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// selections returns the complexity and depth of a selection set on parent; spreading
+// names the fragments being expanded, so cycles (rejected later by validation) end
+func (c *costCounter) selections(parent *graphql.Object, set *ast.SelectionSet, spreading map[string]bool) (complexity, depth int) {
+	if set == nil || parent == nil {
+		return 0, 0
+	}
+	for _, selection := range set.Selections {
+		var cost, levels int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			cost, levels = c.field(parent, selection, spreading)
+		case *ast.InlineFragment:
+			cost, levels = c.selections(parent, selection.SelectionSet, spreading)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			if fragment, ok := c.fragments[name]; ok && !spreading[name] {
+				spreading[name] = true
+				cost, levels = c.selections(parent, fragment.SelectionSet, spreading)
+				delete(spreading, name)
+			}
+		}
+		complexity = min(complexity+cost, math.MaxInt32)
+		depth = max(depth, levels)
+	}
+	return complexity, depth
+}
+
+// field returns the complexity and depth of one field, including its selections
+func (c *costCounter) field(parent *graphql.Object, field *ast.Field, spreading map[string]bool) (complexity, depth int) {
+	definition, ok := parent.Fields()[field.Name.Value]
+	if !ok {
+		// Introspection, or an unknown field that validation rejects
+		return 0, 0
+	}
+
+	items := 1
+	if _, list := graphql.GetNullable(definition.Type).(*graphql.List); list {
+		items = c.pageSize(field)
+	}
+	object, _ := graphql.GetNamed(definition.Type).(*graphql.Object)
+	complexity, depth = c.selections(object, field.SelectionSet, spreading)
+	return min(1+items*complexity, math.MaxInt32), depth + 1
+}
+
+// pageSize is the number of items a list field is assumed to return: its limit
+// argument, capped like pagination does, or defaultLimit
+func (c *costCounter) pageSize(field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "limit" {
+			continue
+		}
+		var limit int
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			limit, _ = strconv.Atoi(value.Value)
+		case *ast.Variable:
+			switch variable := c.variables[value.Name.Value].(type) {
+			case float64:
+				limit = int(variable)
+			case int:
+				limit = variable
+			}
+		}
+		if limit > 0 {
+			return min(limit, maxLimit)
+		}
+	}
+	return defaultLimit
+}
@@ -0,0 +1,132 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"gin-demo-api/models"
+	"gin-demo-api/service"
+)
+
+// loaders batch the lookups made while resolving one operation. Resolvers queue
+// keys and return thunks; graphql-go runs the thunks only after every field at
+// the current depth has been resolved, so each batch becomes a single query.
+type loaders struct {
+	todos *todoLoader
+	users *userLoader
+}
+
+type loadersKey struct{}
+
+// withLoaders returns a context carrying fresh loaders for one operation
+func withLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		todos: &todoLoader{batches: map[string]*todoBatch{}},
+		users: &userLoader{},
+	})
+}
+
+// loadersFrom returns the loaders stored by withLoaders
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// todoLoader loads a page of todos for many users at once, grouped by the
+// completed filter and page
+type todoLoader struct {
+	mu      sync.Mutex
+	batches map[string]*todoBatch
+}
+
+type todoBatch struct {
+	filter service.TodoFilter
+	done   bool
+	todos  map[uint][]models.Todo
+	err    error
+}
+
+// load queues userID and returns a thunk yielding their page of todos. Limit and
+// Offset of filter apply to each user; a zero Limit loads all their todos.
+// The batch query runs with the ctx of the first thunk to be resolved.
+func (l *todoLoader) load(ctx context.Context, userID uint, filter service.TodoFilter) func() (interface{}, error) {
+	key := fmt.Sprint("all/", filter.Limit, "/", filter.Offset)
+	if filter.Completed != nil {
+		key = fmt.Sprint(*filter.Completed, "/", filter.Limit, "/", filter.Offset)
+	}
+
+	l.mu.Lock()
+	batch, ok := l.batches[key]
+	if !ok {
+		batch = &todoBatch{filter: filter}
+		l.batches[key] = batch
+	}
+	batch.filter.UserIDs = append(batch.filter.UserIDs, userID)
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !batch.done {
+			// Later lookups start a new batch
+			delete(l.batches, key)
+			batch.done = true
+			batch.todos, batch.err = service.ListTodosByUser(ctx, batch.filter)
+		}
+		if batch.err != nil {
+			return nil, batch.err
+		}
+		todos := batch.todos[userID]
+		if todos == nil {
+			todos = []models.Todo{}
+		}
+		return todos, nil
+	}
+}
+
+// userLoader loads many users by ID at once
+type userLoader struct {
+	mu    sync.Mutex
+	batch *userBatch
+}
+
+type userBatch struct {
+	ids   []uint
+	done  bool
+	users map[uint]models.User
+	err   error
+}
+
+// load queues id and returns a thunk yielding the user, or nil if it does not exist.
+// The batch query runs with the ctx of the first thunk to be resolved.
+func (l *userLoader) load(ctx context.Context, id uint) func() (interface{}, error) {
+	l.mu.Lock()
+	if l.batch == nil {
+		l.batch = &userBatch{}
+	}
+	batch := l.batch
+	batch.ids = append(batch.ids, id)
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !batch.done {
+			l.batch = nil
+			batch.done = true
+			users, err := service.GetUsers(ctx, batch.ids)
+			batch.err = err
+			batch.users = map[uint]models.User{}
+			for _, user := range users {
+				batch.users[user.ID] = user
+			}
+		}
+		if batch.err != nil {
+			return nil, batch.err
+		}
+		if user, ok := batch.users[id]; ok {
+			return user, nil
+		}
+		return nil, nil
+	}
+}
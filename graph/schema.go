@@ -0,0 +1,457 @@
+// Package graph serves a GraphQL API over users and todos at /graphql.
+// Queries and mutations go through the service layer like the REST handlers,
+// nested lists are batch-loaded per operation, and subscriptions are fed from
+// the event bus.
+package graph
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"gin-demo-api/events"
+	"gin-demo-api/models"
+	"gin-demo-api/service"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	defaultLimit = 50                  // Todos returned by todos and User.todos when no limit is given
+	maxLimit     = service.MaxTodoPage // Upper bound for any limit argument
+)
+
+var (
+	// errUnauthenticated is returned to subscriptions without an authenticated caller
+	errUnauthenticated = errors.New("authentication required")
+	// errReadOnly is returned for mutations sent with an API key that may only read
+	errReadOnly = errors.New("API key scope does not allow mutations")
+)
+
+type actorKey struct{}
+
+// withActor stores the authenticated user's ID for audit events
+func withActor(ctx context.Context, actorID *uint) context.Context {
+	return context.WithValue(ctx, actorKey{}, actorID)
+}
+
+// actorFrom returns the ID stored by withActor, or nil
+func actorFrom(ctx context.Context) *uint {
+	id, _ := ctx.Value(actorKey{}).(*uint)
+	return id
+}
+
+var userType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "User",
+	Fields: graphql.Fields{
+		"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"username":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"email":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: userField(func(u models.User) interface{} { return u.CreatedAt })},
+		"updatedAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: userField(func(u models.User) interface{} { return u.UpdatedAt })},
+	},
+})
+
+var todoType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Todo",
+	Fields: graphql.Fields{
+		"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"item":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"description": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "Markdown source"},
+		"completed":   &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"userId":      &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: todoField(func(t models.Todo) interface{} { return t.UserID })},
+		"createdAt":   &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: todoField(func(t models.Todo) interface{} { return t.CreatedAt })},
+		"updatedAt":   &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: todoField(func(t models.Todo) interface{} { return t.UpdatedAt })},
+		"user": &graphql.Field{
+			Type:        userType,
+			Description: "The owner, or null if they have been deleted. Batch-loaded.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return loadersFrom(p.Context).users.load(p.Context, p.Source.(models.Todo).UserID), nil
+			},
+		},
+	},
+})
+
+var todoEventType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TodoEvent",
+	Fields: graphql.Fields{
+		"id":   &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Description: "Change log ID, as in GET /events", Resolve: eventField(func(e events.Event) interface{} { return e.ID })},
+		"type": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "todo.created, todo.updated, todo.completed or todo.deleted", Resolve: eventField(func(e events.Event) interface{} { return e.Type })},
+		"time": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: eventField(func(e events.Event) interface{} { return e.Time })},
+		"todo": &graphql.Field{Type: graphql.NewNonNull(todoType), Description: "State after the change, or before a delete", Resolve: eventField(func(e events.Event) interface{} { return e.Data })},
+	},
+})
+
+var userEventType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "UserEvent",
+	Fields: graphql.Fields{
+		"id":   &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Description: "Change log ID, as in GET /events", Resolve: eventField(func(e events.Event) interface{} { return e.ID })},
+		"type": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "user.created, user.updated or user.deleted", Resolve: eventField(func(e events.Event) interface{} { return e.Type })},
+		"time": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: eventField(func(e events.Event) interface{} { return e.Time })},
+		"user": &graphql.Field{Type: graphql.NewNonNull(userType), Description: "State after the change, or before a delete", Resolve: eventField(func(e events.Event) interface{} { return e.Data })},
+	},
+})
+
+var queryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Query",
+	Fields: graphql.Fields{
+		"users": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(userType))),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return service.ListUsers(p.Context, false)
+			},
+		},
+		"user": &graphql.Field{
+			Type: userType,
+			Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := parseID(p.Args["id"])
+				if err != nil {
+					return nil, err
+				}
+				user, err := service.GetUser(p.Context, id, false)
+				if errors.Is(err, service.ErrNotFound) {
+					return nil, nil
+				}
+				return user, err
+			},
+		},
+		"todos": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(todoType))),
+			Description: "Todos ordered by ID, optionally filtered by owner and completion",
+			Args: graphql.FieldConfigArgument{
+				"userIds":   &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.ID))},
+				"completed": &graphql.ArgumentConfig{Type: graphql.Boolean},
+				"limit":     &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultLimit},
+				"offset":    &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userIDs, err := parseIDs(p.Args["userIds"])
+				if err != nil {
+					return nil, err
+				}
+				limit, offset, err := pagination(p.Args)
+				if err != nil {
+					return nil, err
+				}
+				if limit == 0 {
+					limit = defaultLimit
+				}
+				filter := service.TodoFilter{UserIDs: userIDs, Limit: limit, Offset: offset}
+				if completed, ok := p.Args["completed"].(bool); ok {
+					filter.Completed = &completed
+				}
+				return service.ListTodos(p.Context, filter)
+			},
+		},
+		"todo": &graphql.Field{
+			Type: todoType,
+			Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := parseID(p.Args["id"])
+				if err != nil {
+					return nil, err
+				}
+				todo, err := service.GetTodo(p.Context, id)
+				if errors.Is(err, service.ErrNotFound) {
+					return nil, nil
+				}
+				return todo, err
+			},
+		},
+	},
+})
+
+var mutationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Mutation",
+	Fields: graphql.Fields{
+		"createUser": &graphql.Field{
+			Type: graphql.NewNonNull(userType),
+			Args: graphql.FieldConfigArgument{
+				"username": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"email":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				input := models.User{Username: p.Args["username"].(string), Email: p.Args["email"].(string)}
+				return service.CreateUser(p.Context, actorFrom(p.Context), input)
+			},
+		},
+		"updateUser": &graphql.Field{
+			Type:        graphql.NewNonNull(userType),
+			Description: "Changes the username and/or email; omitted or empty values are left unchanged",
+			Args: graphql.FieldConfigArgument{
+				"id":       &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"username": &graphql.ArgumentConfig{Type: graphql.String},
+				"email":    &graphql.ArgumentConfig{Type: graphql.String},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := parseID(p.Args["id"])
+				if err != nil {
+					return nil, err
+				}
+				var input models.User
+				input.Username, _ = p.Args["username"].(string)
+				input.Email, _ = p.Args["email"].(string)
+				user, err := service.UpdateUser(p.Context, actorFrom(p.Context), id, input)
+				return user, serviceError(err, "User not found")
+			},
+		},
+		"deleteUser": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Boolean),
+			Description: "Soft-deletes the user; their todos are kept",
+			Args:        graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := parseID(p.Args["id"])
+				if err != nil {
+					return nil, err
+				}
+				_, err = service.DeleteUser(p.Context, actorFrom(p.Context), id)
+				return err == nil, serviceError(err, "User not found")
+			},
+		},
+		"createTodo": &graphql.Field{
+			Type: graphql.NewNonNull(todoType),
+			Args: graphql.FieldConfigArgument{
+				"item":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"description": &graphql.ArgumentConfig{Type: graphql.String},
+				"completed":   &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false},
+				"userId":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				userID, err := parseID(p.Args["userId"])
+				if err != nil {
+					return nil, err
+				}
+				input := models.Todo{Item: p.Args["item"].(string), UserID: userID}
+				input.Description, _ = p.Args["description"].(string)
+				input.Completed, _ = p.Args["completed"].(bool)
+				todo, err := service.CreateTodo(p.Context, actorFrom(p.Context), input)
+				return todo, serviceError(err, "")
+			},
+		},
+		"updateTodo": &graphql.Field{
+			Type:        graphql.NewNonNull(todoType),
+			Description: "Changes the given fields; omitted fields are left unchanged",
+			Args: graphql.FieldConfigArgument{
+				"id":          &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"item":        &graphql.ArgumentConfig{Type: graphql.String},
+				"description": &graphql.ArgumentConfig{Type: graphql.String},
+				"completed":   &graphql.ArgumentConfig{Type: graphql.Boolean},
+				"userId":      &graphql.ArgumentConfig{Type: graphql.ID},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := parseID(p.Args["id"])
+				if err != nil {
+					return nil, err
+				}
+				var changes service.TodoChanges
+				if item, ok := p.Args["item"].(string); ok {
+					changes.Item = &item
+				}
+				if description, ok := p.Args["description"].(string); ok {
+					changes.Description = &description
+				}
+				if completed, ok := p.Args["completed"].(bool); ok {
+					changes.Completed = &completed
+				}
+				if value, ok := p.Args["userId"]; ok {
+					userID, err := parseID(value)
+					if err != nil {
+						return nil, err
+					}
+					changes.UserID = &userID
+				}
+				todo, err := service.UpdateTodo(p.Context, actorFrom(p.Context), id, changes)
+				return todo, serviceError(err, "Todo not found")
+			},
+		},
+		"deleteTodo": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Boolean),
+			Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := parseID(p.Args["id"])
+				if err != nil {
+					return nil, err
+				}
+				_, err = service.DeleteTodo(p.Context, actorFrom(p.Context), id)
+				return err == nil, serviceError(err, "Todo not found")
+			},
+		},
+	},
+})
+
+var subscriptionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Subscription",
+	Fields: graphql.Fields{
+		"todoChanged": &graphql.Field{
+			Type:        graphql.NewNonNull(todoEventType),
+			Description: "Changes to your todos as they are committed",
+			Subscribe: func(p graphql.ResolveParams) (interface{}, error) {
+				return subscribe(p.Context, "todo")
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source, nil
+			},
+		},
+		"userChanged": &graphql.Field{
+			Type:        graphql.NewNonNull(userEventType),
+			Description: "Changes to your user as they are committed",
+			Subscribe: func(p graphql.ResolveParams) (interface{}, error) {
+				return subscribe(p.Context, "user")
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source, nil
+			},
+		},
+	},
+})
+
+func init() {
+	// Added here because User and Todo refer to each other
+	userType.AddFieldConfig("todos", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(todoType))),
+		Description: "The user's todos ordered by ID. One page per user, loaded in one query for all users in the response.",
+		Args: graphql.FieldConfigArgument{
+			"completed": &graphql.ArgumentConfig{Type: graphql.Boolean},
+			"limit":     &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultLimit},
+			"offset":    &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+		},
+		Resolve: resolveUserTodos,
+	})
+}
+
+// Schema is the GraphQL schema served at /graphql
+var Schema = func() graphql.Schema {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query:        queryType,
+		Mutation:     mutationType,
+		Subscription: subscriptionType,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}()
+
+// resolveUserTodos queues the user for the request's batch of todo pages
+func resolveUserTodos(p graphql.ResolveParams) (interface{}, error) {
+	limit, offset, err := pagination(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	filter := service.TodoFilter{Limit: limit, Offset: offset}
+	if completed, ok := p.Args["completed"].(bool); ok {
+		filter.Completed = &completed
+	}
+
+	return loadersFrom(p.Context).todos.load(p.Context, p.Source.(models.User).ID, filter), nil
+}
+
+// subscribe forwards the caller's bus events of one entity type until ctx is done
+// or the subscriber falls too far behind
+func subscribe(ctx context.Context, entityType string) (chan interface{}, error) {
+	actorID := actorFrom(ctx)
+	if actorID == nil {
+		return nil, errUnauthenticated
+	}
+
+	sub := events.Default.Subscribe(64)
+	out := make(chan interface{})
+	go func() {
+		defer close(out)
+		defer sub.Close()
+		for {
+			select {
+			case event := <-sub.Events():
+				if event.EntityType != entityType || event.UserID != *actorID {
+					continue
+				}
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			case <-sub.Done():
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// pagination reads the limit and offset arguments; a zero limit means none was given
+func pagination(args map[string]interface{}) (limit, offset int, err error) {
+	limit, _ = args["limit"].(int)
+	offset, _ = args["offset"].(int)
+	if limit < 0 || offset < 0 {
+		return 0, 0, errors.New("limit and offset must not be negative")
+	}
+	return min(limit, maxLimit), offset, nil
+}
+
+// parseID converts an ID argument to a database ID
+func parseID(value interface{}) (uint, error) {
+	s, _ := value.(string)
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid ID " + strconv.Quote(s))
+	}
+	return uint(id), nil
+}
+
+// parseIDs converts a list of ID arguments
+func parseIDs(value interface{}) ([]uint, error) {
+	values, _ := value.([]interface{})
+	ids := make([]uint, 0, len(values))
+	for _, v := range values {
+		id, err := parseID(v)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// serviceError turns service errors into the messages the REST API uses
+func serviceError(err error, notFound string) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, service.ErrNotFound):
+		return errors.New(notFound)
+	case errors.Is(err, service.ErrInvalidUser):
+		return errors.New("Invalid User ID")
+	case errors.Is(err, service.ErrUnauthenticated):
+		return errors.New("Authentication required")
+	case errors.Is(err, service.ErrForbidden):
+		return errors.New("Only the user or an admin can change this account")
+	}
+	return err
+}
+
+// userField resolves a field of a models.User source
+func userField(get func(models.User) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(models.User)), nil
+	}
+}
+
+// todoField resolves a field of a models.Todo source
+func todoField(get func(models.Todo) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(models.Todo)), nil
+	}
+}
+
+// eventField resolves a field of an events.Event source
+func eventField(get func(events.Event) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(events.Event)), nil
+	}
+}
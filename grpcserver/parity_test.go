@@ -0,0 +1,215 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gin-demo-api/client"
+	"gin-demo-api/config"
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+	"gin-demo-api/proto/todoapi"
+	"gin-demo-api/router"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// todo is the part of a todo both transports return, without timestamps
+type todo struct {
+	ID          uint
+	Item        string
+	Description string
+	Completed   bool
+	UserID      uint
+}
+
+// transport runs todo calls over one API; errors are reported as HTTP status codes
+type transport interface {
+	createTodo(ctx context.Context, item, description string, userID uint) (todo, int)
+	updateTodo(ctx context.Context, id uint, item *string, completed *bool) (todo, int)
+	deleteTodo(ctx context.Context, id uint) int
+	getTodo(ctx context.Context, id uint) (todo, int)
+}
+
+// httpTransport calls the REST API through the Go client
+type httpTransport struct{ api *client.Client }
+
+func newHTTPTransport(t *testing.T, token string) transport {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Load()
+	cfg.OpenAPI.ValidateResponses = true
+	cfg.RateLimit.Default = "off"
+	handler, err := router.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return httpTransport{client.New(server.URL, client.WithToken(token), client.WithRetries(0))}
+}
+
+func (h httpTransport) createTodo(ctx context.Context, item, description string, userID uint) (todo, int) {
+	result, err := h.api.CreateTodo(ctx, client.TodoInput{Item: item, Description: description, UserID: userID})
+	return fromModel(result), httpStatus(err)
+}
+
+func (h httpTransport) updateTodo(ctx context.Context, id uint, item *string, completed *bool) (todo, int) {
+	result, err := h.api.UpdateTodo(ctx, id, client.TodoUpdate{Item: item, Completed: completed})
+	return fromModel(result), httpStatus(err)
+}
+
+func (h httpTransport) deleteTodo(ctx context.Context, id uint) int {
+	return httpStatus(h.api.DeleteTodo(ctx, id))
+}
+
+func (h httpTransport) getTodo(ctx context.Context, id uint) (todo, int) {
+	result, err := h.api.GetTodo(ctx, id)
+	return fromModel(result), httpStatus(err)
+}
+
+// grpcTransport calls TodoService over a local listener
+type grpcTransport struct {
+	todos todoapi.TodoServiceClient
+	token string
+}
+
+func newGRPCTransport(t *testing.T, token string) transport {
+	return grpcTransport{todoapi.NewTodoServiceClient(dial(t, nil)), token}
+}
+
+func (g grpcTransport) context(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+g.token)
+}
+
+func (g grpcTransport) createTodo(ctx context.Context, item, description string, userID uint) (todo, int) {
+	result, err := g.todos.CreateTodo(g.context(ctx), &todoapi.CreateTodoRequest{Item: item, Description: description, UserId: uint64(userID)})
+	return fromMessage(result), grpcStatus(err)
+}
+
+func (g grpcTransport) updateTodo(ctx context.Context, id uint, item *string, completed *bool) (todo, int) {
+	result, err := g.todos.UpdateTodo(g.context(ctx), &todoapi.UpdateTodoRequest{Id: uint64(id), Item: item, Completed: completed})
+	return fromMessage(result), grpcStatus(err)
+}
+
+func (g grpcTransport) deleteTodo(ctx context.Context, id uint) int {
+	_, err := g.todos.DeleteTodo(g.context(ctx), &todoapi.DeleteTodoRequest{Id: uint64(id)})
+	return grpcStatus(err)
+}
+
+func (g grpcTransport) getTodo(ctx context.Context, id uint) (todo, int) {
+	result, err := g.todos.GetTodo(g.context(ctx), &todoapi.GetTodoRequest{Id: uint64(id)})
+	return fromMessage(result), grpcStatus(err)
+}
+
+func fromModel(m *models.Todo) todo {
+	if m == nil {
+		return todo{}
+	}
+	return todo{m.ID, m.Item, m.Description, m.Completed, m.UserID}
+}
+
+func fromMessage(m *todoapi.Todo) todo {
+	if m == nil {
+		return todo{}
+	}
+	return todo{uint(m.GetId()), m.GetItem(), m.GetDescription(), m.GetCompleted(), uint(m.GetUserId())}
+}
+
+// httpStatus returns the status of a client error, or 200
+func httpStatus(err error) int {
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	if err != nil {
+		return -1
+	}
+	return http.StatusOK
+}
+
+// grpcStatus maps a gRPC error to the HTTP status the REST API uses for it
+func grpcStatus(err error) int {
+	switch status.Code(err) {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	}
+	return -1
+}
+
+// scenario runs the same create/update/delete sequence over a transport and
+// records the results, the audit trail and the change log
+func scenario(t *testing.T, newTransport func(*testing.T, string) transport) []string {
+	testdb.Open(t)
+	user, token := testdb.Login(t, "alice")
+	api := newTransport(t, token)
+	ctx := context.Background()
+	item, completed := "Walk the dog", true
+
+	var log []string
+	record := func(step string, result ...interface{}) {
+		log = append(log, fmt.Sprintf("%s: %+v", step, result))
+	}
+	created, code := api.createTodo(ctx, "Buy milk", "- milk\n- **eggs**", user.ID)
+	record("create", created, code)
+	_, code = api.createTodo(ctx, "Orphan", "", user.ID+100)
+	record("create for unknown user", code)
+	_, code = api.createTodo(ctx, "Too long", strings.Repeat("x", 20001), user.ID)
+	record("create with long description", code)
+	record("update", both(api.updateTodo(ctx, created.ID, &item, &completed)))
+	record("update unknown", codeOf(api.updateTodo(ctx, created.ID+100, &item, nil)))
+	record("get", both(api.getTodo(ctx, created.ID)))
+	record("delete", api.deleteTodo(ctx, created.ID))
+	record("get deleted", codeOf(api.getTodo(ctx, created.ID)))
+	record("delete deleted", api.deleteTodo(ctx, created.ID))
+
+	var audit []models.AuditEvent
+	db.DB.Order("id").Find(&audit)
+	for _, event := range audit {
+		delete(event.Changes, "created_at")
+		delete(event.Changes, "updated_at")
+		byActor := event.ActorID != nil && *event.ActorID == user.ID
+		record("audit", byActor, event.Action, event.EntityType, event.EntityID, event.Changes)
+	}
+	var changes []models.ChangeEvent
+	db.DB.Order("id").Find(&changes)
+	for _, change := range changes {
+		record("change", change.Type, change.EntityID, change.UserID)
+	}
+	return log
+}
+
+// both and codeOf turn a call's results into values to record
+func both(t todo, code int) []interface{}   { return []interface{}{t, code} }
+func codeOf(_ todo, code int) []interface{} { return []interface{}{code} }
+
+func TestHTTPAndGRPCParity(t *testing.T) {
+	overHTTP := scenario(t, newHTTPTransport)
+	overGRPC := scenario(t, newGRPCTransport)
+
+	if len(overHTTP) != len(overGRPC) {
+		t.Fatalf("HTTP recorded %d results, gRPC %d:\n%q\n%q", len(overHTTP), len(overGRPC), overHTTP, overGRPC)
+	}
+	for i := range overHTTP {
+		if overHTTP[i] != overGRPC[i] {
+			t.Errorf("results differ:\nHTTP: %s\ngRPC: %s", overHTTP[i], overGRPC[i])
+		}
+	}
+	t.Logf("compared %d results", len(overHTTP))
+}
@@ -0,0 +1,216 @@
+// Package grpcserver implements the TodoService and UserService defined in
+// proto/todoapi.proto on top of the service layer shared with the REST API.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-demo-api/auth"
+	"gin-demo-api/logging"
+	"gin-demo-api/metrics"
+	"gin-demo-api/models"
+	"gin-demo-api/proto/todoapi"
+	"gin-demo-api/ratelimit"
+	"gin-demo-api/service"
+	"gin-demo-api/tracing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type (
+	actorKey  struct{}
+	clientKey struct{}
+)
+
+// New returns a gRPC server with both services and reflection registered. Calls are
+// traced, measured and logged like HTTP requests, and rate limited by limiter like
+// REST requests; nil turns rate limiting off.
+func New(limiter *ratelimit.Limiter) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{tracing.UnaryServerInterceptor(), logging.UnaryServerInterceptor(), metrics.UnaryServerInterceptor()}
+	if limiter != nil {
+		interceptors = append(interceptors, limitFailures(limiter), authenticate, limitCalls(limiter))
+	} else {
+		interceptors = append(interceptors, authenticate)
+	}
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	todoapi.RegisterTodoServiceServer(server, &todoServer{})
+	todoapi.RegisterUserServiceServer(server, &userServer{})
+	reflection.Register(server)
+	return server
+}
+
+// authenticate identifies the caller from a session token or API key (authorization: Bearer,
+// or x-api-key), or in development the x-user-id metadata, like auth.Authenticate does for
+// HTTP. Calls without any continue anonymously.
+func authenticate(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if key := requestKey(md); auth.IsSessionToken(key) {
+		session, err := auth.IdentifySession(ctx, key, peerIP(ctx))
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		ctx = context.WithValue(ctx, actorKey{}, &session.UserID)
+		ctx = context.WithValue(ctx, clientKey{}, "user:"+strconv.FormatUint(uint64(session.UserID), 10))
+	} else if key != "" {
+		apiKey, err := auth.IdentifyKey(ctx, key)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if !auth.ScopesAllow(apiKey.Scopes, methodPath(info.FullMethod), !readMethod(info.FullMethod)) {
+			return nil, status.Error(codes.PermissionDenied, "API key scope does not allow this request")
+		}
+		ctx = context.WithValue(ctx, actorKey{}, &apiKey.UserID)
+		ctx = context.WithValue(ctx, clientKey{}, "key:"+strconv.FormatUint(uint64(apiKey.ID), 10))
+	} else if values := md.Get("x-user-id"); len(values) > 0 {
+		id, err := auth.Identify(ctx, values[0])
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		ctx = context.WithValue(ctx, actorKey{}, &id)
+	}
+	return handler(ctx, req)
+}
+
+// limitFailures refuses callers from an IP that keeps sending bad credentials, and
+// counts every call rejected as unauthenticated against it, like
+// ratelimit.Limiter.AuthFailures does for HTTP
+func limitFailures(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if requestKey(md) == "" && len(md.Get("x-user-id")) == 0 {
+			return handler(ctx, req)
+		}
+		if locked, _ := limiter.Locked(ctx, peerIP(ctx)); locked {
+			return nil, status.Error(codes.ResourceExhausted, "Too many failed authentication attempts")
+		}
+		resp, err := handler(ctx, req)
+		if status.Code(err) == codes.Unauthenticated {
+			limiter.Failed(ctx, peerIP(ctx))
+		}
+		return resp, err
+	}
+}
+
+// limitCalls counts every call against the caller's API-wide bucket: the API key, the
+// user of a session, or the peer IP for anonymous calls and claimed user IDs
+func limitCalls(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		client, ok := ctx.Value(clientKey{}).(string)
+		if !ok {
+			client = "ip:" + peerIP(ctx)
+		}
+		_, result, err := limiter.Take(ctx, client, info.FullMethod)
+		if err != nil {
+			// Better to serve without limits than to fail every call
+			slog.WarnContext(ctx, "Rate limit store failed; call allowed", "err", err)
+		} else if !result.Allowed {
+			return nil, status.Errorf(codes.ResourceExhausted, "Rate limit exceeded; retry in %s", result.RetryAfter.Round(time.Second))
+		}
+		return handler(ctx, req)
+	}
+}
+
+// peerIP returns the caller's IP address, for session activity and rate limits
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// requestKey returns the API key or session token sent as a bearer token or in x-api-key
+func requestKey(md metadata.MD) string {
+	if values := md.Get("authorization"); len(values) > 0 {
+		if scheme, token, ok := strings.Cut(values[0], " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	if values := md.Get("x-api-key"); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// methodPath maps a method to the REST collection it mirrors, for API key scopes
+func methodPath(fullMethod string) string {
+	if strings.Contains(fullMethod, "TodoService/") {
+		return "/todos"
+	}
+	return "/users"
+}
+
+// readMethod reports whether a method only reads, like a GET request
+func readMethod(fullMethod string) bool {
+	name := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	return strings.HasPrefix(name, "Get") || strings.HasPrefix(name, "List")
+}
+
+// actorID returns the authenticated user's ID for audit events, or nil
+func actorID(ctx context.Context) *uint {
+	id, _ := ctx.Value(actorKey{}).(*uint)
+	return id
+}
+
+// toStatus maps service errors to the gRPC codes matching the REST status codes
+func toStatus(err error, notFound string) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, service.ErrNotFound):
+		return status.Error(codes.NotFound, notFound)
+	case errors.Is(err, service.ErrInvalidUser):
+		return status.Error(codes.InvalidArgument, "Invalid User ID")
+	case errors.Is(err, service.ErrInvalidInput):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrUnauthenticated):
+		return status.Error(codes.Unauthenticated, "Authentication required")
+	case errors.Is(err, service.ErrForbidden):
+		return status.Error(codes.PermissionDenied, "Only the user or an admin can change this account")
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// todoMessage converts a todo to its protobuf message
+func todoMessage(todo models.Todo) *todoapi.Todo {
+	return &todoapi.Todo{
+		Id:          uint64(todo.ID),
+		Item:        todo.Item,
+		Description: todo.Description,
+		Completed:   todo.Completed,
+		UserId:      uint64(todo.UserID),
+		CreatedAt:   timestamppb.New(todo.CreatedAt),
+		UpdatedAt:   timestamppb.New(todo.UpdatedAt),
+	}
+}
+
+// userMessage converts a user, with any loaded todos, to its protobuf message
+func userMessage(user models.User) *todoapi.User {
+	message := &todoapi.User{
+		Id:        uint64(user.ID),
+		Username:  user.Username,
+		Email:     user.Email,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+	}
+	for _, todo := range user.Todos {
+		message.Todos = append(message.Todos, todoMessage(todo))
+	}
+	return message
+}
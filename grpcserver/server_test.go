@@ -0,0 +1,151 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/proto/todoapi"
+	"gin-demo-api/ratelimit"
+	"gin-demo-api/tracing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// dial serves gRPC on a local listener until the test ends and connects to it
+func dial(t *testing.T, limiter *ratelimit.Limiter) *grpc.ClientConn {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := New(limiter)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestRateLimit(t *testing.T) {
+	testdb.Open(t)
+	_, alice := testdb.Login(t, "alice")
+	_, bob := testdb.Login(t, "bob")
+	hour := ratelimit.Limit{Requests: 2, Period: time.Hour}
+	todos := todoapi.NewTodoServiceClient(dial(t, ratelimit.New(ratelimit.NewMemoryStore(), hour, nil, hour)))
+	list := func(token string) codes.Code {
+		ctx := context.Background()
+		if token != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+		}
+		_, err := todos.ListTodos(ctx, &todoapi.ListTodosRequest{})
+		return status.Code(err)
+	}
+
+	// Each user has a bucket of their own
+	for i := 0; i < 2; i++ {
+		if code := list(alice); code != codes.OK {
+			t.Fatalf("alice's call %d: %v", i, code)
+		}
+	}
+	if code := list(alice); code != codes.ResourceExhausted {
+		t.Errorf("alice over the limit: got %v, want ResourceExhausted", code)
+	}
+	if code := list(bob); code != codes.OK {
+		t.Errorf("bob's first call: %v", code)
+	}
+
+	// Bad credentials use up the peer's attempts, after which even valid ones are refused
+	for i := 0; i < 2; i++ {
+		if code := list("gds_guess"); code != codes.Unauthenticated {
+			t.Fatalf("bad token %d: got %v, want Unauthenticated", i, code)
+		}
+	}
+	if code := list(bob); code != codes.ResourceExhausted {
+		t.Errorf("valid token after failed attempts: got %v, want ResourceExhausted", code)
+	}
+}
+
+func TestCallsAreInstrumented(t *testing.T) {
+	testdb.Open(t)
+	_, token := testdb.Login(t, "alice")
+	previousProvider := otel.GetTracerProvider()
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown := tracing.Setup(exporter, "test")
+	provider := otel.GetTracerProvider().(*sdktrace.TracerProvider)
+	t.Cleanup(func() {
+		shutdown(context.Background())
+		otel.SetTracerProvider(previousProvider)
+	})
+	todos := todoapi.NewTodoServiceClient(dial(t, nil))
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"authorization", "Bearer "+token,
+		"x-request-id", "call-1",
+		"traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	var header metadata.MD
+	if _, err := todos.ListTodos(ctx, &todoapi.ListTodosRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := todos.GetTodo(ctx, &todoapi.GetTodoRequest{Id: 404}); status.Code(err) != codes.NotFound {
+		t.Fatalf("missing todo: got %v, want NotFound", err)
+	}
+
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "call-1" {
+		t.Errorf("x-request-id header = %v, want call-1", got)
+	}
+
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var span *tracetest.SpanStub
+	for _, s := range exporter.GetSpans() {
+		if s.Name == "todoapi.v1.TodoService/ListTodos" {
+			span = &s
+		}
+	}
+	if span == nil {
+		t.Fatalf("no span for ListTodos in %v", exporter.GetSpans())
+	}
+	if span.SpanContext.TraceID().String() != traceID {
+		t.Errorf("span trace ID = %s, want the caller's %s", span.SpanContext.TraceID(), traceID)
+	}
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, family := range families {
+		if family.GetName() != "grpc_server_handled_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["method"] == "/todoapi.v1.TodoService/GetTodo" && labels["code"] == "NotFound" && metric.GetCounter().GetValue() > 0 {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Error("grpc_server_handled_total has no NotFound GetTodo call")
+	}
+}
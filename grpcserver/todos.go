@@ -0,0 +1,82 @@
+package grpcserver
+
+import (
+	"context"
+
+	"gin-demo-api/models"
+	"gin-demo-api/proto/todoapi"
+	"gin-demo-api/service"
+)
+
+// todoServer implements todoapi.TodoServiceServer
+type todoServer struct {
+	todoapi.UnimplementedTodoServiceServer
+}
+
+func (s *todoServer) CreateTodo(ctx context.Context, req *todoapi.CreateTodoRequest) (*todoapi.Todo, error) {
+	input := models.Todo{
+		Item:        req.GetItem(),
+		Description: req.GetDescription(),
+		Completed:   req.GetCompleted(),
+		UserID:      uint(req.GetUserId()),
+	}
+	todo, err := service.CreateTodo(ctx, actorID(ctx), input)
+	if err != nil {
+		return nil, toStatus(err, "")
+	}
+	return todoMessage(todo), nil
+}
+
+func (s *todoServer) ListTodos(ctx context.Context, req *todoapi.ListTodosRequest) (*todoapi.ListTodosResponse, error) {
+	filter := service.TodoFilter{
+		Completed: req.Completed,
+		Limit:     int(req.GetLimit()),
+		Offset:    int(req.GetOffset()),
+	}
+	for _, id := range req.GetUserIds() {
+		filter.UserIDs = append(filter.UserIDs, uint(id))
+	}
+
+	todos, err := service.ListTodos(ctx, filter)
+	if err != nil {
+		return nil, toStatus(err, "")
+	}
+	response := &todoapi.ListTodosResponse{}
+	for _, todo := range todos {
+		response.Todos = append(response.Todos, todoMessage(todo))
+	}
+	return response, nil
+}
+
+func (s *todoServer) GetTodo(ctx context.Context, req *todoapi.GetTodoRequest) (*todoapi.Todo, error) {
+	todo, err := service.GetTodo(ctx, uint(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err, "Todo not found")
+	}
+	return todoMessage(todo), nil
+}
+
+func (s *todoServer) UpdateTodo(ctx context.Context, req *todoapi.UpdateTodoRequest) (*todoapi.Todo, error) {
+	changes := service.TodoChanges{
+		Item:        req.Item,
+		Description: req.Description,
+		Completed:   req.Completed,
+	}
+	if req.UserId != nil {
+		userID := uint(req.GetUserId())
+		changes.UserID = &userID
+	}
+
+	todo, err := service.UpdateTodo(ctx, actorID(ctx), uint(req.GetId()), changes)
+	if err != nil {
+		return nil, toStatus(err, "Todo not found")
+	}
+	return todoMessage(todo), nil
+}
+
+func (s *todoServer) DeleteTodo(ctx context.Context, req *todoapi.DeleteTodoRequest) (*todoapi.DeleteTodoResponse, error) {
+	if _, err := service.DeleteTodo(ctx, actorID(ctx), uint(req.GetId())); err != nil {
+		return nil, toStatus(err, "Todo not found")
+	}
+	return &todoapi.DeleteTodoResponse{}, nil
+}
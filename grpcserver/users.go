@@ -0,0 +1,65 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"gin-demo-api/models"
+	"gin-demo-api/proto/todoapi"
+	"gin-demo-api/service"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// userServer implements todoapi.UserServiceServer
+type userServer struct {
+	todoapi.UnimplementedUserServiceServer
+}
+
+func (s *userServer) CreateUser(ctx context.Context, req *todoapi.CreateUserRequest) (*todoapi.User, error) {
+	user, err := service.CreateUser(ctx, actorID(ctx), models.User{Username: req.GetUsername(), Email: req.GetEmail()})
+	if err != nil {
+		// Like POST /users, failures (e.g. a duplicate username) are the caller's to fix
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return userMessage(user), nil
+}
+
+func (s *userServer) ListUsers(ctx context.Context, req *todoapi.ListUsersRequest) (*todoapi.ListUsersResponse, error) {
+	users, err := service.ListUsers(ctx, true)
+	if err != nil {
+		return nil, toStatus(err, "")
+	}
+	response := &todoapi.ListUsersResponse{}
+	for _, user := range users {
+		response.Users = append(response.Users, userMessage(user))
+	}
+	return response, nil
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *todoapi.GetUserRequest) (*todoapi.User, error) {
+	user, err := service.GetUser(ctx, uint(req.GetId()), true)
+	if err != nil {
+		return nil, toStatus(err, "User not found")
+	}
+	return userMessage(user), nil
+}
+
+func (s *userServer) UpdateUser(ctx context.Context, req *todoapi.UpdateUserRequest) (*todoapi.User, error) {
+	user, err := service.UpdateUser(ctx, actorID(ctx), uint(req.GetId()), models.User{Username: req.GetUsername(), Email: req.GetEmail()})
+	if errors.Is(err, service.ErrNotFound) || errors.Is(err, service.ErrUnauthenticated) || errors.Is(err, service.ErrForbidden) {
+		return nil, toStatus(err, "User not found")
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return userMessage(user), nil
+}
+
+func (s *userServer) DeleteUser(ctx context.Context, req *todoapi.DeleteUserRequest) (*todoapi.DeleteUserResponse, error) {
+	if _, err := service.DeleteUser(ctx, actorID(ctx), uint(req.GetId())); err != nil {
+		return nil, toStatus(err, "User not found")
+	}
+	return &todoapi.DeleteUserResponse{}, nil
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"gin-demo-api/auth"
+	"gin-demo-api/models"
+	"gin-demo-api/service"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- R E S E N D (POST /auth/email-verification) ----------------------------
+// @Summary Resend the email verification link
+// @Description Emails the authenticated user a new link to verify their address. Earlier links keep working until they expire.
+// @tags Accounts
+// @Produce  json
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 202 {object} map[string]interface{} "Email sent"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 409 {object} map[string]interface{} "Email address already verified"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /auth/email-verification [post]
+func ResendVerification(c *gin.Context) {
+	userID, _ := auth.UserID(c)
+	user, err := service.GetUser(c.Request.Context(), userID, false)
+	if err != nil {
+		serverError(c, "Failed to load user", err)
+		return
+	}
+
+	err = service.SendVerification(c.Request.Context(), user)
+	if errors.Is(err, service.ErrAlreadyVerified) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email address is already verified"})
+		return
+	}
+	if err != nil {
+		serverError(c, "Failed to send verification email", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": true})
+}
+
+// --- V E R I F Y (GET /auth/verify-email) -----------------------------------
+// @Summary Verify an email address
+// @Description Opened from the emailed link. Marks the address the token was sent to as verified; each token works once.
+// @tags Accounts
+// @Produce  json
+// @Param token query string true "Verification token from the email"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]interface{} "Invalid or expired token"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /auth/verify-email [get]
+func VerifyEmail(c *gin.Context) {
+	user, err := service.VerifyEmail(c.Request.Context(), c.Query("token"))
+	if errors.Is(err, auth.ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		serverError(c, "Failed to verify email address", err)
+		return
+	}
+
+	respond(c, http.StatusOK, user)
+}
+
+// --- R E Q U E S T R E S E T (POST /auth/password-reset) --------------------
+// @Summary Request a password reset
+// @Description Emails a single-use reset token, valid for an hour, if an account has this address. The response is the same either way.
+// @tags Accounts
+// @Accept  json
+// @Produce  json
+// @Param request body models.PasswordResetRequest true "Email address of the account"
+// @Success 202 {object} map[string]interface{} "Email sent if the account exists"
+// @Failure 400 {object} map[string]interface{} "Invalid input format"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /auth/password-reset [post]
+func RequestPasswordReset(c *gin.Context) {
+	var input models.PasswordResetRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Failures are only logged, so they do not reveal whether the account exists
+	if err := service.RequestPasswordReset(c.Request.Context(), input.Email); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to send password reset email", "err", err)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": true})
+}
+
+// --- R E S E T (POST /auth/password-reset/confirm) --------------------------
+// @Summary Reset a password
+// @Description Sets a new password with the emailed token. The token, and any other reset token sent before, stops working, and all of the user's sessions are logged out.
+// @tags Accounts
+// @Accept  json
+// @Produce  json
+// @Param reset body models.PasswordReset true "Reset token and new password"
+// @Success 200 {object} map[string]interface{} "Password changed"
+// @Failure 400 {object} map[string]interface{} "Invalid input format, invalid password or invalid or expired token"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /auth/password-reset/confirm [post]
+func ResetPassword(c *gin.Context) {
+	var input models.PasswordReset
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := service.ResetPassword(c.Request.Context(), input.Token, input.Password)
+	if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, service.ErrInvalidInput) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		serverError(c, "Failed to reset password", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": true})
+}
+
+// --- L O G I N (POST /auth/login) -------------------------------------------
+// @Summary Sign in with a password
+// @Description Signs in with a username or email address and the password set with a password reset. Returns a session token to send as "Authorization: Bearer <token>", or a challenge when the user has two-factor authentication enabled.
+// @tags Accounts
+// @Accept  json
+// @Produce  json
+// @Param login body models.PasswordLogin true "Username or email address, and password"
+// @Success 200 {object} models.LoginResult
+// @Failure 400 {object} map[string]interface{} "Invalid input format"
+// @Failure 401 {object} map[string]interface{} "Invalid login or password"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /auth/login [post]
+func PasswordLogin(c *gin.Context) {
+	var input models.PasswordLogin
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := service.LoginWithPassword(c.Request.Context(), input.Login, input.Password, clientInfo(c))
+	if errors.Is(err, service.ErrInvalidCredentials) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid login or password"})
+		return
+	}
+	if err != nil {
+		serverError(c, "Failed to sign in", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"gin-demo-api/auth"
+	"gin-demo-api/db"
+	"gin-demo-api/models"
+	"gin-demo-api/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- C R E A T E (POST /api-keys) -------------------------------------------
+// @Summary Create an API key
+// @Description Creates a long-lived key for scripts and integrations, acting as the authenticated user. Send it as "Authorization: Bearer <key>" or in X-API-Key. The key is only returned by this request; store it safely. Scopes: all (default), read (read-only), todos (todo routes only), todos:read (read-only todo routes).
+// @tags API Keys
+// @Accept  json
+// @Produce  json
+// @Param api_key body models.APIKey true "API key data (name is required)"
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 201 {object} models.APIKey
+// @Failure 400 {object} map[string]interface{} "Invalid input format or unknown scope"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /api-keys [post]
+func CreateAPIKey(c *gin.Context) {
+	var input models.APIKey
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(input.Scopes) == 0 {
+		input.Scopes = models.StringList{models.ScopeAll}
+	}
+	if !validAPIKey(c, input) {
+		return
+	}
+
+	userID, _ := auth.UserID(c)
+	apiKey, err := service.CreateAPIKey(c.Request.Context(), actorID(c), userID, input)
+	if err != nil {
+		serverError(c, "Failed to create API key", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, apiKey)
+}
+
+// --- R E A D A L L (GET /api-keys) ------------------------------------------
+// @Summary Get your API keys
+// @Description Retrieves the authenticated user's keys that have not been revoked, with their prefix and when they were last used.
+// @tags API Keys
+// @Produce  json
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 200 {array} models.APIKey
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /api-keys [get]
+func FindAPIKeys(c *gin.Context) {
+	userID, _ := auth.UserID(c)
+
+	var keys []models.APIKey
+	db.DB.WithContext(c.Request.Context()).Where("user_id = ?", userID).Order("id").Find(&keys)
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// --- U P D A T E (PATCH /api-keys/:id) --------------------------------------
+// @Summary Update an API key
+// @Description Renames a key and/or replaces its scopes. The key itself does not change.
+// @tags API Keys
+// @Accept  json
+// @Produce  json
+// @Param id path int true "API key ID"
+// @Param api_key body models.APIKey true "API key data (name is required; scopes are kept when omitted)"
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 200 {object} models.APIKey
+// @Failure 400 {object} map[string]interface{} "Invalid input format or unknown scope"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 404 {object} map[string]interface{} "API key not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /api-keys/{id} [patch]
+func UpdateAPIKey(c *gin.Context) {
+	apiKey, ok := findOwnAPIKey(c)
+	if !ok {
+		return
+	}
+
+	// Start from the stored key so omitted fields keep their values
+	input := apiKey
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validAPIKey(c, input) {
+		return
+	}
+
+	apiKey, err := service.UpdateAPIKey(c.Request.Context(), actorID(c), apiKey, input)
+	if err != nil {
+		serverError(c, "Failed to update API key", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, apiKey)
+}
+
+// --- D E L E T E (DELETE /api-keys/:id) -------------------------------------
+// @Summary Revoke an API key
+// @Description Revokes a key; requests using it are rejected from then on.
+// @tags API Keys
+// @Produce  json
+// @Param id path int true "API key ID"
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 200 {object} map[string]interface{} "Revocation successful"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 404 {object} map[string]interface{} "API key not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /api-keys/{id} [delete]
+func DeleteAPIKey(c *gin.Context) {
+	apiKey, ok := findOwnAPIKey(c)
+	if !ok {
+		return
+	}
+
+	if err := service.RevokeAPIKey(c.Request.Context(), actorID(c), apiKey); err != nil {
+		serverError(c, "Failed to revoke API key", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": true})
+}
+
+// findOwnAPIKey loads the API key from the URL if the caller owns it
+func findOwnAPIKey(c *gin.Context) (models.APIKey, bool) {
+	userID, _ := auth.UserID(c)
+
+	var apiKey models.APIKey
+	if err := db.DB.WithContext(c.Request.Context()).Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&apiKey).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return apiKey, false
+	}
+	return apiKey, true
+}
+
+// validAPIKey checks the scopes, responding 400 when there are none or one is unknown
+func validAPIKey(c *gin.Context, apiKey models.APIKey) bool {
+	if len(apiKey.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one scope is required"})
+		return false
+	}
+	for _, scope := range apiKey.Scopes {
+		if !auth.ValidScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scope " + scope})
+			return false
+		}
+	}
+	return true
+}
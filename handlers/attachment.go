@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gin-demo-api/audit"
+	"gin-demo-api/auth"
+	"gin-demo-api/config"
+	"gin-demo-api/db"
+	"gin-demo-api/models"
+	"gin-demo-api/storage"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AttachmentLimits restricts uploads; main overrides it from the configuration
+var AttachmentLimits = config.Load().Attachments
+
+// --- C R E A T E (POST /todos/:id/attachments) ------------------------------
+// @Summary Upload an attachment
+// @Description Uploads a file to a todo as multipart form data. The content type is detected from the file and must be allowed; size is limited.
+// @tags Attachments
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param id path int true "Todo ID"
+// @Param file formData file true "File to upload"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {object} map[string]interface{} "Missing file"
+// @Failure 404 {object} map[string]interface{} "Todo not found"
+// @Failure 413 {object} map[string]interface{} "File too large"
+// @Failure 415 {object} map[string]interface{} "File type not allowed"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /todos/{id}/attachments [post]
+func CreateAttachment(c *gin.Context) {
+	var todo models.Todo
+	// Check if todo exists
+	if err := db.DB.WithContext(c.Request.Context()).Where("id = ?", c.Param("id")).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+
+	// Leave some room for the multipart envelope around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, AttachmentLimits.MaxSize+1<<20)
+	header, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || (err == nil && header.Size > AttachmentLimits.MaxSize) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File exceeds %d bytes", AttachmentLimits.MaxSize)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Multipart field 'file' is required"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	// Detect the type from the leading bytes, then stream them back in front of the rest
+	head := make([]byte, 3072)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	head = head[:n]
+	contentType := mimetype.Detect(head).String()
+	if !attachmentTypeAllowed(contentType) {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fmt.Sprintf("File type %s is not allowed", contentType)})
+		return
+	}
+
+	hash := sha256.New()
+	body := io.TeeReader(io.MultiReader(bytes.NewReader(head), file), hash)
+	key := fmt.Sprintf("todos/%d/%s", todo.ID, randomHex(16))
+	if err := storage.Blobs.Put(c.Request.Context(), key, body, header.Size); err != nil {
+		serverError(c, "Failed to store file", err)
+		return
+	}
+
+	attachment := models.Attachment{
+		TodoID:      todo.ID,
+		Filename:    filepath.Base(header.Filename),
+		ContentType: contentType,
+		Size:        header.Size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:  key,
+	}
+	if userID, ok := auth.UserID(c); ok {
+		attachment.UserID = &userID
+	}
+
+	// Save the metadata, removing the stored bytes again if that fails
+	err = db.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&attachment).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, actorID(c), audit.ActionCreate, "attachment", attachment.ID, nil, attachment)
+	})
+	if err != nil {
+		storage.Blobs.Delete(c.Request.Context(), key)
+		serverError(c, "Failed to save attachment", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// --- R E A D A L L (GET /todos/:id/attachments) -----------------------------
+// @Summary Get attachments of a todo item
+// @Description Retrieves the metadata of all files attached to a todo.
+// @tags Attachments
+// @Produce  json
+// @Param id path int true "Todo ID"
+// @Success 200 {array} models.Attachment
+// @Failure 404 {object} map[string]interface{} "Todo not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /todos/{id}/attachments [get]
+func FindAttachments(c *gin.Context) {
+	var todo models.Todo
+	// Check if todo exists
+	if err := db.DB.WithContext(c.Request.Context()).Where("id = ?", c.Param("id")).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+
+	var attachments []models.Attachment
+	db.DB.WithContext(c.Request.Context()).Where("todo_id = ?", todo.ID).Order("created_at, id").Find(&attachments)
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// --- D O W N L O A D (GET /todos/:id/attachments/:attachment_id) ------------
+// @Summary Download an attachment
+// @Description Streams the file contents. Supports Range requests and conditional requests via the SHA-256 ETag.
+// @tags Attachments
+// @Produce  octet-stream,json
+// @Param id path int true "Todo ID"
+// @Param attachment_id path int true "Attachment ID"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
+// @Success 200 {file} file "File contents"
+// @Success 206 {file} file "Partial file contents"
+// @Failure 404 {object} map[string]interface{} "Todo or attachment not found"
+// @Failure 416 {string} string "Range not satisfiable"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /todos/{id}/attachments/{attachment_id} [get]
+func DownloadAttachment(c *gin.Context) {
+	var todo models.Todo
+	// Check if todo exists; attachments of deleted todos are kept, but not served
+	if err := db.DB.WithContext(c.Request.Context()).Where("id = ?", c.Param("id")).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+
+	var attachment models.Attachment
+	if err := db.DB.WithContext(c.Request.Context()).Where("id = ? AND todo_id = ?", c.Param("attachment_id"), todo.ID).First(&attachment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+
+	blob, err := storage.Blobs.Open(c.Request.Context(), attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+	if err != nil {
+		serverError(c, "Failed to read file", err)
+		return
+	}
+	defer blob.Close()
+
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("ETag", `"`+attachment.SHA256+`"`)
+
+	// ServeContent handles Range, If-Range and If-None-Match
+	http.ServeContent(c.Writer, c.Request, attachment.Filename, attachment.CreatedAt, blob)
+}
+
+// --- D E L E T E (DELETE /todos/:id/attachments/:attachment_id) -------------
+// @Summary Delete an attachment
+// @Description Soft-deletes the attachment record and removes the stored file.
+// @tags Attachments
+// @Produce  json
+// @Param id path int true "Todo ID"
+// @Param attachment_id path int true "Attachment ID"
+// @Success 200 {object} map[string]interface{} "Deletion successful"
+// @Failure 404 {object} map[string]interface{} "Attachment not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /todos/{id}/attachments/{attachment_id} [delete]
+func DeleteAttachment(c *gin.Context) {
+	var attachment models.Attachment
+	if err := db.DB.WithContext(c.Request.Context()).Where("id = ? AND todo_id = ?", c.Param("attachment_id"), c.Param("id")).First(&attachment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+
+	// Remove the record first so a failure never leaves it pointing at a missing file
+	err := db.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&attachment).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, actorID(c), audit.ActionDelete, "attachment", attachment.ID, attachment, nil); err != nil {
+			return err
+		}
+		return storage.Blobs.Delete(c.Request.Context(), attachment.StorageKey)
+	})
+	if err != nil {
+		serverError(c, "Failed to delete attachment", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": true})
+}
+
+// attachmentTypeAllowed checks a detected MIME type against AttachmentLimits.AllowedTypes
+func attachmentTypeAllowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range AttachmentLimits.AllowedTypes {
+		if allowed == mediaType || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// randomHex returns n random bytes, hex-encoded
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+	"gin-demo-api/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDownloadAttachmentOfDeletedTodo(t *testing.T) {
+	testdb.Open(t)
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := storage.Blobs
+	storage.Blobs = store
+	t.Cleanup(func() { storage.Blobs = previous })
+
+	user, _ := testdb.Login(t, "alice")
+	todo := models.Todo{Item: "Pay rent", UserID: user.ID}
+	if err := db.DB.Create(&todo).Error; err != nil {
+		t.Fatal(err)
+	}
+	const content = "rent: 950"
+	if err := store.Put(context.Background(), "todos/1/receipt", strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatal(err)
+	}
+	attachment := models.Attachment{TodoID: todo.ID, Filename: "receipt.txt", ContentType: "text/plain", Size: int64(len(content)), SHA256: "x", StorageKey: "todos/1/receipt"}
+	if err := db.DB.Create(&attachment).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/todos/:id/attachments/:attachment_id", DownloadAttachment)
+	download := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos/1/attachments/1", nil))
+		return w
+	}
+
+	if w := download(); w.Code != http.StatusOK || w.Body.String() != content {
+		t.Fatalf("download: status %d, body %q", w.Code, w.Body)
+	}
+	if err := db.DB.Delete(&todo).Error; err != nil {
+		t.Fatal(err)
+	}
+	if w := download(); w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "Todo not found") {
+		t.Errorf("download after deleting the todo: status %d, body %s; want %d", w.Code, w.Body, http.StatusNotFound)
+	}
+}
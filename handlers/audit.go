@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"gin-demo-api/db"
+	"gin-demo-api/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- R E A D A L L (GET /audit) ---------------------------------------------
+// @Summary Query the audit trail
+// @Description Retrieves audit events, newest first. All filters are optional and combined with AND. Admins only, with a session token; API keys are refused.
+// @tags Audit
+// @Produce  json
+// @Security BearerAuth
+// @Param entity_type query string false "Entity type" Enums(todo, user, comment, attachment, webhook, api_key, session)
+// @Param entity_id query int false "Entity ID"
+// @Param actor_id query int false "ID of the user who made the change"
+// @Param action query string false "Action" Enums(create, update, delete)
+// @Param since query string false "Only events at or after this time (RFC 3339)"
+// @Param until query string false "Only events before this time (RFC 3339)"
+// @Param before_id query int false "Only events with a smaller ID (for paging)"
+// @Param limit query int false "Maximum number of events (default 50, max 500)"
+// @Success 200 {array} models.AuditEvent
+// @Failure 400 {object} map[string]interface{} "Invalid filter"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Admin role, a session token or two-factor authentication required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /audit [get]
+func FindAuditEvents(c *gin.Context) {
+	query := db.DB.WithContext(c.Request.Context()).Model(&models.AuditEvent{})
+
+	for _, filter := range []string{"entity_type", "action"} {
+		if value := c.Query(filter); value != "" {
+			query = query.Where(filter+" = ?", value)
+		}
+	}
+	for _, filter := range []string{"entity_id", "actor_id"} {
+		if value := c.Query(filter); value != "" {
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + filter})
+				return
+			}
+			query = query.Where(filter+" = ?", id)
+		}
+	}
+	if value := c.Query("before_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before_id"})
+			return
+		}
+		query = query.Where("id < ?", id)
+	}
+	if value := c.Query("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since, expected RFC 3339"})
+			return
+		}
+		query = query.Where("created_at >= ?", since)
+	}
+	if value := c.Query("until"); value != "" {
+		until, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid until, expected RFC 3339"})
+			return
+		}
+		query = query.Where("created_at < ?", until)
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+
+	events := []models.AuditEvent{}
+	query.Order("id DESC").Limit(min(limit, 500)).Find(&events)
+
+	c.JSON(http.StatusOK, events)
+}
+
+// --- H I S T O R Y (GET /todos/:id/history) ---------------------------------
+// @Summary Get the history of a todo item
+// @Description Retrieves every recorded change to a todo, oldest first, including after it was deleted.
+// @tags Todos
+// @Produce  json
+// @Param id path int true "Todo ID"
+// @Success 200 {array} models.AuditEvent
+// @Failure 404 {object} map[string]interface{} "Todo not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /todos/{id}/history [get]
+func FindTodoHistory(c *gin.Context) {
+	var todo models.Todo
+	// Deleted todos keep their history
+	if err := db.DB.WithContext(c.Request.Context()).Unscoped().Where("id = ?", c.Param("id")).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+
+	var events []models.AuditEvent
+	db.DB.WithContext(c.Request.Context()).Where("entity_type = ? AND entity_id = ?", "todo", todo.ID).Order("id").Find(&events)
+
+	c.JSON(http.StatusOK, events)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFindAuditEventsFilters(t *testing.T) {
+	testdb.Open(t)
+	alice, bob := uint(1), uint(2)
+	start := time.Date(2025, 10, 25, 12, 0, 0, 0, time.UTC)
+	for i, event := range []models.AuditEvent{
+		{ActorID: &alice, Action: "create", EntityType: "todo", EntityID: 1},
+		{ActorID: &alice, Action: "update", EntityType: "todo", EntityID: 1},
+		{ActorID: &bob, Action: "create", EntityType: "todo", EntityID: 2},
+		{ActorID: nil, Action: "create", EntityType: "user", EntityID: 3},
+		{ActorID: &bob, Action: "delete", EntityType: "webhook", EntityID: 1},
+	} {
+		event.CreatedAt = start.Add(time.Duration(i) * time.Hour)
+		if err := db.DB.Create(&event).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/audit", FindAuditEvents)
+
+	tests := []struct {
+		query string
+		want  []uint
+	}{
+		{"", []uint{5, 4, 3, 2, 1}},
+		{"?entity_type=todo", []uint{3, 2, 1}},
+		{"?entity_type=todo&entity_id=1", []uint{2, 1}},
+		{"?actor_id=2", []uint{5, 3}},
+		{"?action=create", []uint{4, 3, 1}},
+		{"?action=create&entity_type=todo&actor_id=1", []uint{1}},
+		{"?since=2025-10-25T13:00:00Z&until=2025-10-25T15:00:00Z", []uint{3, 2}},
+		{"?before_id=4&limit=2", []uint{3, 2}},
+		{"?entity_type=comment", []uint{}},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit"+tt.query, nil))
+		var events []models.AuditEvent
+		if err := json.Unmarshal(w.Body.Bytes(), &events); w.Code != http.StatusOK || err != nil {
+			t.Errorf("GET /audit%s: got %d %s", tt.query, w.Code, w.Body)
+			continue
+		}
+		ids := []uint{}
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("GET /audit%s: got events %v, want %v", tt.query, ids, tt.want)
+		}
+	}
+
+	for _, query := range []string{"?entity_id=x", "?actor_id=-1", "?before_id=x", "?since=yesterday", "?until=2025-10-25", "?limit=0"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET /audit%s: got %d, want 400", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"gin-demo-api/audit"
+	"gin-demo-api/auth"
+	"gin-demo-api/db"
+	"gin-demo-api/models"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// mentionPattern matches @username mentions in a comment body
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w.-]+)`)
+
+// --- C R E A T E (POST /todos/:id/comments) ---------------------------------
+// @Summary Comment on a todo item
+// @Description Adds a comment to a todo as the authenticated user. @username mentions are recorded for notification.
+// @tags Comments
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Todo ID"
+// @Param comment body models.Comment true "Comment data (only body is required)"
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 201 {object} models.Comment
+// @Failure 400 {object} map[string]interface{} "Invalid input format"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 404 {object} map[string]interface{} "Todo not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /todos/{id}/comments [post]
+func CreateComment(c *gin.Context) {
+	var todo models.Todo
+	// Check if todo exists
+	if err := db.DB.WithContext(c.Request.Context()).Where("id = ?", c.Param("id")).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+
+	var input models.Comment
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := auth.UserID(c)
+	comment := models.Comment{TodoID: todo.ID, UserID: userID, Body: input.Body}
+
+	// Save the comment, its mentions and the audit event together
+	err := db.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		if err := saveMentions(tx, &comment); err != nil {
+			return err
+		}
+		return audit.Record(tx, actorID(c), audit.ActionCreate, "comment", comment.ID, nil, comment)
+	})
+	if err != nil {
+		serverError(c, "Failed to save comment", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// --- R E A D A L L (GET /todos/:id/comments) --------------------------------
+// @Summary Get comments on a todo item
+// @Description Retrieves the comments on a todo, oldest first.
+// @tags Comments
+// @Produce  json
+// @Param id path int true "Todo ID"
+// @Success 200 {array} models.Comment
+// @Failure 404 {object} map[string]interface{} "Todo not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /todos/{id}/comments [get]
+func FindComments(c *gin.Context) {
+	var todo models.Todo
+	// Check if todo exists
+	if err := db.DB.WithContext(c.Request.Context()).Where("id = ?", c.Param("id")).First(&todo).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+
+	var comments []models.Comment
+	db.DB.WithContext(c.Request.Context()).Preload("Mentions").Where("todo_id = ?", todo.ID).Order("created_at, id").Find(&comments)
+
+	c.JSON(http.StatusOK, comments)
+}
+
+// --- U P D A T E (PATCH /todos/:id/comments/:comment_id) --------------------
+// @Summary Edit a comment
+// @Description Replaces the body of a comment. Only the author may edit; mentions are re-parsed.
+// @tags Comments
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Todo ID"
+// @Param comment_id path int true "Comment ID"
+// @Param comment body models.Comment true "Comment data (only body is updated)"
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} map[string]interface{} "Invalid input format"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Not the author"
+// @Failure 404 {object} map[string]interface{} "Comment not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /todos/{id}/comments/{comment_id} [patch]
+func UpdateComment(c *gin.Context) {
+	comment, ok := findOwnComment(c)
+	if !ok {
+		return
+	}
+
+	var input models.Comment
+	// Validate input JSON
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	before := comment
+	err := db.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&comment).Updates(models.Comment{Body: input.Body, EditedAt: &now}).Error; err != nil {
+			return err
+		}
+		// Mentions follow the current body
+		if err := tx.Where("comment_id = ?", comment.ID).Delete(&models.Mention{}).Error; err != nil {
+			return err
+		}
+		if err := saveMentions(tx, &comment); err != nil {
+			return err
+		}
+		return audit.Record(tx, actorID(c), audit.ActionUpdate, "comment", comment.ID, before, comment)
+	})
+	if err != nil {
+		serverError(c, "Failed to update comment", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// --- D E L E T E (DELETE /todos/:id/comments/:comment_id) -------------------
+// @Summary Delete a comment
+// @Description Soft-deletes a comment. Only the author may delete it.
+// @tags Comments
+// @Produce  json
+// @Param id path int true "Todo ID"
+// @Param comment_id path int true "Comment ID"
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 200 {object} map[string]interface{} "Deletion successful"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Not the author"
+// @Failure 404 {object} map[string]interface{} "Comment not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /todos/{id}/comments/{comment_id} [delete]
+func DeleteComment(c *gin.Context) {
+	comment, ok := findOwnComment(c)
+	if !ok {
+		return
+	}
+
+	// Soft delete the record
+	err := db.DB.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&comment).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, actorID(c), audit.ActionDelete, "comment", comment.ID, comment, nil)
+	})
+	if err != nil {
+		serverError(c, "Failed to delete comment", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": true})
+}
+
+// findOwnComment loads the comment from the URL and checks the caller wrote it
+func findOwnComment(c *gin.Context) (models.Comment, bool) {
+	var comment models.Comment
+	if err := db.DB.WithContext(c.Request.Context()).Where("id = ? AND todo_id = ?", c.Param("comment_id"), c.Param("id")).First(&comment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return comment, false
+	}
+
+	if userID, _ := auth.UserID(c); comment.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the author can change this comment"})
+		return comment, false
+	}
+
+	return comment, true
+}
+
+// saveMentions stores a Mention for every existing user @mentioned in the comment body
+func saveMentions(tx *gorm.DB, comment *models.Comment) error {
+	var usernames []string
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(comment.Body, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			usernames = append(usernames, match[1])
+		}
+	}
+
+	comment.Mentions = []models.Mention{}
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	var users []models.User
+	if err := tx.Where("username IN ?", usernames).Find(&users).Error; err != nil {
+		return err
+	}
+	for _, user := range users {
+		comment.Mentions = append(comment.Mentions, models.Mention{CommentID: comment.ID, UserID: user.ID, Username: user.Username})
+	}
+	if len(comment.Mentions) == 0 {
+		return nil
+	}
+	return tx.Create(&comment.Mentions).Error
+}
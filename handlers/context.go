@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"gin-demo-api/auth"
+	"gin-demo-api/models"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// paramID parses a numeric ID from the URL; ok is false when it is malformed
+func paramID(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	return uint(id), err == nil
+}
+
+// actorID returns the authenticated user's ID for audit events, or nil
+func actorID(c *gin.Context) *uint {
+	if id, ok := auth.UserID(c); ok {
+		return &id
+	}
+	return nil
+}
+
+// serverError logs the cause of a failed request and answers 500 with a generic message
+func serverError(c *gin.Context, message string, err error) {
+	slog.ErrorContext(c.Request.Context(), message, "err", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
+
+// clientInfo describes the client making the request, for the sessions it logs in to
+func clientInfo(c *gin.Context) models.ClientInfo {
+	return models.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"bytes"
+
+	"gin-demo-api/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	// GitHub-flavored Markdown; raw HTML in the source is dropped by goldmark
+	markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	// Sanitizer for the rendered output (safe for user-generated content)
+	htmlPolicy = bluemonday.UGCPolicy()
+)
+
+// renderMarkdown converts a Markdown description to sanitized HTML
+func renderMarkdown(source string) string {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		return ""
+	}
+	return htmlPolicy.Sanitize(buf.String())
+}
+
+// renderTodos fills DescriptionHTML when the request asks for ?render=html
+func renderTodos(c *gin.Context, todos ...*models.Todo) {
+	if c.Query("render") != "html" {
+		return
+	}
+	for _, todo := range todos {
+		todo.DescriptionHTML = renderMarkdown(todo.Description)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gin-demo-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name, source string
+		want         []string // present in the output
+		unwanted     []string // absent from the output
+	}{
+		{"emphasis and lists", "- milk\n- **eggs**", []string{"<ul>", "<li>milk</li>", "<strong>eggs</strong>"}, nil},
+		{"GitHub extensions", "~~done~~ https://example.com\n\n| a |\n|---|\n| b |", []string{"<del>done</del>", `<a href="https://example.com"`, "<table>", "<td>b</td>"}, nil},
+		{"raw HTML", "hi <script>alert(1)</script> <b onmouseover=\"x()\">there</b>", []string{"hi"}, []string{"<script", "alert(1)</script>", "onmouseover"}},
+		{"script links", "[click](javascript:alert(1))", []string{"click"}, []string{"javascript:"}},
+		{"images", "![x](https://example.com/x.png) <img src=x onerror=alert(1)>", []string{`src="https://example.com/x.png"`}, []string{"onerror", "src=\"x\""}},
+		{"links", "[site](https://example.com)", []string{`href="https://example.com"`, `rel="nofollow"`}, nil},
+	}
+	for _, tt := range tests {
+		got := renderMarkdown(tt.source)
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: %q does not contain %q", tt.name, got, want)
+			}
+		}
+		for _, unwanted := range tt.unwanted {
+			if strings.Contains(got, unwanted) {
+				t.Errorf("%s: %q contains %q", tt.name, got, unwanted)
+			}
+		}
+	}
+}
+
+func TestRenderTodosOnlyWhenAsked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for query, want := range map[string]string{"": "", "?render=text": "", "?render=html": "<p><em>soon</em></p>\n"} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/todos"+query, nil)
+		todo := models.Todo{Description: "*soon*"}
+		renderTodos(c, &todo)
+		if todo.DescriptionHTML != want {
+			t.Errorf("%q: description_html = %q, want %q", query, todo.DescriptionHTML, want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"gin-demo-api/auth"
+	"gin-demo-api/models"
+	"gin-demo-api/service"
+	"gin-demo-api/sso"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// loginCookie carries the state, nonce and PKCE verifier of a login in progress
+	loginCookie = "oidc_login"
+	// loginTimeout is how long the user has to finish signing in at the provider
+	loginTimeout = 10 * time.Minute
+	// purposeOIDCLogin signs the login cookie
+	purposeOIDCLogin = "oidc-login"
+)
+
+// PublicURL is the base URL of the API as clients reach it, for links to the login routes
+var PublicURL = "http://localhost:8080"
+
+// --- P R O V I D E R S (GET /auth/oidc/providers) ---------------------------
+// @Summary List identity providers
+// @Description Lists the OpenID Connect providers users can sign in with.
+// @tags Accounts
+// @Produce  json
+// @Success 200 {array} models.OIDCProvider
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /auth/oidc/providers [get]
+func FindOIDCProviders(c *gin.Context) {
+	providers := []models.OIDCProvider{}
+	for _, provider := range sso.Providers() {
+		providers = append(providers, models.OIDCProvider{
+			Name:        provider.Name,
+			DisplayName: provider.DisplayName,
+			LoginURL:    PublicURL + "/v1/auth/oidc/" + provider.Name + "/login",
+		})
+	}
+
+	c.JSON(http.StatusOK, providers)
+}
+
+// --- L O G I N (GET /auth/oidc/:provider/login) -----------------------------
+// @Summary Sign in with an identity provider
+// @Description Opened in a browser. Redirects to the provider to sign in (authorization code flow with PKCE); the provider redirects back to the callback.
+// @tags Accounts
+// @Produce  json
+// @Param provider path string true "Provider name"
+// @Success 302 "Redirect to the provider"
+// @Failure 404 {object} map[string]interface{} "Unknown provider"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 502 {object} map[string]interface{} "Provider unavailable"
+// @Router /auth/oidc/{provider}/login [get]
+func OIDCLogin(c *gin.Context) {
+	provider, ok := sso.Lookup(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+
+	state, nonce, verifier := randomHex(16), randomHex(16), oauth2.GenerateVerifier()
+	redirect, err := provider.AuthCodeURL(c.Request.Context(), state, nonce, verifier)
+	if err != nil {
+		providerError(c, err)
+		return
+	}
+
+	// The cookie is signed, so the callback can trust it without server-side state
+	value := auth.SignToken(purposeOIDCLogin, 0, strings.Join([]string{provider.Name, state, nonce, verifier}, " "), loginTimeout)
+	setLoginCookie(c, value, int(loginTimeout.Seconds()))
+	c.Redirect(http.StatusFound, redirect)
+}
+
+// --- C A L L B A C K (GET /auth/oidc/:provider/callback) --------------------
+// @Summary Finish signing in with an identity provider
+// @Description The provider redirects here. An identity seen before signs in its user; a new one is linked to the user with the same email if the user has verified it, or creates a user if the provider allows sign-up. Returns a session token to send as "Authorization: Bearer <token>".
+// @tags Accounts
+// @Produce  json
+// @Param provider path string true "Provider name"
+// @Param code query string false "Authorization code"
+// @Param state query string false "State from the login redirect"
+// @Param error query string false "Error reported by the provider"
+// @Success 200 {object} models.LoginResult
+// @Failure 400 {object} map[string]interface{} "Login failed, expired or was not started here"
+// @Failure 403 {object} map[string]interface{} "Email not verified by the provider or the account, or sign-up disabled"
+// @Failure 404 {object} map[string]interface{} "Unknown provider"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Failure 502 {object} map[string]interface{} "Provider unavailable"
+// @Router /auth/oidc/{provider}/callback [get]
+func OIDCCallback(c *gin.Context) {
+	provider, ok := sso.Lookup(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+
+	cookie, _ := c.Cookie(loginCookie)
+	setLoginCookie(c, "", -1) // Each login attempt can only finish once
+	_, value, err := auth.VerifyToken(cookie, purposeOIDCLogin)
+	fields := strings.Split(value, " ")
+	if err != nil || len(fields) != 4 || fields[0] != provider.Name || fields[1] != c.Query("state") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Login expired or was not started here; start again"})
+		return
+	}
+	if message := c.Query("error"); message != "" {
+		if description := c.Query("error_description"); description != "" {
+			message += ": " + description
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Login failed at the identity provider: " + message})
+		return
+	}
+
+	claims, err := provider.Exchange(c.Request.Context(), c.Query("code"), fields[2], fields[3])
+	if errors.Is(err, sso.ErrUnavailable) {
+		providerError(c, err)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Login failed: " + err.Error()})
+		return
+	}
+
+	result, err := service.LoginWithOIDC(c.Request.Context(), provider.Name, claims, provider.Signup, clientInfo(c))
+	if errors.Is(err, service.ErrUnverifiedEmail) || errors.Is(err, service.ErrUnverifiedAccount) || errors.Is(err, service.ErrSignupDisabled) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		serverError(c, "Failed to sign in", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// setLoginCookie stores or, with maxAge -1, clears the login cookie
+func setLoginCookie(c *gin.Context, value string, maxAge int) {
+	// Lax, so the cookie comes along on the provider's top-level redirect back
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(loginCookie, value, maxAge, "/", "", strings.HasPrefix(PublicURL, "https://"), true)
+}
+
+// providerError answers 502 when an identity provider cannot be reached
+func providerError(c *gin.Context, err error) {
+	c.Error(err)
+	c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider unavailable"})
+}
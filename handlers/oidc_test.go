@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-demo-api/auth"
+	"gin-demo-api/config"
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+	"gin-demo-api/sso"
+	"gin-demo-api/sso/ssotest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcTest drives logins through the login and callback routes against a mock provider
+type oidcTest struct {
+	t      *testing.T
+	api    string
+	client *http.Client
+}
+
+// startOIDC serves the OIDC routes with one provider, "mock", backed by ssotest
+func startOIDC(t *testing.T, signup bool) *oidcTest {
+	t.Helper()
+	provider, idp, err := ssotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(idp.Close)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/v1")
+	api.GET("/auth/oidc/:provider/login", OIDCLogin)
+	api.GET("/auth/oidc/:provider/callback", OIDCCallback)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	cfg := config.OIDCProviderConfig{Name: "mock", Issuer: provider.Issuer, ClientID: "todo", Signup: signup}
+	if err := sso.Configure([]config.OIDCProviderConfig{cfg}, server.URL); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sso.Configure(nil, "") })
+
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{
+		Jar:           jar,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	return &oidcTest{t, server.URL, client}
+}
+
+// redirect requests url and returns where it redirects to
+func (o *oidcTest) redirect(url string) *url.URL {
+	o.t.Helper()
+	resp, err := o.client.Get(url)
+	if err != nil {
+		o.t.Fatal(err)
+	}
+	resp.Body.Close()
+	location, err := resp.Location()
+	if resp.StatusCode != http.StatusFound || err != nil {
+		o.t.Fatalf("GET %s: status %d, want a redirect", url, resp.StatusCode)
+	}
+	return location
+}
+
+// start begins a login and returns the callback URL the provider redirects back to.
+// The extra parameters are added to the authorization request.
+func (o *oidcTest) start(extra url.Values) *url.URL {
+	o.t.Helper()
+	authorize := o.redirect(o.api + "/v1/auth/oidc/mock/login")
+	q := authorize.Query()
+	for name := range extra {
+		q.Set(name, extra.Get(name))
+	}
+	authorize.RawQuery = q.Encode()
+	return o.redirect(authorize.String())
+}
+
+// finish calls the callback and returns the status and the decoded response
+func (o *oidcTest) finish(callback *url.URL) (int, models.LoginResult, string) {
+	o.t.Helper()
+	resp, err := o.client.Get(callback.String())
+	if err != nil {
+		o.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		models.LoginResult
+		Error string `json:"error"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	return resp.StatusCode, body.LoginResult, body.Error
+}
+
+// login runs a whole login as email
+func (o *oidcTest) login(email string) (int, models.LoginResult, string) {
+	o.t.Helper()
+	return o.finish(o.start(url.Values{"login_hint": {email}}))
+}
+
+func TestOIDCSignupAndLogin(t *testing.T) {
+	testdb.Open(t)
+	o := startOIDC(t, true)
+
+	code, result, message := o.login("carol@example.com")
+	if code != http.StatusOK || result.Token == "" || result.User == nil || result.User.Username != "carol" || result.User.EmailVerifiedAt == nil {
+		t.Fatalf("first login: status %d, %+v, %q; want a session for a new, verified user", code, result, message)
+	}
+	userID := result.User.ID
+
+	// The same identity signs in the same user again
+	code, result, message = o.login("carol@example.com")
+	if code != http.StatusOK || result.User == nil || result.User.ID != userID {
+		t.Fatalf("second login: status %d, %+v, %q; want user %d", code, result, message, userID)
+	}
+	var identities []models.Identity
+	db.DB.Find(&identities)
+	if len(identities) != 1 || identities[0].Subject != ssotest.Subject("carol@example.com") || identities[0].UserID != userID {
+		t.Errorf("identities = %+v, want one for carol", identities)
+	}
+
+	// A callback can only be used once, since it clears the login cookie
+	callback := o.start(nil)
+	if code, _, _ := o.finish(callback); code != http.StatusOK {
+		t.Fatalf("login: status %d", code)
+	}
+	if code, _, _ := o.finish(callback); code != http.StatusBadRequest {
+		t.Errorf("replayed callback: status %d, want %d", code, http.StatusBadRequest)
+	}
+}
+
+func TestOIDCLinksVerifiedAccounts(t *testing.T) {
+	testdb.Open(t)
+	o := startOIDC(t, false)
+	now := time.Now()
+	verified := models.User{Username: "alice", Email: "alice@example.com", Role: models.RoleUser, EmailVerifiedAt: &now}
+	unverified := models.User{Username: "bob", Email: "bob@example.com", Role: models.RoleUser}
+	for _, user := range []*models.User{&verified, &unverified} {
+		if err := db.DB.Create(user).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if code, result, message := o.login("Alice@Example.com"); code != http.StatusOK || result.User == nil || result.User.ID != verified.ID {
+		t.Errorf("verified account: status %d, %+v, %q; want alice's session", code, result, message)
+	}
+	if code, _, message := o.login("bob@example.com"); code != http.StatusForbidden || !strings.Contains(message, "has not verified it") {
+		t.Errorf("unverified account: status %d, %q; want %d", code, message, http.StatusForbidden)
+	}
+	if code, _, message := o.login("dave@example.com"); code != http.StatusForbidden || !strings.Contains(message, "sign-up is disabled") {
+		t.Errorf("signup disabled: status %d, %q; want %d", code, message, http.StatusForbidden)
+	}
+
+	var count int64
+	db.DB.Model(&models.User{}).Count(&count)
+	if count != 2 {
+		t.Errorf("%d users, want no new ones", count)
+	}
+}
+
+func TestOIDCRejectsForgedLogins(t *testing.T) {
+	testdb.Open(t)
+	o := startOIDC(t, true)
+
+	// The provider has not verified the address
+	if code, _, message := o.finish(o.start(url.Values{"login_hint": {"eve@example.com"}, "email_verified": {"false"}})); code != http.StatusForbidden || !strings.Contains(message, "not verified this email") {
+		t.Errorf("unverified email: status %d, %q; want %d", code, message, http.StatusForbidden)
+	}
+
+	// The state does not match the login cookie, as when an attacker sends their own callback
+	callback := o.start(nil)
+	q := callback.Query()
+	q.Set("state", "attacker")
+	callback.RawQuery = q.Encode()
+	if code, _, message := o.finish(callback); code != http.StatusBadRequest || !strings.Contains(message, "not started here") {
+		t.Errorf("state mismatch: status %d, %q; want %d", code, message, http.StatusBadRequest)
+	}
+
+	// The ID token was issued for another login attempt than the cookie's
+	callback = o.start(nil)
+	api, _ := url.Parse(o.api)
+	var cookie *http.Cookie
+	for _, c := range o.client.Jar.Cookies(api) {
+		if c.Name == loginCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil {
+		t.Fatal("the login set no cookie")
+	}
+	_, value, err := auth.VerifyToken(cookie.Value, purposeOIDCLogin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Split(value, " ")
+	fields[2] = "othernonce"
+	cookie.Value = auth.SignToken(purposeOIDCLogin, 0, strings.Join(fields, " "), loginTimeout)
+	o.client.Jar.SetCookies(api, []*http.Cookie{cookie})
+	if code, _, message := o.finish(callback); code != http.StatusBadRequest || !strings.Contains(message, "nonce does not match") {
+		t.Errorf("nonce mismatch: status %d, %q; want %d", code, message, http.StatusBadRequest)
+	}
+
+	var count int64
+	db.DB.Model(&models.User{}).Count(&count)
+	if count != 0 {
+		t.Errorf("%d users, want none", count)
+	}
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"gin-demo-api/db"
+	"gin-demo-api/models"
+	"html"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Markers placed around matches before the snippet is HTML-escaped
+const (
+	matchStart = "\x02"
+	matchEnd   = "\x03"
+)
+
+// searchTerm is a single word or quoted phrase from the search query
+type searchTerm struct {
+	text   string
+	phrase bool
+	prefix bool
+}
+
+// --- S E A R C H (GET /todos/search) ----------------------------------------
+// @Summary Search todo items
+// @Description Ranked full-text search over todo items and descriptions. Supports prefix matching (groc*) and "quoted phrases"; all terms must match. Without the FTS5 index, matches are not ranked but returned most recently updated first.
+// @tags Todos
+// @Produce  json
+// @Param q query string true "Search query"
+// @Param limit query int false "Maximum number of results (default 20, max 100)"
+// @Success 200 {array} models.TodoSearchResult
+// @Failure 400 {object} map[string]interface{} "Missing or invalid query"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /todos/search [get]
+func SearchTodos(c *gin.Context) {
+	terms := parseSearchQuery(c.Query("q"))
+	if len(terms) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'q' is required"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	var results []models.TodoSearchResult
+	if db.FTSEnabled {
+		results, err = searchTodosFTS(c.Request.Context(), terms, limit)
+	} else {
+		results, err = searchTodosLike(c.Request.Context(), terms, limit)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search query"})
+		return
+	}
+
+	for i := range results {
+		results[i].Snippet = highlight(results[i].Snippet)
+	}
+
+	respond(c, http.StatusOK, results)
+}
+
+// searchTodosFTS runs the query against the todos_fts index, best matches first
+func searchTodosFTS(ctx context.Context, terms []searchTerm, limit int) ([]models.TodoSearchResult, error) {
+	expressions := make([]string, 0, len(terms))
+	for _, term := range terms {
+		expression := `"` + strings.ReplaceAll(term.text, `"`, `""`) + `"`
+		if term.prefix {
+			expression += "*"
+		}
+		expressions = append(expressions, expression)
+	}
+
+	results := []models.TodoSearchResult{}
+	err := db.DB.WithContext(ctx).Raw(`SELECT todos.*,
+			snippet(todos_fts, -1, ?, ?, '…', 12) AS snippet,
+			bm25(todos_fts) AS rank
+		FROM todos_fts
+		JOIN todos ON todos.id = todos_fts.rowid
+		WHERE todos_fts MATCH ? AND todos.deleted_at IS NULL
+		ORDER BY rank
+		LIMIT ?`, matchStart, matchEnd, strings.Join(expressions, " "), limit).
+		Scan(&results).Error
+	return results, err
+}
+
+// searchTodosLike is the fallback for drivers without FTS5; every term must appear in the item or description.
+// Results are the most recently updated matches, not the best ones; rank only counts their matches.
+func searchTodosLike(ctx context.Context, terms []searchTerm, limit int) ([]models.TodoSearchResult, error) {
+	query := db.DB.WithContext(ctx).Model(&models.Todo{})
+	for _, term := range terms {
+		pattern := "%" + escapeLike(strings.ToLower(term.text)) + "%"
+		query = query.Where("(LOWER(item) LIKE ? ESCAPE '\\' OR LOWER(description) LIKE ? ESCAPE '\\')", pattern, pattern)
+	}
+
+	var todos []models.Todo
+	if err := query.Order("updated_at DESC").Limit(limit).Find(&todos).Error; err != nil {
+		return nil, err
+	}
+
+	results := make([]models.TodoSearchResult, 0, len(todos))
+	for _, todo := range todos {
+		// Prefer the item for the snippet unless only the description matches
+		snippet, matches := likeSnippet(todo.Item, terms)
+		descriptionSnippet, descriptionMatches := likeSnippet(todo.Description, terms)
+		if matches == 0 {
+			snippet = descriptionSnippet
+		}
+		results = append(results, models.TodoSearchResult{Todo: todo, Snippet: snippet, Rank: -float64(matches + descriptionMatches)})
+	}
+	return results, nil
+}
+
+// parseSearchQuery splits q into words and "quoted phrases"; a trailing * marks a prefix term
+func parseSearchQuery(q string) []searchTerm {
+	var terms []searchTerm
+	for q = strings.TrimSpace(q); q != ""; q = strings.TrimSpace(q) {
+		var term searchTerm
+		if q[0] == '"' {
+			end := strings.IndexByte(q[1:], '"')
+			if end < 0 {
+				end = len(q) - 1
+			}
+			term = searchTerm{text: q[1 : end+1], phrase: true}
+			q = q[min(end+2, len(q)):]
+		} else {
+			end := strings.IndexAny(q, " \t\n\"")
+			if end < 0 {
+				end = len(q)
+			}
+			term = searchTerm{text: q[:end]}
+			q = q[end:]
+		}
+
+		if strings.HasSuffix(term.text, "*") && !term.phrase {
+			term.prefix = true
+		}
+		term.text = strings.TrimSpace(strings.TrimRight(term.text, "*"))
+		if term.text != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// likeSnippet returns a window of text around the first match with every match marked
+func likeSnippet(text string, terms []searchTerm) (string, int) {
+	patterns := make([]string, 0, len(terms))
+	for _, term := range terms {
+		patterns = append(patterns, regexp.QuoteMeta(term.text))
+	}
+	matches := regexp.MustCompile("(?i)"+strings.Join(patterns, "|")).FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return text, 0
+	}
+
+	// Keep roughly 60 bytes either side of the first match, on rune boundaries
+	start, end := max(matches[0][0]-60, 0), min(matches[0][1]+60, len(text))
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	var snippet strings.Builder
+	if start > 0 {
+		snippet.WriteString("…")
+	}
+	last := start
+	for _, match := range matches {
+		if match[0] < start || match[1] > end {
+			continue
+		}
+		snippet.WriteString(text[last:match[0]])
+		snippet.WriteString(matchStart + text[match[0]:match[1]] + matchEnd)
+		last = match[1]
+	}
+	snippet.WriteString(text[last:end])
+	if end < len(text) {
+		snippet.WriteString("…")
+	}
+	return snippet.String(), len(matches)
+}
+
+// highlight HTML-escapes a snippet and turns the match markers into <mark> tags
+func highlight(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	return strings.NewReplacer(matchStart, "<mark>", matchEnd, "</mark>").Replace(escaped)
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
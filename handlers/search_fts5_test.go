@@ -0,0 +1,86 @@
+//go:build sqlite_fts5
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// search runs GET /todos/search?q=query and returns the results
+func search(t *testing.T, query string) []models.TodoSearchResult {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/todos/search", SearchTodos)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos/search?q="+url.QueryEscape(query), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("%q: status %d, want 200: %s", query, w.Code, w.Body)
+	}
+	var results []models.TodoSearchResult
+	if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+	return results
+}
+
+func TestSearchTodosRanksAndHighlightsMatches(t *testing.T) {
+	testdb.Open(t)
+	if !db.FTSEnabled {
+		t.Fatal("FTS5 is not available in a build with -tags sqlite_fts5")
+	}
+	alice := testdb.CreateUser(t, models.User{Username: "alice"})
+	for _, todo := range []models.Todo{
+		{Item: "Plan the week", Description: "Call the plumber, pay rent, water the plants, pick up milk on the way home and book the dentist"},
+		{Item: "Buy milk", Description: "Oat milk, not <dairy> & not almond"},
+		{Item: "Walk the dog"},
+		{Item: "Milk the goats"},
+	} {
+		todo.UserID = alice.ID
+		if err := db.DB.Create(&todo).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.DB.Delete(&models.Todo{}, 4)
+
+	results := search(t, "milk")
+	if len(results) != 2 {
+		t.Fatalf("milk: got %d results, want the 2 todos not deleted", len(results))
+	}
+	if results[0].Item != "Buy milk" || results[1].Item != "Plan the week" {
+		t.Errorf("milk: got %q then %q, want the todo mentioning milk most first", results[0].Item, results[1].Item)
+	}
+	if results[0].Rank >= results[1].Rank {
+		t.Errorf("milk: ranks %v and %v are not in ascending bm25 order", results[0].Rank, results[1].Rank)
+	}
+
+	// Snippets mark every match in a window of the matching column, HTML-escaped
+	if results := search(t, "dairy"); len(results) != 1 || results[0].Snippet != "Oat milk, not &lt;<mark>dairy</mark>&gt; &amp; not almond" {
+		t.Errorf("dairy: got %+v, want an escaped snippet of the description", results)
+	}
+	if snippet := results[1].Snippet; !strings.HasSuffix(snippet, "…") || !strings.Contains(snippet, "<mark>milk</mark>") {
+		t.Errorf("milk: snippet %q of a long description is not a marked window", snippet)
+	}
+
+	// Prefixes and phrases
+	if results := search(t, "pla*"); len(results) != 1 || !strings.Contains(strings.ToLower(results[0].Snippet), "<mark>pla") {
+		t.Errorf("pla*: got %+v, want the plan with the prefix marked", results)
+	}
+	if results := search(t, `"oat milk"`); len(results) != 1 || results[0].Item != "Buy milk" {
+		t.Errorf(`"oat milk": got %+v, want only the exact phrase`, results)
+	}
+	if results := search(t, `"milk oat"`); len(results) != 0 {
+		t.Errorf(`"milk oat": got %+v, want no results`, results)
+	}
+}
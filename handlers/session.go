@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"gin-demo-api/auth"
+	"gin-demo-api/db"
+	"gin-demo-api/models"
+	"gin-demo-api/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- R E A D A L L (GET /me/sessions) ---------------------------------------
+// @Summary List your sessions
+// @Description Lists the authenticated user's active logins, most recently used first, with the device, IP address and last activity of each. The session making the request has current set.
+// @tags Sessions
+// @Produce  json
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 200 {array} models.Session
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /me/sessions [get]
+func FindSessions(c *gin.Context) {
+	userID, _ := auth.UserID(c)
+	currentID, _ := auth.SessionID(c)
+
+	var sessions []models.Session
+	db.DB.WithContext(c.Request.Context()).
+		Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("last_active_at DESC, id DESC").
+		Find(&sessions)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// --- D E L E T E (DELETE /me/sessions/:id) ----------------------------------
+// @Summary Revoke a session
+// @Description Logs out one of your sessions; its token stops working at once. Use "current" as the ID to log out the session making the request.
+// @tags Sessions
+// @Produce  json
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Param id path string true "Session ID, or current"
+// @Success 200 {object} map[string]interface{} "Revoked"
+// @Failure 400 {object} map[string]interface{} "Invalid session ID, or current without a session"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 404 {object} map[string]interface{} "Session not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /me/sessions/{id} [delete]
+func DeleteSession(c *gin.Context) {
+	userID, _ := auth.UserID(c)
+	id, ok := paramID(c, "id")
+	if c.Param("id") == "current" {
+		id, ok = auth.SessionID(c)
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID; use a numeric ID, or current with a session token"})
+		return
+	}
+
+	found, err := service.RevokeSession(c.Request.Context(), actorID(c), userID, id)
+	if err != nil {
+		serverError(c, "Failed to revoke session", err)
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": true})
+}
+
+// --- D E L E T E A L L (DELETE /me/sessions) --------------------------------
+// @Summary Log out everywhere
+// @Description Revokes all of your sessions. With keep_current, the session making the request stays logged in. API keys are not affected.
+// @tags Sessions
+// @Produce  json
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Param keep_current query bool false "Keep the session making the request"
+// @Success 200 {object} map[string]interface{} "Number of sessions revoked"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /me/sessions [delete]
+func DeleteSessions(c *gin.Context) {
+	userID, _ := auth.UserID(c)
+	var keep uint
+	if c.Query("keep_current") == "true" {
+		keep, _ = auth.SessionID(c)
+	}
+
+	revoked, err := service.RevokeUserSessions(c.Request.Context(), actorID(c), userID, keep)
+	if err != nil {
+		serverError(c, "Failed to revoke sessions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-demo-api/auth"
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readEvents resumes the change feed after lastEventID and returns the IDs of the first
+// want events
+func readEvents(t *testing.T, url, token, lastEventID string, want int) []uint64 {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Last-Event-ID", lastEventID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d, want 200", resp.StatusCode)
+	}
+
+	var ids []uint64
+	scanner := bufio.NewScanner(resp.Body)
+	for len(ids) < want && scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+			id, _ := strconv.ParseUint(value, 10, 64)
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) < want {
+		t.Fatalf("got events %v before the stream ended (%v), want %d", ids, scanner.Err(), want)
+	}
+	return ids
+}
+
+func TestStreamEventsResumesWithVisibleChanges(t *testing.T) {
+	testdb.Open(t)
+	alice, aliceToken := testdb.Login(t, "alice")
+	bob, _ := testdb.Login(t, "bob")
+	now := time.Now()
+	admin := testdb.CreateUser(t, models.User{Username: "root", Role: models.RoleAdmin, TwoFactorEnabledAt: &now})
+	adminToken, _ := testdb.Session(t, admin.ID, true)
+	for _, entry := range []models.ChangeEvent{
+		{Type: "todo.created", EntityType: "todo", EntityID: 1, UserID: alice.ID, Data: "{}"},
+		{Type: "todo.created", EntityType: "todo", EntityID: 2, UserID: bob.ID, Data: "{}"},
+		{Type: "user.updated", EntityType: "user", EntityID: alice.ID, UserID: alice.ID, Data: "{}"},
+		{Type: "todo.deleted", EntityType: "todo", EntityID: 1, UserID: alice.ID, Data: "{}"},
+	} {
+		if err := db.DB.Create(&entry).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(auth.Authenticate())
+	router.GET("/events", auth.RequireUser(), StreamEvents)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	if resp, err := http.Get(server.URL + "/events"); err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("anonymous stream: got %v, %v; want 401", resp, err)
+	}
+
+	tests := []struct {
+		name, token, lastEventID string
+		want                     []uint64
+	}{
+		{"alice from the start", aliceToken, "0", []uint64{1, 3, 4}},
+		{"alice resuming", aliceToken, "1", []uint64{3, 4}},
+		{"admin resuming", adminToken, "1", []uint64{2, 3, 4}},
+	}
+	for _, tt := range tests {
+		if got := readEvents(t, server.URL+"/events", tt.token, tt.lastEventID, len(tt.want)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got events %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
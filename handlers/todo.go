@@ -1,9 +1,12 @@
 package handlers
 
 import (
-	"gin-demo-api/db"
+	"errors"
 	"gin-demo-api/models"
+	"gin-demo-api/service"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,8 +18,10 @@ import (
 // @Accept  json
 // @Produce  json
 // @Param todo body models.Todo true "Todo item data (requires user_id)"
+// @Param render query string false "Set to html to include description_html" Enums(html)
 // @Success 201 {object} models.Todo
 // @Failure 400 {object} map[string]interface{} "Invalid input format or invalid User ID"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
 // @Router /todos [post]
 func CreateTodo(c *gin.Context) {
 	var input models.Todo
@@ -25,33 +30,75 @@ func CreateTodo(c *gin.Context) {
 		return
 	}
 
-	// Validate UserID exists before creating todo
-	var user models.User
-	if err := db.DB.First(&user, input.UserID).Error; err != nil {
+	// Save the new Todo record (the service validates the UserID)
+	todo, err := service.CreateTodo(c.Request.Context(), actorID(c), input)
+	if errors.Is(err, service.ErrInvalidUser) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid User ID"})
 		return
 	}
+	if err != nil {
+		serverError(c, "Failed to create todo", err)
+		return
+	}
 
-	// Save the new Todo record to the database
-	db.DB.Create(&input)
-
-	c.JSON(http.StatusCreated, input)
+	renderTodos(c, &todo)
+	respond(c, http.StatusCreated, todo)
 }
 
 // --- R E A D A L L (GET /todos) ---------------------------------------------
 // @Summary Get all todo items
-// @Description Retrieves a list of all todo items.
+// @Description Retrieves todo items ordered by ID, optionally filtered and paged. Without limit, all matching todos are returned.
 // @tags Todos
 // @Produce  json
+// @Param user_id query string false "Comma-separated owner user IDs"
+// @Param completed query bool false "Only completed (true) or open (false) todos"
+// @Param limit query int false "Maximum number of todos (max 500)"
+// @Param offset query int false "Number of todos to skip"
+// @Param render query string false "Set to html to include description_html" Enums(html)
 // @Success 200 {array} models.Todo
+// @Failure 400 {object} map[string]interface{} "Invalid filter"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
 // @Router /todos [get]
 func FindTodos(c *gin.Context) {
-	var todos []models.Todo
+	var filter service.TodoFilter
+	if value := c.Query("user_id"); value != "" {
+		ids, err := parseIDList(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
+			return
+		}
+		filter.UserIDs = ids
+	}
+	if value := c.Query("completed"); value != "" {
+		completed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid completed"})
+			return
+		}
+		filter.Completed = &completed
+	}
+	for name, target := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if value := c.Query(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+				return
+			}
+			*target = n
+		}
+	}
 
-	// Find all Todo records
-	db.DB.Find(&todos)
+	// Find the matching Todo records
+	todos, err := service.ListTodos(c.Request.Context(), filter)
+	if err != nil {
+		serverError(c, "Failed to load todos", err)
+		return
+	}
 
-	c.JSON(http.StatusOK, todos)
+	for i := range todos {
+		renderTodos(c, &todos[i])
+	}
+	respond(c, http.StatusOK, todos)
 }
 
 // --- R E A D O N E (GET /todos/:id) -----------------------------------------
@@ -60,52 +107,66 @@ func FindTodos(c *gin.Context) {
 // @tags Todos
 // @Produce  json
 // @Param id path int true "Todo ID"
+// @Param render query string false "Set to html to include description_html" Enums(html)
 // @Success 200 {object} models.Todo
 // @Failure 404 {object} map[string]interface{} "Todo not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
 // @Router /todos/{id} [get]
 func FindTodo(c *gin.Context) {
-	var todo models.Todo
-
 	// Find record by ID (from URL parameter)
-	if err := db.DB.Where("id = ?", c.Param("id")).First(&todo).Error; err != nil {
+	id, ok := paramID(c, "id")
+	todo, err := service.GetTodo(c.Request.Context(), id)
+	if !ok || err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, todo)
+	renderTodos(c, &todo)
+	respond(c, http.StatusOK, todo)
 }
 
 // --- U P D A T E (PATCH /todos/:id) -----------------------------------------
 // @Summary Update a todo item
-// @Description Updates the item and/or completed status for a specific todo.
+// @Description Updates the item, description and/or completed status for a specific todo.
 // @tags Todos
 // @Accept  json
 // @Produce  json
 // @Param id path int true "Todo ID"
-// @Param todo body models.Todo true "Todo data (item and/or completed status)"
+// @Param todo body models.Todo true "Todo data (item, description and/or completed status)"
+// @Param render query string false "Set to html to include description_html" Enums(html)
 // @Success 200 {object} models.Todo
-// @Failure 400 {object} map[string]interface{} "Invalid input format"
+// @Failure 400 {object} map[string]interface{} "Invalid input format or invalid User ID"
 // @Failure 404 {object} map[string]interface{} "Todo not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
 // @Router /todos/{id} [patch]
 func UpdateTodo(c *gin.Context) {
-	var todo models.Todo
 	// Check if todo exists
-	if err := db.DB.Where("id = ?", c.Param("id")).First(&todo).Error; err != nil {
+	id, ok := paramID(c, "id")
+	if _, err := service.GetTodo(c.Request.Context(), id); !ok || err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
 		return
 	}
 
-	var input models.Todo
-	// Validate input JSON
+	var input service.TodoChanges
+	// Validate input JSON; only the fields present are changed
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Update the record with the new input data
-	db.DB.Model(&todo).Updates(input)
+	todo, err := service.UpdateTodo(c.Request.Context(), actorID(c), id, input)
+	if errors.Is(err, service.ErrInvalidUser) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid User ID"})
+		return
+	}
+	if err != nil {
+		serverError(c, "Failed to update todo", err)
+		return
+	}
 
-	c.JSON(http.StatusOK, todo)
+	renderTodos(c, &todo)
+	respond(c, http.StatusOK, todo)
 }
 
 // --- D E L E T E (DELETE /todos/:id) ----------------------------------------
@@ -116,17 +177,34 @@ func UpdateTodo(c *gin.Context) {
 // @Param id path int true "Todo ID"
 // @Success 200 {object} map[string]interface{} "Deletion successful"
 // @Failure 404 {object} map[string]interface{} "Todo not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
 // @Router /todos/{id} [delete]
 func DeleteTodo(c *gin.Context) {
-	var todo models.Todo
 	// Check if todo exists
-	if err := db.DB.Where("id = ?", c.Param("id")).First(&todo).Error; err != nil {
+	id, ok := paramID(c, "id")
+	if _, err := service.GetTodo(c.Request.Context(), id); !ok || err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
 		return
 	}
 
 	// Soft delete the record
-	db.DB.Delete(&todo)
+	if _, err := service.DeleteTodo(c.Request.Context(), actorID(c), id); err != nil {
+		serverError(c, "Failed to delete todo", err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"data": true})
 }
+
+// parseIDList parses a comma-separated list of IDs
+func parseIDList(value string) ([]uint, error) {
+	var ids []uint
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"errors"
+	"gin-demo-api/auth"
+	"gin-demo-api/models"
+	"gin-demo-api/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- S T A T U S (GET /auth/2fa) --------------------------------------------
+// @Summary Get two-factor authentication status
+// @Description Whether the authenticated user has two-factor authentication enabled, whether their role requires it, and how many recovery codes are left.
+// @tags Two-Factor Authentication
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} models.TwoFactorStatus
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "A session token is required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /auth/2fa [get]
+func GetTwoFactorStatus(c *gin.Context) {
+	userID, _ := auth.UserID(c)
+
+	status, err := service.GetTwoFactorStatus(c.Request.Context(), userID)
+	if err != nil {
+		serverError(c, "Failed to load two-factor status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// --- E N R O L L (POST /auth/2fa/enroll) ------------------------------------
+// @Summary Enroll in two-factor authentication
+// @Description Generates a TOTP secret. Show otpauth_url as a QR code for an authenticator app to scan, then activate with a code from the app. Enrolling again before activating replaces the secret.
+// @tags Two-Factor Authentication
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} models.TwoFactorEnrollment
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "A session token is required"
+// @Failure 409 {object} map[string]interface{} "Already enabled"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /auth/2fa/enroll [post]
+func EnrollTwoFactor(c *gin.Context) {
+	userID, _ := auth.UserID(c)
+
+	enrollment, err := service.EnrollTwoFactor(c.Request.Context(), userID)
+	if errors.Is(err, service.ErrTwoFactorEnabled) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		serverError(c, "Failed to enroll in two-factor authentication", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// --- A C T I V A T E (POST /auth/2fa/activate) ------------------------------
+// @Summary Activate two-factor authentication
+// @Description Turns two-factor authentication on with a code from the enrolled authenticator app. Returns recovery codes, which are shown only this once. The current session counts as confirmed with a second factor.
+// @tags Two-Factor Authentication
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param code body models.TwoFactorCode true "Code from the authenticator app"
+// @Success 200 {object} models.RecoveryCodes
+// @Failure 400 {object} map[string]interface{} "Invalid input format or invalid code"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "A session token is required"
+// @Failure 409 {object} map[string]interface{} "Already enabled, or not enrolled"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /auth/2fa/activate [post]
+func ActivateTwoFactor(c *gin.Context) {
+	userID, _ := auth.UserID(c)
+	var input models.TwoFactorCode
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sessionID, _ := auth.SessionID(c)
+	codes, err := service.ActivateTwoFactor(c.Request.Context(), userID, sessionID, input.Code)
+	if twoFactorError(c, err) {
+		return
+	}
+	if err != nil {
+		serverError(c, "Failed to activate two-factor authentication", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// --- R E C O V E R Y C O D E S (POST /auth/2fa/recovery-codes) --------------
+// @Summary Regenerate recovery codes
+// @Description Replaces all recovery codes with new ones, which are shown only this once. Confirm with a code from the authenticator app or a recovery code.
+// @tags Two-Factor Authentication
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param code body models.TwoFactorCode true "Code from the authenticator app, or a recovery code"
+// @Success 200 {object} models.RecoveryCodes
+// @Failure 400 {object} map[string]interface{} "Invalid input format or invalid code"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "A session token is required"
+// @Failure 409 {object} map[string]interface{} "Not enabled"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded, or too many wrong codes"
+// @Router /auth/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	userID, _ := auth.UserID(c)
+	var input models.TwoFactorCode
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := service.RegenerateRecoveryCodes(c.Request.Context(), userID, input.Code)
+	if twoFactorError(c, err) {
+		return
+	}
+	if err != nil {
+		serverError(c, "Failed to regenerate recovery codes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// --- D I S A B L E (DELETE /auth/2fa) ---------------------------------------
+// @Summary Disable two-factor authentication
+// @Description Turns two-factor authentication off and deletes the recovery codes. Confirm with a code from the authenticator app or a recovery code. Not allowed for roles the policy requires it for.
+// @tags Two-Factor Authentication
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param code body models.TwoFactorCode true "Code from the authenticator app, or a recovery code"
+// @Success 200 {object} map[string]interface{} "Disabled"
+// @Failure 400 {object} map[string]interface{} "Invalid input format or invalid code"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Required for this role, or a session token is required"
+// @Failure 409 {object} map[string]interface{} "Not enabled"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded, or too many wrong codes"
+// @Router /auth/2fa [delete]
+func DisableTwoFactor(c *gin.Context) {
+	userID, _ := auth.UserID(c)
+	var input models.TwoFactorCode
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := service.DisableTwoFactor(c.Request.Context(), userID, input.Code)
+	if twoFactorError(c, err) {
+		return
+	}
+	if err != nil {
+		serverError(c, "Failed to disable two-factor authentication", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": true})
+}
+
+// --- V E R I F Y (POST /auth/2fa/verify) ------------------------------------
+// @Summary Complete a login with a second factor
+// @Description Logins of users with two-factor authentication enabled return a challenge instead of a session token. Send it here within 5 minutes, with a code from the authenticator app or a recovery code, to get the session token. A challenge works once, and is given up after 3 wrong codes; after 10 wrong codes in a row the user's codes are refused for 15 minutes.
+// @tags Two-Factor Authentication
+// @Accept  json
+// @Produce  json
+// @Param login body models.TwoFactorLogin true "Login challenge and code"
+// @Success 200 {object} models.LoginResult
+// @Failure 400 {object} map[string]interface{} "Invalid input format, or invalid or expired challenge"
+// @Failure 401 {object} map[string]interface{} "Invalid code"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded, or too many wrong codes"
+// @Router /auth/2fa/verify [post]
+func VerifyTwoFactorLogin(c *gin.Context) {
+	var input models.TwoFactorLogin
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := service.VerifyTwoFactorLogin(c.Request.Context(), input.Challenge, input.Code, clientInfo(c))
+	if errors.Is(err, auth.ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Login expired; start again"})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidCode) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrTwoFactorLocked) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		serverError(c, "Failed to sign in", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// twoFactorError answers the errors the two-factor settings share, reporting whether it did
+func twoFactorError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrInvalidCode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorLocked):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorEnabled), errors.Is(err, service.ErrTwoFactorNotEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
@@ -1,8 +1,9 @@
 package handlers
 
 import (
-	"gin-demo-api/db"
+	"errors"
 	"gin-demo-api/models"
+	"gin-demo-api/service"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -10,13 +11,14 @@ import (
 
 // --- C R E A T E (POST /users) ------------------------------------------------
 // @Summary Create a new user
-// @Description Creates a new user with a unique username and email.
+// @Description Creates a new user with a unique username and email, and emails them a link to verify the address.
 // @tags Users
 // @Accept  json
 // @Produce  json
 // @Param user body models.User true "User data (only username and email are required)"
 // @Success 201 {object} models.User
 // @Failure 400 {object} map[string]interface{} "Invalid input format or duplicate entry"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
 // @Router /users [post]
 func CreateUser(c *gin.Context) {
 	var input models.User
@@ -26,9 +28,13 @@ func CreateUser(c *gin.Context) {
 	}
 
 	// Save the new User record to the database
-	db.DB.Create(&input)
+	user, err := service.CreateUser(c.Request.Context(), actorID(c), input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusCreated, input)
+	respond(c, http.StatusCreated, user)
 }
 
 // --- R E A D A L L (GET /users) ---------------------------------------------
@@ -37,14 +43,17 @@ func CreateUser(c *gin.Context) {
 // @tags Users
 // @Produce  json
 // @Success 200 {array} models.User
-// @Router /users [get] // <-- CORRECT: /users [get] for ALL users
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /users [get]
 func FindUsers(c *gin.Context) {
-	var users []models.User
-
 	// Preload the Todos relationship when retrieving users
-	db.DB.Preload("Todos").Find(&users)
+	users, err := service.ListUsers(c.Request.Context(), true)
+	if err != nil {
+		serverError(c, "Failed to load users", err)
+		return
+	}
 
-	c.JSON(http.StatusOK, users)
+	respond(c, http.StatusOK, users)
 }
 
 // --- R E A D O N E (GET /users/:id) -----------------------------------------
@@ -55,35 +64,42 @@ func FindUsers(c *gin.Context) {
 // @Param id path int true "User ID"
 // @Success 200 {object} models.User
 // @Failure 404 {object} map[string]interface{} "User not found"
-// @Router /users/{id} [get] // <-- CORRECT: /users/{id} [get] for ONE user
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /users/{id} [get]
 func FindUser(c *gin.Context) {
-	var user models.User
-
 	// Find record by ID (from URL parameter), Preload Todos
-	if err := db.DB.Preload("Todos").Where("id = ?", c.Param("id")).First(&user).Error; err != nil {
+	id, ok := paramID(c, "id")
+	user, err := service.GetUser(c.Request.Context(), id, true)
+	if !ok || err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	respond(c, http.StatusOK, user)
 }
 
 // --- U P D A T E (PATCH /users/:id) -----------------------------------------
 // @Summary Update a user
-// @Description Updates the username and/or email for a specific user.
+// @Description Updates the username and/or email for a specific user. Users can change their own account; admins can change any. A changed email must be verified again: email_verified_at is cleared and a new link is sent.
 // @tags Users
 // @Accept  json
 // @Produce  json
 // @Param id path int true "User ID"
 // @Param user body models.User true "User data (only username/email are updated)"
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
 // @Success 200 {object} models.User
-// @Failure 400 {object} map[string]interface{} "Invalid input format"
+// @Failure 400 {object} map[string]interface{} "Invalid input format or duplicate entry"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Not the user or an admin"
 // @Failure 404 {object} map[string]interface{} "User not found"
-// @Router /users/{id} [patch] // <-- CORRECT: /users/{id} [patch] for UPDATE
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /users/{id} [patch]
 func UpdateUser(c *gin.Context) {
-	var user models.User
 	// Check if user exists
-	if err := db.DB.Where("id = ?", c.Param("id")).First(&user).Error; err != nil {
+	id, ok := paramID(c, "id")
+	if _, err := service.GetUser(c.Request.Context(), id, false); !ok || err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -96,31 +112,53 @@ func UpdateUser(c *gin.Context) {
 	}
 
 	// Update the record with the new input data
-	db.DB.Model(&user).Updates(models.User{Username: input.Username, Email: input.Email})
+	user, err := service.UpdateUser(c.Request.Context(), actorID(c), id, input)
+	if errors.Is(err, service.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the user or an admin can change this account"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, user)
+	respond(c, http.StatusOK, user)
 }
 
 // --- D E L E T E (DELETE /users/:id) ----------------------------------------
 // @Summary Delete a user
-// @Description Soft-deletes a user by ID.
+// @Description Soft-deletes a user by ID. Users can delete their own account; admins can delete any.
 // @tags Users
 // @Produce  json
 // @Param id path int true "User ID"
-// @Success 200 {object} map[string]interface{} "Deletion successful" // <-- FIXED gin.H here
-// @Failure 404 {object} map[string]interface{} "User not found" // <-- FIXED gin.H here
-// @Router /users/{id} [delete] // <-- CORRECT: /users/{id} [delete] for DELETE
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 200 {object} map[string]interface{} "Deletion successful"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Not the user or an admin"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /users/{id} [delete]
 func DeleteUser(c *gin.Context) {
-	var user models.User
 	// Check if user exists
-	if err := db.DB.Where("id = ?", c.Param("id")).First(&user).Error; err != nil {
+	id, ok := paramID(c, "id")
+	if _, err := service.GetUser(c.Request.Context(), id, false); !ok || err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
 	// WARNING: In a real app, you must decide how to handle the dependent todos (e.g., delete them too, or set UserID to null)
 	// For this demo, GORM will typically handle the soft delete on the User record.
-	db.DB.Delete(&user)
+	_, err := service.DeleteUser(c.Request.Context(), actorID(c), id)
+	if errors.Is(err, service.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the user or an admin can delete this account"})
+		return
+	}
+	if err != nil {
+		serverError(c, "Failed to delete user", err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"data": true})
 }
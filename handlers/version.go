@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context key under which APIVersion stores the version of the current route
+const apiVersionKey = "api_version"
+
+// Shaper converts a response body into the JSON shape of one API version
+type Shaper func(body interface{}) interface{}
+
+// shapers holds the response shaping of every version that differs from the models.
+// Version 1 is the models' own JSON, so it has no entry. When a version changes a DTO
+// (e.g. models.Todo), register a Shaper for it that switches on the body type.
+var shapers = map[int]Shaper{}
+
+// APIVersion tags the routes of a version group, e.g. /v1, with their version number
+func APIVersion(version int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(apiVersionKey, version)
+		c.Next()
+	}
+}
+
+// Deprecated marks routes superseded by the same path under successorPrefix.
+// Responses carry Deprecation (RFC 9745), Sunset (RFC 8594) and a successor-version Link.
+func Deprecated(successorPrefix string, since, sunset time.Time) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", since.Unix())
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		c.Header("Sunset", sunsetDate)
+		c.Header("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, strings.TrimSuffix(successorPrefix, "/"), c.Request.URL.Path))
+		c.Next()
+	}
+}
+
+// requestVersion returns the API version of the current route; unversioned routes are version 1
+func requestVersion(c *gin.Context) int {
+	if version, ok := c.Get(apiVersionKey); ok {
+		return version.(int)
+	}
+	return 1
+}
+
+// respond writes body as JSON in the shape of the request's API version
+func respond(c *gin.Context, code int, body interface{}) {
+	if shape, ok := shapers[requestVersion(c)]; ok {
+		body = shape(body)
+	}
+	c.JSON(code, body)
+}
@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"gin-demo-api/auth"
+	"gin-demo-api/db"
+	"gin-demo-api/models"
+	"gin-demo-api/service"
+	"gin-demo-api/tracing"
+	"gin-demo-api/webhooks"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- C R E A T E (POST /webhooks) -------------------------------------------
+// @Summary Register a webhook
+// @Description Subscribes a URL to change events of your own todos and user (e.g. todo.created, todo.completed, user.updated, or * for all). user.created and user.deleted are sent to admins' webhooks, for every account. The URL must not point to a private, loopback or link-local address. Deliveries are signed with the secret (generated when omitted): X-Webhook-Signature is sha256=HMAC-SHA256(secret, X-Webhook-Timestamp + "." + body). The secret is only returned here, so store it now.
+// @tags Webhooks
+// @Accept  json
+// @Produce  json
+// @Param webhook body models.Webhook true "Webhook data (url and events are required)"
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 201 {object} models.Webhook
+// @Failure 400 {object} map[string]interface{} "Invalid input format, URL or event type"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /webhooks [post]
+func CreateWebhook(c *gin.Context) {
+	var input models.Webhook
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validWebhook(c, input) {
+		return
+	}
+
+	userID, _ := auth.UserID(c)
+	input.UserID = userID
+	if input.Secret == "" {
+		input.Secret = "whsec_" + randomHex(24)
+	}
+	if input.Active == nil {
+		active := true
+		input.Active = &active
+	}
+
+	hook, err := service.CreateWebhook(c.Request.Context(), actorID(c), input)
+	if err != nil {
+		serverError(c, "Failed to create webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, hook)
+}
+
+// --- R E A D A L L (GET /webhooks) ------------------------------------------
+// @Summary Get your webhooks
+// @Description Retrieves the webhooks owned by the authenticated user, without their secrets.
+// @tags Webhooks
+// @Produce  json
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 200 {array} models.Webhook
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /webhooks [get]
+func FindWebhooks(c *gin.Context) {
+	userID, _ := auth.UserID(c)
+
+	var hooks []models.Webhook
+	if err := db.DB.WithContext(c.Request.Context()).Where("user_id = ?", userID).Find(&hooks).Error; err != nil {
+		serverError(c, "Failed to load webhooks", err)
+		return
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+
+	c.JSON(http.StatusOK, hooks)
+}
+
+// --- R E A D O N E (GET /webhooks/:id) --------------------------------------
+// @Summary Get webhook by ID
+// @Description Retrieves one of the authenticated user's webhooks, without its secret.
+// @tags Webhooks
+// @Produce  json
+// @Param id path int true "Webhook ID"
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 200 {object} models.Webhook
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 404 {object} map[string]interface{} "Webhook not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /webhooks/{id} [get]
+func FindWebhook(c *gin.Context) {
+	hook, ok := findOwnWebhook(c)
+	if !ok {
+		return
+	}
+	hook.Secret = ""
+
+	c.JSON(http.StatusOK, hook)
+}
+
+// --- U P D A T E (PATCH /webhooks/:id) --------------------------------------
+// @Summary Update a webhook
+// @Description Updates the URL, events, secret and/or active flag of a webhook. The secret is not returned.
+// @tags Webhooks
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Webhook ID"
+// @Param webhook body models.WebhookChanges true "Webhook data (only provided fields are updated)"
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} map[string]interface{} "Invalid input format, URL or event type"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 404 {object} map[string]interface{} "Webhook not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /webhooks/{id} [patch]
+func UpdateWebhook(c *gin.Context) {
+	hook, ok := findOwnWebhook(c)
+	if !ok {
+		return
+	}
+
+	var changes models.WebhookChanges
+	if err := c.ShouldBindJSON(&changes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Start from the stored webhook so omitted fields keep their values
+	input := hook
+	if changes.URL != nil {
+		input.URL = *changes.URL
+	}
+	if changes.Events != nil {
+		input.Events = changes.Events
+	}
+	if changes.Secret != nil {
+		input.Secret = *changes.Secret
+	}
+	if changes.Active != nil {
+		input.Active = changes.Active
+	}
+	if !validWebhook(c, input) {
+		return
+	}
+
+	hook, err := service.UpdateWebhook(c.Request.Context(), actorID(c), hook, input)
+	if err != nil {
+		serverError(c, "Failed to update webhook", err)
+		return
+	}
+	hook.Secret = ""
+
+	c.JSON(http.StatusOK, hook)
+}
+
+// --- D E L E T E (DELETE /webhooks/:id) -------------------------------------
+// @Summary Delete a webhook
+// @Description Soft-deletes a webhook; queued deliveries to it are abandoned.
+// @tags Webhooks
+// @Produce  json
+// @Param id path int true "Webhook ID"
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 200 {object} map[string]interface{} "Deletion successful"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 404 {object} map[string]interface{} "Webhook not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /webhooks/{id} [delete]
+func DeleteWebhook(c *gin.Context) {
+	hook, ok := findOwnWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := service.DeleteWebhook(c.Request.Context(), actorID(c), hook); err != nil {
+		serverError(c, "Failed to delete webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": true})
+}
+
+// --- D E L I V E R I E S (GET /webhooks/:id/deliveries) ---------------------
+// @Summary Get the delivery log of a webhook
+// @Description Retrieves deliveries to a webhook, newest first, with attempt counts and the last response.
+// @tags Webhooks
+// @Produce  json
+// @Param id path int true "Webhook ID"
+// @Param status query string false "Only deliveries with this status" Enums(pending, succeeded, failed)
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 200 {array} models.WebhookDelivery
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 404 {object} map[string]interface{} "Webhook not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /webhooks/{id}/deliveries [get]
+func FindWebhookDeliveries(c *gin.Context) {
+	hook, ok := findOwnWebhook(c)
+	if !ok {
+		return
+	}
+
+	query := db.DB.WithContext(c.Request.Context()).Where("webhook_id = ?", hook.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("id DESC").Limit(100).Find(&deliveries).Error; err != nil {
+		serverError(c, "Failed to load deliveries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// --- R E D E L I V E R (POST /webhooks/:id/deliveries/:delivery_id/redeliver)
+// @Summary Redeliver an event
+// @Description Queues a fresh delivery of the same payload, regardless of the original outcome.
+// @tags Webhooks
+// @Produce  json
+// @Param id path int true "Webhook ID"
+// @Param delivery_id path int true "Delivery ID"
+// @Security UserID
+// @Security BearerAuth
+// @Security APIKey
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 404 {object} map[string]interface{} "Delivery not found"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func RedeliverWebhook(c *gin.Context) {
+	hook, ok := findOwnWebhook(c)
+	if !ok {
+		return
+	}
+
+	var original models.WebhookDelivery
+	if err := db.DB.WithContext(c.Request.Context()).Where("id = ? AND webhook_id = ?", c.Param("delivery_id"), hook.ID).First(&original).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+
+	delivery := models.WebhookDelivery{
+		WebhookID:     hook.ID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        webhooks.StatusPending,
+		NextAttemptAt: time.Now(),
+		TraceParent:   tracing.TraceParent(c.Request.Context()),
+	}
+	if err := db.DB.WithContext(c.Request.Context()).Create(&delivery).Error; err != nil {
+		serverError(c, "Failed to queue delivery", err)
+		return
+	}
+	webhooks.Notify()
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// findOwnWebhook loads the webhook from the URL if the caller owns it
+func findOwnWebhook(c *gin.Context) (models.Webhook, bool) {
+	userID, _ := auth.UserID(c)
+
+	var hook models.Webhook
+	if err := db.DB.WithContext(c.Request.Context()).Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&hook).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return hook, false
+	}
+	return hook, true
+}
+
+// validWebhook checks the URL and event types, responding 400 when invalid
+func validWebhook(c *gin.Context, hook models.Webhook) bool {
+	if err := webhooks.ValidateURL(c.Request.Context(), hook.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if len(hook.Events) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one event type is required"})
+		return false
+	}
+	for _, eventType := range hook.Events {
+		if !webhooks.ValidEventType(eventType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event type " + eventType})
+			return false
+		}
+	}
+	return true
+}
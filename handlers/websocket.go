@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"gin-demo-api/auth"
+	"gin-demo-api/events"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second // Time allowed to write a message
+	wsPongTimeout  = 60 * time.Second // Time allowed between pongs from the client
+	wsPingInterval = 25 * time.Second // Must be shorter than wsPongTimeout
+	wsBuffer       = 64               // Events queued per client before it counts as too slow
+	wsProtocol     = "todos"          // Subprotocol to offer next to a bearer token
+)
+
+// errForeignTodos refuses a filter on other users' todos to anyone but an admin
+const errForeignTodos = "Only admins can stream other users' todos"
+
+var upgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 4096, Subprotocols: []string{wsProtocol}}
+
+// todoFilter restricts the todo events sent to one client. Without user IDs, admins get
+// every todo and everyone else their own.
+type todoFilter struct {
+	mu      sync.RWMutex
+	userID  uint // The client's user
+	admin   bool
+	userIDs map[uint]bool
+}
+
+// subscribeMessage is sent by the client to replace its filter
+type subscribeMessage struct {
+	Type    string `json:"type"` // "subscribe"
+	UserIDs []uint `json:"user_ids"`
+}
+
+// errorMessage is sent to the client when a subscribe message is refused
+type errorMessage struct {
+	Type  string `json:"type"` // "error"
+	Error string `json:"error"`
+}
+
+// --- T I C K E T (POST /ws/tickets) -----------------------------------------
+// @Summary Get a WebSocket ticket
+// @Description Returns a single-use ticket that authenticates one WebSocket handshake as the current session within 30 seconds, as ?ticket=... on /ws/todos or /graphql.
+// @Description For browsers, which cannot send an Authorization header with a handshake. Only sessions can get tickets.
+// @tags Todos
+// @Produce  json
+// @Security BearerAuth
+// @Success 201 {object} map[string]interface{} "ticket, and expires_in seconds"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Not a session"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /ws/tickets [post]
+func CreateWebSocketTicket(c *gin.Context) {
+	userID, _ := auth.UserID(c)
+	sessionID, _ := auth.SessionID(c)
+	c.JSON(http.StatusCreated, gin.H{"ticket": auth.NewWebSocketTicket(userID, sessionID), "expires_in": int(auth.WebSocketTicketTTL.Seconds())})
+}
+
+// --- S T R E A M (GET /ws/todos) --------------------------------------------
+// @Summary Stream todo changes over WebSocket
+// @Description Upgrades to a WebSocket that receives todo.created, todo.updated, todo.completed and todo.deleted events as JSON: of the authenticated user's todos, or of every todo for admins.
+// @Description Filter by owner with ?user_id=1,2 or by sending {"type":"subscribe","user_ids":[1,2]}; only admins can name other users. A refused subscribe message is answered with {"type":"error"} and keeps the filter.
+// @Description Browsers can authenticate by offering the subprotocols "todos" and "bearer.<token>", or with a ticket from POST /ws/tickets.
+// @Description The server pings every 25s and closes clients that stop answering or fall too far behind (close code 1013).
+// @tags Todos
+// @Security BearerAuth
+// @Security APIKey
+// @Param user_id query string false "Comma-separated owner user IDs"
+// @Param ticket query string false "Ticket from POST /ws/tickets"
+// @Success 101 {object} events.Event "Switching Protocols; messages are events"
+// @Failure 400 {object} map[string]interface{} "Invalid filter"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Filter names other users"
+// @Failure 429 {object} map[string]interface{} "Rate limit exceeded"
+// @Router /ws/todos [get]
+func StreamTodos(c *gin.Context) {
+	userID, _ := auth.UserID(c)
+	filter := &todoFilter{userID: userID, admin: auth.IsAdmin(c)}
+	if value := c.Query("user_id"); value != "" {
+		ids, err := parseIDList(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
+			return
+		}
+		if !filter.set(ids) {
+			c.JSON(http.StatusForbidden, gin.H{"error": errForeignTodos})
+			return
+		}
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already written an error response
+		return
+	}
+	defer conn.Close()
+
+	sub := events.Default.Subscribe(wsBuffer)
+	defer sub.Close()
+
+	// Reader: handles pongs and filter updates; ends the subscription when the client goes away
+	replies := make(chan errorMessage, 1)
+	conn.SetReadLimit(4096)
+	conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+	go func() {
+		defer sub.Close()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			// Anything other than a valid subscribe message is ignored
+			var message subscribeMessage
+			if json.Unmarshal(data, &message) != nil || message.Type != "subscribe" || filter.set(message.UserIDs) {
+				continue
+			}
+			select {
+			case replies <- errorMessage{Type: "error", Error: errForeignTodos}:
+			default: // One refusal is waiting already
+			}
+		}
+	}()
+
+	// Writer: the only goroutine writing to the connection
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	for {
+		var message any
+		select {
+		case event := <-sub.Events():
+			if event.EntityType != "todo" || !filter.matches(event.UserID) {
+				continue
+			}
+			message = event
+		case reply := <-replies:
+			message = reply
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+			continue
+		case <-sub.Done():
+			if sub.Overflowed() {
+				message := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "slow consumer")
+				conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(wsWriteTimeout))
+			}
+			return
+		}
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		if err := conn.WriteJSON(message); err != nil {
+			return
+		}
+	}
+}
+
+// set replaces the filtered user IDs, unless they include users the client may not see
+func (f *todoFilter) set(userIDs []uint) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	filtered := map[uint]bool{}
+	for _, id := range userIDs {
+		if id != f.userID && !f.admin {
+			return false
+		}
+		filtered[id] = true
+	}
+	f.userIDs = filtered
+	return true
+}
+
+// matches reports whether an event for a todo owned by userID passes the filter
+func (f *todoFilter) matches(userID uint) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if len(f.userIDs) == 0 {
+		return f.admin || userID == f.userID
+	}
+	return f.userIDs[userID]
+}
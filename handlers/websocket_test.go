@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-demo-api/auth"
+	"gin-demo-api/events"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// startStream serves the WebSocket routes and returns the URL of /ws/todos
+func startStream(t *testing.T) (wsURL, httpURL string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(auth.Authenticate())
+	router.GET("/ws/todos", auth.RequireUser(), StreamTodos)
+	router.POST("/ws/tickets", auth.RequireSession(), CreateWebSocketTicket)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/todos", server.URL
+}
+
+// dialStream opens a stream with a bearer token
+func dialStream(t *testing.T, url, token string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer " + token}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// nextEvent publishes until the stream passes on an event, since the handler only
+// subscribes after the upgrade, and returns that event
+func nextEvent(t *testing.T, conn *websocket.Conn, publish ...events.Event) events.Event {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	conn.SetReadDeadline(deadline)
+	received := make(chan events.Event)
+	go func() {
+		defer close(received)
+		var event events.Event
+		if conn.ReadJSON(&event) == nil {
+			received <- event
+		}
+	}()
+	for time.Now().Before(deadline) {
+		for _, event := range publish {
+			events.Default.Publish(event)
+		}
+		select {
+		case event, ok := <-received:
+			if !ok {
+				t.Fatal("connection closed before an event arrived")
+			}
+			return event
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	t.Fatal("no event arrived")
+	return events.Event{}
+}
+
+// todoEvent is a todo.created event for a todo owned by userID
+func todoEvent(userID uint) events.Event {
+	return events.Event{Type: "todo.created", EntityType: "todo", EntityID: userID, UserID: userID}
+}
+
+func TestStreamTodosOnlySendsOwnTodos(t *testing.T) {
+	testdb.Open(t)
+	alice, token := testdb.Login(t, "alice")
+	bob, _ := testdb.Login(t, "bob")
+	url, _ := startStream(t)
+
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("anonymous handshake: got %v, want 401", resp)
+	}
+
+	conn := dialStream(t, url, token)
+	event := nextEvent(t, conn,
+		todoEvent(bob.ID),
+		events.Event{Type: "user.updated", EntityType: "user", EntityID: alice.ID, UserID: alice.ID},
+		todoEvent(alice.ID))
+	if event.EntityType != "todo" || event.UserID != alice.ID {
+		t.Fatalf("got %+v, want only alice's todo events", event)
+	}
+}
+
+func TestStreamTodosFilters(t *testing.T) {
+	testdb.Open(t)
+	alice, aliceToken := testdb.Login(t, "alice")
+	bob, _ := testdb.Login(t, "bob")
+	now := time.Now()
+	admin := testdb.CreateUser(t, models.User{Username: "root", Role: models.RoleAdmin, TwoFactorEnabledAt: &now})
+	adminToken, _ := testdb.Session(t, admin.ID, true)
+	url, _ := startStream(t)
+
+	// Users can only name themselves
+	_, resp, err := websocket.DefaultDialer.Dial(url+"?user_id="+fmt.Sprint(bob.ID), http.Header{"Authorization": {"Bearer " + aliceToken}})
+	if err == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("alice filtering on bob: got %v, want 403", resp)
+	}
+	conn := dialStream(t, url+"?user_id="+fmt.Sprint(alice.ID), aliceToken)
+	conn.WriteJSON(subscribeMessage{Type: "subscribe", UserIDs: []uint{bob.ID}})
+	var reply errorMessage
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.ReadJSON(&reply); err != nil || reply.Type != "error" {
+		t.Fatalf("alice subscribing to bob: got %+v, %v; want an error message", reply, err)
+	}
+	if event := nextEvent(t, conn, todoEvent(bob.ID), todoEvent(alice.ID)); event.UserID != alice.ID {
+		t.Errorf("after the refused subscribe: got %+v, want alice's todos still", event)
+	}
+
+	// Admins see every todo, and can narrow them down to anyone's
+	conn = dialStream(t, url, adminToken)
+	if event := nextEvent(t, conn, todoEvent(bob.ID)); event.UserID != bob.ID {
+		t.Errorf("admin without a filter: got %+v, want bob's todo", event)
+	}
+	conn = dialStream(t, url+"?user_id="+fmt.Sprint(bob.ID), adminToken)
+	if event := nextEvent(t, conn, todoEvent(alice.ID), todoEvent(bob.ID)); event.UserID != bob.ID {
+		t.Errorf("admin filtering on bob: got %+v, want bob's todo", event)
+	}
+	conn.WriteJSON(subscribeMessage{Type: "subscribe", UserIDs: []uint{alice.ID}})
+	for i := 0; ; i++ {
+		// Events for bob may still be on their way
+		if event := nextEvent(t, conn, todoEvent(bob.ID), todoEvent(alice.ID)); event.UserID == alice.ID {
+			break
+		} else if i == 100 {
+			t.Fatalf("admin subscribing to alice: still got %+v", event)
+		}
+	}
+}
+
+func TestStreamTodosBrowserAuthentication(t *testing.T) {
+	testdb.Open(t)
+	alice, token := testdb.Login(t, "alice")
+	url, httpURL := startStream(t)
+
+	// A bearer token offered as a subprotocol, next to the one the server picks
+	dialer := websocket.Dialer{Subprotocols: []string{"todos", auth.BearerProtocolPrefix + token}}
+	conn, resp, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("subprotocol handshake: %v", err)
+	}
+	if protocol := resp.Header.Get("Sec-WebSocket-Protocol"); protocol != "todos" {
+		t.Errorf("selected subprotocol = %q, want todos", protocol)
+	}
+	if event := nextEvent(t, conn, todoEvent(alice.ID)); event.UserID != alice.ID {
+		t.Errorf("got %+v, want alice's todo", event)
+	}
+	conn.Close()
+
+	// A ticket works for one handshake
+	request, _ := http.NewRequest(http.MethodPost, httpURL+"/ws/tickets", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	resp, err = http.DefaultClient.Do(request)
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("ticket: got %v, %v; want 201", resp, err)
+	}
+	var ticket struct{ Ticket string }
+	json.NewDecoder(resp.Body).Decode(&ticket)
+	resp.Body.Close()
+	conn, _, err = websocket.DefaultDialer.Dial(url+"?ticket="+ticket.Ticket, nil)
+	if err != nil {
+		t.Fatalf("ticket handshake: %v", err)
+	}
+	if event := nextEvent(t, conn, todoEvent(alice.ID)); event.UserID != alice.ID {
+		t.Errorf("got %+v, want alice's todo", event)
+	}
+	conn.Close()
+	if _, resp, err := websocket.DefaultDialer.Dial(url+"?ticket="+ticket.Ticket, nil); err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("reused ticket: got %v, want 401", resp)
+	}
+}
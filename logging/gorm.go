@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger routes GORM's log through slog. Failed statements are logged at error level,
+// statements slower than the threshold at warn and all others at debug. Statements are
+// logged with their ? placeholders, never the bound values, which include passwords,
+// token hashes and TOTP secrets.
+type GormLogger struct {
+	SlowThreshold time.Duration // 0 disables slow-query warnings
+	level         gormlogger.LogLevel
+}
+
+// NewGormLogger returns a GORM logger that warns about statements slower than slowThreshold
+func NewGormLogger(slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{SlowThreshold: slowThreshold, level: gormlogger.Info}
+}
+
+// LogMode implements gorm/logger.Interface; slog's level still applies on top
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copy := *l
+	copy.level = level
+	return &copy
+}
+
+func (l *GormLogger) Info(ctx context.Context, message string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		slog.InfoContext(ctx, fmt.Sprintf(message, args...), "component", "gorm")
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, message string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		slog.WarnContext(ctx, fmt.Sprintf(message, args...), "component", "gorm")
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, message string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		slog.ErrorContext(ctx, fmt.Sprintf(message, args...), "component", "gorm")
+	}
+}
+
+// ParamsFilter implements gorm/logger.ParamsFilter, keeping bound values out of the SQL passed to Trace
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+// Trace logs one executed statement
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		sql, rows := fc()
+		slog.ErrorContext(ctx, "query failed", "component", "gorm", "err", err, "sql", sql, "rows", rows, milliseconds("duration_ms", elapsed))
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		slog.WarnContext(ctx, "slow query", "component", "gorm", "sql", sql, "rows", rows, milliseconds("duration_ms", elapsed), milliseconds("threshold_ms", l.SlowThreshold))
+	case l.level >= gormlogger.Info && slog.Default().Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		slog.DebugContext(ctx, "query", "component", "gorm", "sql", sql, "rows", rows, milliseconds("duration_ms", elapsed))
+	}
+}
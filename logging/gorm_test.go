@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gin-demo-api/db"
+	"gin-demo-api/models"
+)
+
+func TestGormLoggerOmitsBoundValues(t *testing.T) {
+	var out bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	db.Logger = NewGormLogger(0)
+	if err := db.Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.DB.AutoMigrate(&models.User{}); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	user := models.User{Username: "alice", Email: "alice@example.com", PasswordHash: "$2a$10$secrethash", TOTPSecret: "TOTPSECRET"}
+	if err := db.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	// A failing statement is logged at error level through the same path
+	db.DB.Create(&models.User{ID: user.ID, Username: "alice", Email: "alice@example.com", PasswordHash: "$2a$10$secrethash"})
+
+	log := out.String()
+	if !strings.Contains(log, "INSERT INTO") || !strings.Contains(log, "query failed") {
+		t.Fatalf("statements were not logged:\n%s", log)
+	}
+	for _, secret := range []string{"secrethash", "TOTPSECRET", "alice@example.com"} {
+		if strings.Contains(log, secret) {
+			t.Errorf("log contains %q:\n%s", secret, log)
+		}
+	}
+}
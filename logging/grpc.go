@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDMetadata is RequestIDHeader as gRPC metadata, whose keys are lower case
+var requestIDMetadata = strings.ToLower(RequestIDHeader)
+
+// UnaryServerInterceptor logs one line per gRPC call, like AccessLog, and gives calls a
+// request ID like RequestIDMiddleware: the caller's x-request-id or a generated one,
+// echoed in the response header. Calls failing on the server's side log at error level,
+// other failures at warn. A panic becomes an Internal error, logged with its stack, like
+// Recovery.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		id := ""
+		if values := md.Get(requestIDMetadata); len(values) > 0 {
+			id = values[0]
+		}
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, id))
+		ctx = WithRequestID(ctx, id)
+
+		start := time.Now()
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				slog.ErrorContext(ctx, "panic while handling call", "panic", recovered, "stack", string(debug.Stack()))
+				err = status.Error(codes.Internal, "Internal server error")
+			}
+
+			code := status.Code(err)
+			level := slog.LevelInfo
+			switch code {
+			case codes.OK:
+			case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+				level = slog.LevelError
+			default:
+				level = slog.LevelWarn
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", info.FullMethod),
+				slog.String("code", code.String()),
+				milliseconds("duration_ms", time.Since(start)),
+			}
+			if p, ok := peer.FromContext(ctx); ok {
+				attrs = append(attrs, slog.String("peer", p.Addr.String()))
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+			}
+			slog.LogAttrs(ctx, level, "call", attrs...)
+		}()
+		return handler(ctx, req)
+	}
+}
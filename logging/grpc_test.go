@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	var out bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(contextHandler{slog.NewJSONHandler(&out, nil)}))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	intercept := UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/todoapi.v1.TodoService/GetTodo"}
+	call := func(requestID string, handler grpc.UnaryHandler) (string, error) {
+		out.Reset()
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", requestID))
+		seen := ""
+		_, err := intercept(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			seen = RequestID(ctx)
+			return handler(ctx, req)
+		})
+		return seen, err
+	}
+
+	tests := []struct {
+		name, requestID string
+		handler         grpc.UnaryHandler
+		want            codes.Code
+		log             []string
+	}{
+		{
+			name:      "success",
+			requestID: "call-1",
+			handler:   func(context.Context, interface{}) (interface{}, error) { return "ok", nil },
+			want:      codes.OK,
+			log:       []string{`"level":"INFO","msg":"call"`, `"method":"/todoapi.v1.TodoService/GetTodo","code":"OK"`},
+		},
+		{
+			name:      "client error",
+			requestID: "call-2",
+			handler: func(context.Context, interface{}) (interface{}, error) {
+				return nil, status.Error(codes.NotFound, "Todo not found")
+			},
+			want: codes.NotFound,
+			log:  []string{`"level":"WARN"`, `"code":"NotFound"`, `"error":"Todo not found"`},
+		},
+		{
+			name:      "panic",
+			requestID: "not a valid ID",
+			handler:   func(context.Context, interface{}) (interface{}, error) { panic("boom") },
+			want:      codes.Internal,
+			log:       []string{`"msg":"panic while handling call","panic":"boom"`, `"level":"ERROR","msg":"call"`, `"code":"Internal"`},
+		},
+	}
+	for _, tt := range tests {
+		seen, err := call(tt.requestID, tt.handler)
+		if code := status.Code(err); code != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, code, tt.want)
+		}
+		if valid := validRequestID.MatchString(tt.requestID); valid && seen != tt.requestID {
+			t.Errorf("%s: handler saw request ID %q, want %q", tt.name, seen, tt.requestID)
+		} else if !valid && (seen == "" || seen == tt.requestID) {
+			t.Errorf("%s: handler saw request ID %q, want a generated one", tt.name, seen)
+		}
+		if !strings.Contains(out.String(), `"request_id":"`+seen+`"`) {
+			t.Errorf("%s: log lines do not carry request ID %q:\n%s", tt.name, seen, out.String())
+		}
+		for _, want := range tt.log {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%s: log does not contain %s:\n%s", tt.name, want, out.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package mail sends the server's emails through a pluggable Mailer: SMTP in
+// production, and files or the log during development.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"time"
+
+	"gin-demo-api/config"
+)
+
+// Message is one email, ready to send
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string // Plain-text body
+	HTML    string // Optional HTML alternative
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var (
+	// Outbox is the mailer used for all outgoing email
+	Outbox Mailer = LogMailer{}
+	// From is the default sender address
+	From = "Gin Demo API <no-reply@localhost>"
+)
+
+// ConnectMailer initializes the mailer selected by the configuration
+func ConnectMailer(cfg config.MailConfig) error {
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return fmt.Errorf("invalid MAIL_FROM %q: %w", cfg.From, err)
+	}
+	From = cfg.From
+
+	switch cfg.Driver {
+	case "log":
+		Outbox = LogMailer{}
+	case "file":
+		mailer, err := NewFileMailer(cfg.Dir)
+		if err != nil {
+			return err
+		}
+		Outbox = mailer
+	case "smtp":
+		Outbox = &SMTPMailer{Addr: cfg.SMTPAddr, Username: cfg.SMTPUsername, Password: cfg.SMTPPassword}
+	default:
+		return fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+	return nil
+}
+
+// Send renders the named template with data and sends it to the given address
+func Send(ctx context.Context, to, template string, data interface{}) error {
+	msg, err := Render(template, data)
+	if err != nil {
+		return err
+	}
+	msg.From = From
+	msg.To = to
+	return Outbox.Send(ctx, msg)
+}
+
+// compose encodes a message in RFC 5322 format, with an HTML alternative when there is one
+func compose(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+
+	id := make([]byte, 16)
+	rand.Read(id)
+	header("From", msg.From)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+hex.EncodeToString(id)+"@gin-demo-api>")
+	header("MIME-Version", "1.0")
+
+	if msg.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, msg.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeQuotedPrintable writes body to w in quoted-printable encoding
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
@@ -0,0 +1,64 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Each template file defines "subject", "text" and optionally "html"
+//
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+// templateSet holds one message template, parsed for plain text and for HTML escaping
+type templateSet struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var templates = map[string]templateSet{}
+
+func init() {
+	names, err := templateFiles.ReadDir("templates")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range names {
+		path := "templates/" + entry.Name()
+		templates[strings.TrimSuffix(entry.Name(), ".tmpl")] = templateSet{
+			text: texttemplate.Must(texttemplate.ParseFS(templateFiles, path)),
+			html: htmltemplate.Must(htmltemplate.ParseFS(templateFiles, path)),
+		}
+	}
+}
+
+// Render fills in the named template (a file in templates/, without .tmpl) with data
+func Render(name string, data interface{}) (Message, error) {
+	set, ok := templates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown mail template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := set.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := set.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return Message{}, err
+	}
+	if set.html.Lookup("html") != nil {
+		if err := set.html.ExecuteTemplate(&html, "html", data); err != nil {
+			return Message{}, err
+		}
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    strings.TrimSpace(html.String()),
+	}, nil
+}
@@ -1,17 +1,14 @@
 package main
 
 import (
-	"gin-demo-api/db"
-	"gin-demo-api/handlers"
-
-	"github.com/gin-gonic/gin"
+	"fmt"
+	"os"
 
-	// imports for Swagger
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
+	"gin-demo-api/config"
+	"gin-demo-api/db"
+	"gin-demo-api/logging"
 
-	// 🚨 Import the docs package (must be manually created by 'swag init')
-	_ "gin-demo-api/docs"
+	"github.com/spf13/cobra"
 )
 
 // 🚨 Add top-level annotations for the API metadata
@@ -19,31 +16,49 @@ import (
 // @version 1.0
 // @description This is a sample server for a User/Todo management API.
 // @host localhost:8080
-// @BasePath /
+// @BasePath /v1
+// @schemes http
+
+// @securityDefinitions.apikey UserID
+// @in header
+// @name X-User-ID
+// @description Development only: ID of the user making the request, accepted when DEV_TRUST_USER_ID_HEADER is on
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Session token or API key as "Bearer gds_..." or "Bearer gda_..."
+
+// @securityDefinitions.apikey APIKey
+// @in header
+// @name X-API-Key
+// @description API key, as an alternative to the Authorization header
 
 func main() {
-	// 1. Initialize DB connection and run migrations
-	db.ConnectDatabase()
-
-	// 2. Initialize the Gin router
-	router := gin.Default()
-
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	// --- USER ROUTES ---
-	router.POST("/users", handlers.CreateUser)       // C: Create User
-	router.GET("/users", handlers.FindUsers)         // R: Read All Users (with Todos)
-	router.GET("/users/:id", handlers.FindUser)      // R: Read One User (with Todos)
-	router.PATCH("/users/:id", handlers.UpdateUser)  // U: Update User
-	router.DELETE("/users/:id", handlers.DeleteUser) // D: Delete User
-
-	// 3. Define RESTful API routes (CRUD)
-	router.POST("/todos", handlers.CreateTodo)       // C: Create
-	router.GET("/todos", handlers.FindTodos)         // R: Read All
-	router.GET("/todos/:id", handlers.FindTodo)      // R: Read One
-	router.PATCH("/todos/:id", handlers.UpdateTodo)  // U: Update
-	router.DELETE("/todos/:id", handlers.DeleteTodo) // D: Delete
-
-	// 4. Start the server
-	router.Run("localhost:8080")
+	root := &cobra.Command{
+		Use:           "gin-demo-api",
+		Short:         "User/Todo management API server and maintenance tasks",
+		Long:          "Runs the API server (the default when no command is given) or one of the maintenance tasks below.\nAll commands share the environment-based configuration, e.g. DB_PATH.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve()
+		},
+	}
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		// Every command logs through slog, including GORM's query log
+		cfg := config.Load().Log
+		if err := logging.Setup(cfg); err != nil {
+			return err
+		}
+		db.Logger = logging.NewGormLogger(cfg.SlowQuery)
+		return nil
+	}
+	root.AddCommand(serveCommand(), migrateCommand(), seedCommand(), exportCommand(), importCommand(), createAdminCommand())
+
+	if err := root.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
 }
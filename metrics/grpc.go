@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	grpcCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_handled_total",
+		Help: "gRPC calls handled, by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "Time to handle gRPC calls, by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+
+	grpcInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "grpc_server_in_flight",
+		Help: "gRPC calls currently being handled, by method.",
+	}, []string{"method"})
+)
+
+// UnaryServerInterceptor records call metrics under the full method name (e.g.
+// /todoapi.v1.TodoService/ListTodos), like Middleware does for HTTP routes
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		method := info.FullMethod
+		inFlight := grpcInFlight.WithLabelValues(method)
+		inFlight.Inc()
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		inFlight.Dec()
+		grpcDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		grpcCalls.WithLabelValues(method, status.Code(err).String()).Inc()
+		return resp, err
+	}
+}
@@ -0,0 +1,88 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gin-demo-api/config"
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/metrics"
+	"gin-demo-api/models"
+	"gin-demo-api/router"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	testdb.Open(t)
+	if err := metrics.InstrumentDB(db.DB); err != nil {
+		t.Fatal(err)
+	}
+	alice := testdb.CreateUser(t, models.User{Username: "alice"})
+	bob := testdb.CreateUser(t, models.User{Username: "bob"})
+	db.DB.Delete(&bob)
+	for _, todo := range []models.Todo{
+		{Item: "Open", UserID: alice.ID},
+		{Item: "Done", UserID: alice.ID, Completed: true},
+		{Item: "Deleted", UserID: alice.ID},
+	} {
+		if err := db.DB.Create(&todo).Error; err != nil {
+			t.Fatal(err)
+		}
+		if todo.Item == "Deleted" {
+			db.DB.Delete(&todo)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	cfg := config.Load()
+	cfg.RateLimit.Default = "off"
+	handler, err := router.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	get := func(path string) string {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	get("/v1/users")
+	get("/v1/todos/999")
+	get("/v1/todos/998")
+	get("/no-such-route")
+
+	body := get("/metrics")
+	for _, want := range []string{
+		// Requests, under their route template
+		`http_requests_total{method="GET",route="/v1/users",status="200"} 1`,
+		`http_requests_total{method="GET",route="/v1/todos/:id",status="404"} 2`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/v1/todos/:id"} 2`,
+		`http_requests_in_flight{method="GET",route="/metrics"} 1`,
+		// Database statements and the connection pool
+		`gorm_query_duration_seconds_count{operation="create",table="todos"} 3`,
+		`gorm_query_duration_seconds_count{operation="query",table="users"}`,
+		`go_sql_open_connections{db_name="sqlite"}`,
+		// Data, without deleted rows
+		`todos{state="open"} 1`,
+		`todos{state="completed"} 1`,
+		"\nusers 1\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics does not contain %s", want)
+		}
+	}
+	if strings.Contains(body, "/v1/todos/999") {
+		t.Error("/metrics has a series for a raw path")
+	}
+}
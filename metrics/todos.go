@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"log/slog"
+
+	"gin-demo-api/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+var (
+	todosDesc = prometheus.NewDesc("todos", "Todos that are not deleted, by state (open or completed).", []string{"state"}, nil)
+	usersDesc = prometheus.NewDesc("users", "Users that are not deleted.", nil, nil)
+)
+
+// todoCollector counts todos and users when scraped, so the gauges never drift from the database
+type todoCollector struct {
+	db *gorm.DB
+}
+
+func (tc *todoCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- todosDesc
+	ch <- usersDesc
+}
+
+func (tc *todoCollector) Collect(ch chan<- prometheus.Metric) {
+	var counts []struct {
+		Completed bool
+		Count     int64
+	}
+	if err := tc.db.Model(&models.Todo{}).Select("completed, count(*) AS count").Group("completed").Scan(&counts).Error; err != nil {
+		slog.Error("metrics: counting todos", "err", err)
+		return
+	}
+	open, completed := 0.0, 0.0
+	for _, row := range counts {
+		if row.Completed {
+			completed = float64(row.Count)
+		} else {
+			open = float64(row.Count)
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(todosDesc, prometheus.GaugeValue, open, "open")
+	ch <- prometheus.MustNewConstMetric(todosDesc, prometheus.GaugeValue, completed, "completed")
+
+	var users int64
+	if err := tc.db.Model(&models.User{}).Count(&users).Error; err != nil {
+		slog.Error("metrics: counting users", "err", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(usersDesc, prometheus.GaugeValue, float64(users))
+}
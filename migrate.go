@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+
+	"gin-demo-api/config"
+	"gin-demo-api/db"
+
+	"github.com/spf13/cobra"
+)
+
+func migrateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "Create or update the database schema, then exit",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := openDatabase(); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Migrated %s\n", config.Load().DatabasePath)
+			return nil
+		},
+	}
+}
+
+// openDatabase connects to the configured database and migrates it
+func openDatabase() error {
+	path := config.Load().DatabasePath
+	if err := db.Open(path); err != nil {
+		return fmt.Errorf("opening %s: %w", path, err)
+	}
+	if err := db.Migrate(); err != nil {
+		return fmt.Errorf("migrating %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package models
+
+// PasswordResetRequest asks for a password reset token to be emailed
+type PasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email" example:"alice@example.com"`
+}
+
+// PasswordReset sets a new password with an emailed reset token
+type PasswordReset struct {
+	Token    string `json:"token" binding:"required" example:"eyJwIjoicmVzZXQtcGFzc3dvcmQifQ.c2lnbmF0dXJl"`
+	Password string `json:"password" binding:"required,min=8,max=72" minLength:"8" maxLength:"72" example:"correct horse battery"`
+}
+
+// PasswordLogin signs in with a username or email address and a password
+type PasswordLogin struct {
+	Login    string `json:"login" binding:"required" example:"alice@example.com"` // Username or email address
+	Password string `json:"password" binding:"required" example:"correct horse battery"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// API key scopes; a key may make any request one of its scopes allows
+const (
+	ScopeAll       = "all"        // Everything the owner can do
+	ScopeRead      = "read"       // Read-only requests to any route
+	ScopeTodos     = "todos"      // Todo routes, including comments, attachments, search and live updates
+	ScopeTodosRead = "todos:read" // Read-only requests to todo routes
+)
+
+// APIKeyScopes lists the valid scopes
+var APIKeyScopes = []string{ScopeAll, ScopeRead, ScopeTodos, ScopeTodosRead}
+
+// APIKey is a long-lived credential a user creates for scripts and integrations.
+// Only a hash of the key is stored; the key itself is shown once, on creation.
+type APIKey struct {
+	// GORM fields explicitly documented for Swagger
+	ID        uint           `json:"id" example:"1"`
+	CreatedAt time.Time      `json:"created_at" example:"2025-10-25T12:00:00Z"`
+	UpdatedAt time.Time      `json:"updated_at" example:"2025-10-25T12:00:00Z"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"` // Set when the key is revoked
+
+	// API key fields
+	UserID     uint       `json:"user_id" gorm:"index;not null" readonly:"true" example:"1"` // Owner, taken from the authenticated user
+	Name       string     `json:"name" gorm:"not null" binding:"required" example:"Nightly backup"`
+	Prefix     string     `json:"prefix" gorm:"not null" readonly:"true" example:"gda_3f9a1c2b"` // Start of the key, to recognise it
+	Hash       string     `json:"-" gorm:"uniqueIndex;not null"`                                 // SHA-256 of the key
+	Scopes     StringList `json:"scopes" gorm:"type:text;not null" swaggertype:"array,string" example:"todos:read"`
+	LastUsedAt *time.Time `json:"last_used_at" readonly:"true" example:"2025-10-25T12:30:00Z" extensions:"x-nullable"`
+
+	// Key is the full key, returned only by the create request
+	Key string `json:"key,omitempty" gorm:"-" readonly:"true" example:"gda_3f9a1c2b5e7d90a1c3e5f7b9d1f3a5c7e9b1d3f5a7c9e1b3"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Attachment describes a file uploaded to a todo; the bytes live in the blob store
+type Attachment struct {
+	// GORM fields explicitly documented for Swagger
+	ID        uint           `json:"id" example:"1"`
+	CreatedAt time.Time      `json:"created_at" example:"2025-10-25T12:00:00Z"`
+	UpdatedAt time.Time      `json:"updated_at" example:"2025-10-25T12:00:00Z"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Attachment fields
+	TodoID      uint   `json:"todo_id" gorm:"index;not null" example:"1"`
+	UserID      *uint  `json:"user_id" example:"1" extensions:"x-nullable"` // Uploader, when authenticated
+	Filename    string `json:"filename" gorm:"not null" example:"receipt.pdf"`
+	ContentType string `json:"content_type" gorm:"not null" example:"application/pdf"` // Detected from the file contents
+	Size        int64  `json:"size" example:"52431"`                                   // Bytes
+	SHA256      string `json:"sha256" gorm:"column:sha256;not null" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	StorageKey  string `json:"-" gorm:"not null"` // Key in the blob store
+}
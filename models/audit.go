@@ -0,0 +1,50 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// AuditEvent records a single create, update or delete performed through the API
+type AuditEvent struct {
+	ID        uint      `json:"id" example:"1"`
+	CreatedAt time.Time `json:"created_at" gorm:"index" example:"2025-10-25T12:00:00Z"`
+
+	// Audit fields
+	ActorID    *uint        `json:"actor_id" gorm:"index" example:"1" extensions:"x-nullable"` // Authenticated user, null for anonymous requests
+	Action     string       `json:"action" gorm:"not null" example:"update"`                   // create, update or delete
+	EntityType string       `json:"entity_type" gorm:"index:idx_audit_entity;not null" example:"todo"`
+	EntityID   uint         `json:"entity_id" gorm:"index:idx_audit_entity;not null" example:"1"`
+	Changes    AuditChanges `json:"changes" gorm:"type:text"` // Changed fields only
+}
+
+// FieldChange holds the before and after values of a single field
+type FieldChange struct {
+	Before interface{} `json:"before" extensions:"x-nullable"`
+	After  interface{} `json:"after" extensions:"x-nullable"`
+}
+
+// AuditChanges maps JSON field names to their changes; stored as a JSON column
+type AuditChanges map[string]FieldChange
+
+// Value implements driver.Valuer
+func (c AuditChanges) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	return string(b), err
+}
+
+// Scan implements sql.Scanner
+func (c *AuditChanges) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case []byte:
+		return json.Unmarshal(v, c)
+	}
+	return errors.New("unsupported type for AuditChanges")
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ChangeEvent is an entry in the change log behind GET /events. IDs only ever
+// increase, so clients can resume from the last ID they saw.
+type ChangeEvent struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement"`
+	CreatedAt time.Time `gorm:"index"`
+
+	// Event fields
+	Type       string `gorm:"not null"` // e.g. todo.updated
+	EntityType string `gorm:"not null"`
+	EntityID   uint   `gorm:"not null"`
+	UserID     uint   `gorm:"index"`
+	Data       string `gorm:"type:text"` // JSON snapshot of the entity
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Comment is a discussion message attached to a todo
+type Comment struct {
+	// GORM fields explicitly documented for Swagger
+	ID        uint           `json:"id" example:"1"`
+	CreatedAt time.Time      `json:"created_at" example:"2025-10-25T12:00:00Z"`
+	UpdatedAt time.Time      `json:"updated_at" example:"2025-10-25T12:00:00Z"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Comment fields
+	TodoID   uint       `json:"todo_id" gorm:"index;not null" readonly:"true" example:"1"` // Taken from the URL
+	UserID   uint       `json:"user_id" gorm:"not null" readonly:"true" example:"1"`       // Author, taken from the authenticated user
+	Body     string     `json:"body" gorm:"type:text;not null" binding:"required,max=5000" example:"@user_bob can you pick up the eggs?"`
+	EditedAt *time.Time `json:"edited_at" readonly:"true" example:"2025-10-25T12:05:00Z" extensions:"x-nullable"` // Set when the author edits the body
+
+	// Relationship: users mentioned with @username in the body
+	Mentions []Mention `json:"mentions" readonly:"true" extensions:"x-nullable"`
+}
+
+// Mention records a user @mentioned in a comment, pending notification
+type Mention struct {
+	ID         uint       `json:"-"`
+	CreatedAt  time.Time  `json:"-"`
+	CommentID  uint       `json:"-" gorm:"index;not null"`
+	UserID     uint       `json:"user_id" gorm:"index;not null" example:"2"`
+	Username   string     `json:"username" example:"user_bob"`
+	NotifiedAt *time.Time `json:"-"` // Nil until a notification has been delivered
+}
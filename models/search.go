@@ -0,0 +1,10 @@
+package models
+
+// TodoSearchResult is a todo matched by full-text search, with a highlighted snippet
+type TodoSearchResult struct {
+	Todo
+
+	// Search fields
+	Snippet string  `json:"snippet" example:"Buy <mark>groceries</mark> for the week"` // HTML-escaped, matches wrapped in <mark>
+	Rank    float64 `json:"rank" example:"-1.25"`                                      // Lower is a better match
+}
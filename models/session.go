@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session is a login. The client holds a bearer token; only its hash is stored.
+type Session struct {
+	ID        uint           `json:"id" example:"1"`
+	CreatedAt time.Time      `json:"created_at" example:"2025-10-25T12:00:00Z"`
+	UpdatedAt time.Time      `json:"updated_at" example:"2025-10-25T12:00:00Z"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"` // Set on logout
+
+	// Session fields
+	UserID    uint      `json:"user_id" gorm:"index;not null" example:"1"`
+	TokenHash string    `json:"-" gorm:"uniqueIndex;not null"`                 // SHA-256 of the token
+	Method    string    `json:"method" gorm:"not null" example:"oidc:company"` // How the user signed in
+	TwoFactor bool      `json:"two_factor" example:"true"`                     // Whether the login was confirmed with a second factor
+	ExpiresAt time.Time `json:"expires_at" example:"2025-11-24T12:00:00Z"`
+
+	// Device fields
+	UserAgent    string    `json:"user_agent" example:"Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"` // Of the login
+	Device       string    `json:"device" example:"Firefox on Linux"`                                                           // Described from the user agent
+	IP           string    `json:"ip" example:"203.0.113.7"`                                                                    // Of the last activity
+	LastActiveAt time.Time `json:"last_active_at" example:"2025-10-25T14:30:00Z"`                                               // Updated at most once a minute
+	Current      bool      `json:"current" gorm:"-" example:"true"`                                                             // Whether the request was made with this session
+}
+
+// ClientInfo describes where a login came from
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// Identity links a user to their account at an OpenID Connect provider
+type Identity struct {
+	ID        uint      `json:"id" example:"1"`
+	CreatedAt time.Time `json:"created_at" example:"2025-10-25T12:00:00Z"`
+
+	// Identity fields
+	UserID   uint   `json:"user_id" gorm:"index;not null" example:"1"`
+	Provider string `json:"provider" gorm:"uniqueIndex:idx_identity_subject;not null" example:"company"`
+	Subject  string `json:"subject" gorm:"uniqueIndex:idx_identity_subject;not null" example:"248289761001"` // The provider's stable user ID
+	Email    string `json:"email" example:"alice@example.com"`                                               // As last reported by the provider
+}
+
+// LoginResult is returned by a successful login. When the user has two-factor
+// authentication enabled, it holds a challenge to complete with a code instead of a token.
+type LoginResult struct {
+	Token             string    `json:"token,omitempty" example:"gds_5e7d90a1c3e5f7b9d1f3a5c7e9b1d3f5a7c9e1b35e7d90a1"` // Send as "Authorization: Bearer <token>"
+	TwoFactorRequired bool      `json:"two_factor_required,omitempty" example:"false"`                                  // Send the challenge and a code to POST /auth/2fa/verify
+	Challenge         string    `json:"challenge,omitempty" example:"eyJwIjoiMmZhLWxvZ2luIn0.c2lnbmF0dXJl"`
+	ExpiresAt         time.Time `json:"expires_at" example:"2025-11-24T12:00:00Z"` // Of the token, or of the challenge
+	User              *User     `json:"user,omitempty"`                            // Only once the login is complete
+}
+
+// OIDCProvider is an identity provider users can sign in with
+type OIDCProvider struct {
+	Name        string `json:"name" example:"company"`
+	DisplayName string `json:"display_name" example:"Company SSO"`
+	LoginURL    string `json:"login_url" example:"http://localhost:8080/v1/auth/oidc/company/login"` // Open in a browser to sign in
+}
@@ -6,7 +6,7 @@ import (
 	"gorm.io/gorm"
 )
 
-// Todo struct defines a single task item in the system 👈 ADD THIS LINE
+// Todo struct defines a single task item in the system
 type Todo struct {
 	// GORM fields explicitly documented for Swagger
 	ID        uint           `json:"id" example:"1"`
@@ -15,7 +15,11 @@ type Todo struct {
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Todo fields
-	Item      string `json:"item" gorm:"not null" example:"Buy groceries"`
-	Completed bool   `json:"completed" example:"false"`
-	UserID    uint   `json:"user_id" example:"1"` // Foreign key linking to User.ID
+	Item        string `json:"item" gorm:"not null" example:"Buy groceries"`
+	Description string `json:"description" gorm:"type:text" binding:"max=20000" example:"- milk\n- **eggs**"` // Markdown, up to 20000 characters
+	Completed   bool   `json:"completed" example:"false"`
+	UserID      uint   `json:"user_id" example:"1"` // Foreign key linking to User.ID
+
+	// Rendered description, only set when requested with ?render=html
+	DescriptionHTML string `json:"description_html,omitempty" gorm:"-" readonly:"true" example:"<ul><li>milk</li></ul>"`
 }
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// RecoveryCode lets a user log in once without their authenticator. Only its hash is stored.
+type RecoveryCode struct {
+	ID        uint       `json:"id" example:"1"`
+	CreatedAt time.Time  `json:"created_at" example:"2025-10-25T12:00:00Z"`
+	UserID    uint       `json:"user_id" gorm:"index;not null" example:"1"`
+	Hash      string     `json:"-" gorm:"uniqueIndex;not null"` // SHA-256 of the normalized code
+	UsedAt    *time.Time `json:"used_at" example:"2025-10-26T08:00:00Z" extensions:"x-nullable"`
+}
+
+// TwoFactorChallenge is a login waiting for its second factor. Its token is signed, and
+// the record makes it single-use and counts the wrong codes sent with it.
+type TwoFactorChallenge struct {
+	ID        uint
+	CreatedAt time.Time
+	UserID    uint       `gorm:"index;not null"`
+	Method    string     `gorm:"not null"` // How the first factor was given, e.g. password
+	Failures  int        // Wrong codes sent with it
+	ExpiresAt time.Time  // When its token expires
+	UsedAt    *time.Time // Set when the login completes
+}
+
+// TwoFactorEnrollment is the secret to add to an authenticator app
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`                                                           // Base32, for entering by hand
+	OTPAuthURL string `json:"otpauth_url" example:"otpauth://totp/Gin%20Demo%20API:alice@example.com?issuer=Gin+Demo+API&secret=JBSWY3DP"` // Show as a QR code to scan
+}
+
+// TwoFactorCode is a code from the authenticator app, or a recovery code
+type TwoFactorCode struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// TwoFactorLogin completes a login that needs a second factor
+type TwoFactorLogin struct {
+	Challenge string `json:"challenge" binding:"required" example:"eyJwIjoiMmZhLWxvZ2luIn0.c2lnbmF0dXJl"` // From the login result
+	Code      string `json:"code" binding:"required" example:"123456"`                                    // From the authenticator app, or a recovery code
+}
+
+// RecoveryCodes are shown once, when they are generated
+type RecoveryCodes struct {
+	Codes []string `json:"codes" example:"k7qm-2xbd-9fwa-p3tn"`
+}
+
+// TwoFactorStatus describes a user's two-factor authentication
+type TwoFactorStatus struct {
+	Enabled           bool       `json:"enabled" example:"true"`
+	EnabledAt         *time.Time `json:"enabled_at" example:"2025-10-25T11:40:00Z" extensions:"x-nullable"`
+	Required          bool       `json:"required" example:"false"` // The policy requires it for the user's role
+	RecoveryCodesLeft int        `json:"recovery_codes_left" example:"10"`
+}
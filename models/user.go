@@ -6,7 +6,13 @@ import (
 	"gorm.io/gorm"
 )
 
-// User struct defines the user entity and their basic profile information. 👈 Struct Description
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// User struct defines the user entity and their basic profile information.
 
 type User struct {
 	// GORM Model Fields (Explicitly documented for Swagger)
@@ -16,9 +22,21 @@ type User struct {
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"` // Ignored in JSON output
 
 	// User fields
-	Username string `json:"username" gorm:"unique;not null" example:"user_alice"`     // Must be unique
-	Email    string `json:"email" gorm:"unique;not null" example:"alice@example.com"` // Must be unique
+	Username string `json:"username" gorm:"unique;not null" example:"user_alice"`                                // Must be unique
+	Email    string `json:"email" gorm:"unique;not null" example:"alice@example.com"`                            // Must be unique
+	Role     string `json:"role" gorm:"not null;default:user" readonly:"true" enums:"user,admin" example:"user"` // Set with the create-admin command
+
+	// Account fields
+	EmailVerifiedAt *time.Time `json:"email_verified_at" readonly:"true" example:"2025-10-25T11:35:00Z" extensions:"x-nullable"` // Null until the emailed link is opened
+	PasswordHash    string     `json:"-"`                                                                                        // bcrypt; empty until a password is set
+
+	// Two-factor fields
+	TwoFactorEnabledAt   *time.Time `json:"two_factor_enabled_at" readonly:"true" example:"2025-10-25T11:40:00Z" extensions:"x-nullable"` // Null unless logins need a TOTP code
+	TOTPSecret           string     `json:"-"`                                                                                            // Base32; set on enrollment, before it is enabled
+	TOTPLastStep         int64      `json:"-"`                                                                                            // Time step of the last accepted code, so codes work once
+	TwoFactorFailures    int        `json:"-"`                                                                                            // Wrong codes in a row
+	TwoFactorLockedUntil *time.Time `json:"-"`                                                                                            // Codes are refused until then after too many wrong ones
 
 	// Relationship: List of associated Todo items
-	Todos []Todo `json:"todos"` // The 'json:"todos"' tag allows the list of todos to be included in the response.
+	Todos []Todo `json:"todos" extensions:"x-nullable"` // The 'json:"todos"' tag allows the list of todos to be included in the response.
 }
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Webhook is a user's subscription to change events, delivered by HTTP POST
+type Webhook struct {
+	// GORM fields explicitly documented for Swagger
+	ID        uint           `json:"id" example:"1"`
+	CreatedAt time.Time      `json:"created_at" example:"2025-10-25T12:00:00Z"`
+	UpdatedAt time.Time      `json:"updated_at" example:"2025-10-25T12:00:00Z"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Webhook fields
+	UserID uint       `json:"user_id" gorm:"index;not null" readonly:"true" example:"1"` // Owner, taken from the authenticated user
+	URL    string     `json:"url" gorm:"not null" binding:"required,url" example:"https://chat.example.com/hooks/todos"`
+	Events StringList `json:"events" gorm:"type:text;not null" binding:"required,min=1" swaggertype:"array,string" example:"todo.created,todo.completed"` // Event types, or "*" for all
+	Secret string     `json:"secret,omitempty" gorm:"not null" example:"whsec_8f1c2b7a9d3e4f50"`                                                          // HMAC key; generated when omitted, returned only on creation
+	Active *bool      `json:"active" gorm:"not null;default:true" example:"true"`
+}
+
+// WebhookChanges lists the fields to change on a webhook; omitted fields are left unchanged
+type WebhookChanges struct {
+	URL    *string    `json:"url" binding:"omitempty,url" example:"https://chat.example.com/hooks/todos"`
+	Events StringList `json:"events" binding:"omitempty,min=1" swaggertype:"array,string" example:"todo.created,todo.completed"`
+	Secret *string    `json:"secret" example:"whsec_8f1c2b7a9d3e4f50"`
+	Active *bool      `json:"active" example:"false"`
+}
+
+// WebhookDelivery is one queued or attempted delivery of an event to a webhook.
+// Pending rows form the persistent retry queue; finished rows are the delivery log.
+type WebhookDelivery struct {
+	ID        uint      `json:"id" example:"1"`
+	CreatedAt time.Time `json:"created_at" example:"2025-10-25T12:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2025-10-25T12:00:05Z"`
+
+	// Delivery fields
+	WebhookID      uint       `json:"webhook_id" gorm:"index;not null" example:"1"`
+	EventID        uint64     `json:"event_id" example:"42"` // Change log ID
+	EventType      string     `json:"event_type" gorm:"not null" example:"todo.completed"`
+	Payload        string     `json:"payload" gorm:"type:text;not null"`                                   // JSON request body
+	Status         string     `json:"status" gorm:"index:idx_delivery_queue;not null" example:"succeeded"` // pending, succeeded or failed
+	Attempts       int        `json:"attempts" example:"1"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index:idx_delivery_queue" example:"2025-10-25T12:00:00Z"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at" example:"2025-10-25T12:00:05Z" extensions:"x-nullable"`
+	ResponseStatus int        `json:"response_status" example:"200"`
+	ResponseBody   string     `json:"response_body" example:"ok"` // Truncated
+	Error          string     `json:"error" example:""`
+	TraceParent    string     `json:"-"` // W3C traceparent of the change, continued by every attempt
+
+	Webhook Webhook `json:"-"`
+}
+
+// StringList is a list of strings stored as a JSON column
+type StringList []string
+
+// Value implements driver.Valuer
+func (l StringList) Value() (driver.Value, error) {
+	b, err := json.Marshal(l)
+	return string(b), err
+}
+
+// Scan implements sql.Scanner
+func (l *StringList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	case []byte:
+		return json.Unmarshal(v, l)
+	}
+	return errors.New("unsupported type for StringList")
+}
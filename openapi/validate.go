@@ -0,0 +1,205 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+)
+
+// ValidatorOptions configures Validator
+type ValidatorOptions struct {
+	// ValidateResponses checks JSON responses too and replaces mismatches with a 500,
+	// so tests fail when a handler drifts from its annotations
+	ValidateResponses bool
+
+	// ExtraBasePaths are mounted with the documented routes too, besides the document's own base path
+	ExtraBasePaths []string
+}
+
+// Validator returns middleware that rejects requests not matching the OpenAPI document with 400.
+// Routes missing from the document (Swagger UI, WebSockets) are passed through unchecked.
+func Validator(opts ValidatorOptions) (gin.HandlerFunc, error) {
+	spec, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	// Match on paths only; the document's server URL names the default host
+	routing := *spec
+	routing.Servers = nil
+	for _, server := range spec.Servers {
+		base, err := server.BasePath()
+		if err != nil {
+			return nil, err
+		}
+		routing.Servers = append(routing.Servers, &openapi3.Server{URL: base})
+	}
+	for _, base := range opts.ExtraBasePaths {
+		routing.Servers = append(routing.Servers, &openapi3.Server{URL: base})
+	}
+	router, err := gorillamux.NewRouter(&routing)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				// Authentication is enforced by the auth middleware
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				// Uploads are streamed and checked by the handler rather than buffered here
+				ExcludeRequestBody: mediaType(c.GetHeader("Content-Type")) == "multipart/form-data",
+			},
+		}
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": validationMessage(err)})
+			return
+		}
+
+		if !opts.ValidateResponses {
+			c.Next()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = recorder
+		c.Next()
+		c.Writer = recorder.ResponseWriter
+		recorder.finish(input, route)
+	}, nil
+}
+
+// responseRecorder holds back JSON responses so they can be validated before being sent.
+// Anything else (files, event streams, upgrades) is passed straight through.
+type responseRecorder struct {
+	gin.ResponseWriter
+	status    int
+	statusSet bool
+	decided   bool
+	buffering bool
+	body      bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.decided && !r.buffering {
+		r.ResponseWriter.WriteHeader(code)
+		return
+	}
+	r.status = code
+	r.statusSet = true
+}
+
+func (r *responseRecorder) WriteHeaderNow() {
+	r.decide()
+	if !r.buffering {
+		r.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.decide()
+	if r.buffering {
+		return r.body.Write(data)
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	return r.Write([]byte(s))
+}
+
+func (r *responseRecorder) Flush() {
+	r.decide()
+	if !r.buffering {
+		r.ResponseWriter.Flush()
+	}
+}
+
+func (r *responseRecorder) Status() int {
+	if r.decided && !r.buffering {
+		return r.ResponseWriter.Status()
+	}
+	return r.status
+}
+
+func (r *responseRecorder) Written() bool {
+	return r.decided || r.ResponseWriter.Written()
+}
+
+// decide picks buffering or pass-through at the first write, once the content type is known
+func (r *responseRecorder) decide() {
+	if r.decided {
+		return
+	}
+	r.decided = true
+	r.buffering = mediaType(r.Header().Get("Content-Type")) == "application/json"
+	if !r.buffering && r.statusSet {
+		r.ResponseWriter.WriteHeader(r.status)
+	}
+}
+
+// finish validates a buffered response and sends it, or a 500 describing the mismatch
+func (r *responseRecorder) finish(request *openapi3filter.RequestValidationInput, route *routers.Route) {
+	if !r.decided {
+		// Nothing was written; let Gin send the status alone
+		if r.statusSet {
+			r.ResponseWriter.WriteHeader(r.status)
+		}
+		return
+	}
+	if !r.buffering {
+		return
+	}
+
+	err := openapi3filter.ValidateResponse(request.Request.Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: request,
+		Status:                 r.status,
+		Header:                 r.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(r.body.Bytes())),
+		Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+	})
+	if err != nil {
+		message := fmt.Sprintf("Response does not match the OpenAPI document for %s %s: %s", route.Method, route.Path, validationMessage(err))
+		slog.ErrorContext(request.Request.Context(), message)
+		r.ResponseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+		r.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+		data, _ := json.Marshal(gin.H{"error": message})
+		r.ResponseWriter.Write(data)
+		return
+	}
+
+	r.ResponseWriter.WriteHeader(r.status)
+	r.ResponseWriter.Write(r.body.Bytes())
+}
+
+// validationMessage keeps the first line of a kin-openapi error, dropping the schema dump
+func validationMessage(err error) string {
+	message, _, _ := strings.Cut(err.Error(), "\n")
+	return message
+}
+
+// mediaType returns the media type of a Content-Type header without parameters
+func mediaType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType
+}
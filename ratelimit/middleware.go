@@ -0,0 +1,190 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-demo-api/auth"
+	"gin-demo-api/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limiter applies a default limit to every client, and separate limits to the
+// routes that have one. Failed authentication is limited per client IP.
+type Limiter struct {
+	store    Store
+	fallback Limit
+	routes   map[string]Limit
+	failures Limit
+}
+
+// New returns a limiter; routes maps "METHOD /path" (without the version prefix) to its
+// limit, and failures limits failed authentication attempts per client IP
+func New(store Store, fallback Limit, routes map[string]Limit, failures Limit) *Limiter {
+	return &Limiter{store: store, fallback: fallback, routes: routes, failures: failures}
+}
+
+// FromConfig builds a limiter from its configuration, keeping buckets in memory
+func FromConfig(cfg config.RateLimitConfig) (*Limiter, error) {
+	fallback, err := ParseLimit(cfg.Default)
+	if err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT: %w", err)
+	}
+	routes, err := ParseRoutes(cfg.Routes)
+	if err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT_ROUTES: %w", err)
+	}
+	failures, err := ParseLimit(cfg.AuthFailures)
+	if err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT_AUTH_FAILURES: %w", err)
+	}
+	return New(NewMemoryStore(), fallback, routes, failures), nil
+}
+
+// ParseRoutes reads per-route limits such as "POST /todos=10/m". Paths are route
+// templates without the version prefix, e.g. "GET /todos/:id".
+func ParseRoutes(entries []string) (map[string]Limit, error) {
+	routes := map[string]Limit{}
+	for _, entry := range entries {
+		route, value, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPath || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid route rate limit %q: want \"METHOD /path=<requests>/<period>\"", entry)
+		}
+		limit, err := ParseLimit(value)
+		if err != nil {
+			return nil, err
+		}
+		routes[strings.ToUpper(method)+" "+path] = limit
+	}
+	return routes, nil
+}
+
+// Middleware limits the routes of a group mounted at basePath. The same route under
+// /v1 and the unversioned aliases shares one bucket.
+func (l *Limiter) Middleware(basePath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + routePath(c.FullPath(), basePath)
+		limit, result, err := l.Take(c.Request.Context(), ClientKey(c), route)
+		if err != nil {
+			// Better to serve without limits than to fail every request
+			slog.WarnContext(c.Request.Context(), "Rate limit store failed; request allowed", "err", err)
+			c.Next()
+			return
+		}
+		if limit.Unlimited() {
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", seconds(result.Reset))
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s", limit.Requests, seconds(limit.Period)))
+		if !result.Allowed {
+			header.Set("Retry-After", seconds(result.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Take counts a request of client against the limit of route ("METHOD /path" without
+// the version prefix), or against the API-wide limit when the route has none. It
+// returns the limit that applied; unlimited requests are always allowed.
+func (l *Limiter) Take(ctx context.Context, client, route string) (Limit, Result, error) {
+	limit, scope := l.fallback, "*"
+	if override, ok := l.routes[route]; ok {
+		limit, scope = override, route
+	}
+	if limit.Unlimited() {
+		return limit, Result{Allowed: true}, nil
+	}
+	result, err := l.store.Take(ctx, client+" "+scope, limit)
+	return limit, result, err
+}
+
+// AuthFailures limits failed authentication per client IP, and must run before
+// auth.Authenticate. Authentication rejects bad credentials before the API limits
+// apply, so every 401 to a request with credentials takes a token from a bucket of
+// its own, and while that is empty such requests are refused without being checked.
+func (l *Limiter) AuthFailures() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.HasCredentials(c.Request) {
+			c.Next()
+			return
+		}
+		ctx := c.Request.Context()
+		if locked, retryAfter := l.Locked(ctx, c.ClientIP()); locked {
+			c.Header("Retry-After", seconds(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed authentication attempts"})
+			return
+		}
+		c.Next()
+		if c.Writer.Status() == http.StatusUnauthorized {
+			l.Failed(ctx, c.ClientIP())
+		}
+	}
+}
+
+// Locked reports whether the client IP has used up its failed authentication attempts,
+// and how long until it may try again
+func (l *Limiter) Locked(ctx context.Context, ip string) (bool, time.Duration) {
+	if l.failures.Unlimited() {
+		return false, 0
+	}
+	result, err := l.store.Peek(ctx, failureKey(ip), l.failures)
+	if err != nil {
+		slog.WarnContext(ctx, "Rate limit store failed; authentication allowed", "err", err)
+		return false, 0
+	}
+	return !result.Allowed, result.RetryAfter
+}
+
+// Failed counts a failed authentication attempt against the client IP
+func (l *Limiter) Failed(ctx context.Context, ip string) {
+	if l.failures.Unlimited() {
+		return
+	}
+	if _, err := l.store.Take(ctx, failureKey(ip), l.failures); err != nil {
+		slog.WarnContext(ctx, "Rate limit store failed; authentication failure not counted", "err", err)
+	}
+}
+
+// failureKey is the bucket of failed authentication attempts from an IP
+func failureKey(ip string) string {
+	return "ip:" + ip + " auth-failures"
+}
+
+// ClientKey identifies who a request counts against: the API key, the user of the
+// session, or the client IP. A user ID taken from the X-User-ID header is not
+// proven, so such requests count against their IP like anonymous ones.
+func ClientKey(c *gin.Context) string {
+	if id, ok := auth.APIKeyID(c); ok {
+		return "key:" + strconv.FormatUint(uint64(id), 10)
+	}
+	if _, ok := auth.SessionID(c); ok {
+		id, _ := auth.UserID(c)
+		return "user:" + strconv.FormatUint(uint64(id), 10)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// routePath strips the group's base path from a route template
+func routePath(fullPath, basePath string) string {
+	return "/" + strings.TrimPrefix(strings.TrimPrefix(fullPath, strings.TrimSuffix(basePath, "/")), "/")
+}
+
+// seconds renders a duration as whole seconds, rounded up
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
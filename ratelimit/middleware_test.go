@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gin-demo-api/auth"
+	"gin-demo-api/internal/testdb"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientKey(t *testing.T) {
+	testdb.Open(t)
+	auth.TrustUserIDHeader = true
+	t.Cleanup(func() { auth.TrustUserIDHeader = false })
+	alice, aliceToken := testdb.Login(t, "alice")
+	bob, bobToken := testdb.Login(t, "bob")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	limiter := New(NewMemoryStore(), Limit{Requests: 1, Period: time.Minute}, nil, Limit{})
+	router.GET("/todos", auth.Authenticate(), limiter.Middleware("/"), func(c *gin.Context) {
+		c.String(http.StatusOK, ClientKey(c))
+	})
+	send := func(header, value string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+		req.Header.Set(header, value)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Sessions are counted per user, so two users behind one IP do not share a bucket
+	for _, token := range []string{aliceToken, bobToken} {
+		if w := send("Authorization", "Bearer "+token); w.Code != http.StatusOK {
+			t.Errorf("session: status %d, want %d", w.Code, http.StatusOK)
+		}
+	}
+
+	// Claimed user IDs share the IP's bucket, so rotating them does not evade the limit
+	if w := send("X-User-ID", "1"); w.Code != http.StatusOK || w.Body.String() != "ip:192.0.2.1" {
+		t.Errorf("X-User-ID: status %d, key %q; want %d, ip:192.0.2.1", w.Code, w.Body, http.StatusOK)
+	}
+	for _, id := range []uint{alice.ID, bob.ID} {
+		if w := send("X-User-ID", strconv.FormatUint(uint64(id), 10)); w.Code != http.StatusTooManyRequests {
+			t.Errorf("X-User-ID %d: status %d, want %d", id, w.Code, http.StatusTooManyRequests)
+		}
+	}
+}
+
+func TestAuthFailures(t *testing.T) {
+	testdb.Open(t)
+	_, token := testdb.Login(t, "alice")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	limiter := New(NewMemoryStore(), Limit{}, nil, Limit{Requests: 3, Period: time.Hour})
+	router.Use(limiter.AuthFailures(), auth.Authenticate())
+	router.GET("/todos", func(c *gin.Context) { c.Status(http.StatusOK) })
+	send := func(ip, token string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+		req.RemoteAddr = ip + ":1234"
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for i := 1; i <= 3; i++ {
+		if code := send("192.0.2.1", "gds_guess"+strconv.Itoa(i)); code != http.StatusUnauthorized {
+			t.Fatalf("bad token %d: status %d, want %d", i, code, http.StatusUnauthorized)
+		}
+	}
+	// Once the attempts are used up, credentials from that IP are not even checked
+	if code := send("192.0.2.1", "gds_guess4"); code != http.StatusTooManyRequests {
+		t.Errorf("bad token after the limit: status %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code := send("192.0.2.1", token); code != http.StatusTooManyRequests {
+		t.Errorf("valid token after the limit: status %d, want %d", code, http.StatusTooManyRequests)
+	}
+	// Requests without credentials, and other IPs, are not affected
+	if code := send("192.0.2.1", ""); code != http.StatusOK {
+		t.Errorf("anonymous request: status %d, want %d", code, http.StatusOK)
+	}
+	if code := send("198.51.100.7", token); code != http.StatusOK {
+		t.Errorf("valid token from another IP: status %d, want %d", code, http.StatusOK)
+	}
+	// Successful requests do not use up attempts
+	for i := 0; i < 5; i++ {
+		if code := send("198.51.100.7", token); code != http.StatusOK {
+			t.Fatalf("valid token, request %d: status %d, want %d", i, code, http.StatusOK)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Package ratelimit throttles API clients with token buckets. Each client gets a
+// bucket for the API as a whole and one per route that has its own limit.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests per Period, refilled continuously; a full bucket absorbs a burst of Requests
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Unlimited reports whether the limit turns rate limiting off
+func (l Limit) Unlimited() bool {
+	return l.Requests <= 0
+}
+
+// String renders the limit the way ParseLimit reads it
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// ParseLimit reads a limit such as "60/m", "1000/h" or "10/30s". "off" or "0" disables limiting.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" || s == "0" {
+		return Limit{}, nil
+	}
+
+	count, period, ok := strings.Cut(s, "/")
+	requests, err := strconv.Atoi(count)
+	if !ok || err != nil || requests < 1 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: want <requests>/<period>, e.g. 60/m", s)
+	}
+
+	var duration time.Duration
+	switch period {
+	case "s":
+		duration = time.Second
+	case "m":
+		duration = time.Minute
+	case "h":
+		duration = time.Hour
+	default:
+		if duration, err = time.ParseDuration(period); err != nil || duration <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit period %q: want s, m, h or a duration", period)
+		}
+	}
+	return Limit{Requests: requests, Period: duration}, nil
+}
+
+// Result is the state of a bucket after a Take
+type Result struct {
+	Allowed    bool          // Whether a token was taken
+	Remaining  int           // Whole tokens left
+	RetryAfter time.Duration // Time until the next token, when not allowed
+	Reset      time.Duration // Time until the bucket is full again
+}
+
+// Store keeps the buckets. MemoryStore serves a single instance; a shared store
+// (Redis, the database) lets several instances enforce one limit.
+type Store interface {
+	// Take refills the bucket for key at the limit's rate, then takes one token if there is one
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	// Peek refills the bucket for key like Take, without taking a token; Allowed reports
+	// whether a Take would succeed
+	Peek(ctx context.Context, key string, limit Limit) (Result, error)
+}
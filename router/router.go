@@ -0,0 +1,181 @@
+// Package router builds the HTTP handler of the API: middleware, documentation,
+// probes and every route under each version prefix.
+package router
+
+import (
+	"fmt"
+	"time"
+
+	"gin-demo-api/auth"
+	"gin-demo-api/config"
+	"gin-demo-api/diagnostics"
+	"gin-demo-api/graph"
+	"gin-demo-api/handlers"
+	"gin-demo-api/logging"
+	"gin-demo-api/metrics"
+	"gin-demo-api/openapi"
+	"gin-demo-api/ratelimit"
+	"gin-demo-api/tracing"
+
+	"github.com/gin-gonic/gin"
+
+	// imports for Swagger
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+
+	// 🚨 Import the docs package (must be manually created by 'swag init')
+	_ "gin-demo-api/docs"
+)
+
+// apiVersions lists the mounted API versions; each is served under /v<N>.
+// A new version that changes a DTO also registers its response shaper in handlers.
+var apiVersions = []int{1}
+
+// legacyDeprecatedSince is when the unversioned routes became aliases of /v1
+var legacyDeprecatedSince = time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+// New sets up the Gin router with all routes. The database, storage, mailer and
+// the other package-level dependencies must be configured first.
+func New(cfg config.Config) (*gin.Engine, error) {
+	// 2. Initialize the Gin router
+	router := gin.New()
+	// Only X-Forwarded-For set by a known proxy decides the client IP used for rate limits and logs
+	if err := router.SetTrustedProxies(cfg.Proxies); err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+
+	// Request IDs, one structured log line per request, and panics turned into 500s
+	router.Use(logging.RequestIDMiddleware(), logging.AccessLog("/healthz", "/readyz"), logging.Recovery())
+
+	// A span per request, continuing the caller's trace (traceparent header)
+	router.Use(tracing.Middleware())
+
+	// Request counts and latencies per route template
+	router.Use(metrics.Middleware())
+
+	// Per-client token buckets for the API routes; probes and metrics are not limited
+	limiter, err := ratelimit.FromConfig(cfg.RateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Identify the caller (session token or API key) for routes that need an actor,
+	// refusing clients that keep sending bad credentials
+	router.Use(limiter.AuthFailures(), auth.Authenticate())
+
+	// Reject requests that do not match the OpenAPI document
+	validator, err := openapi.Validator(openapi.ValidatorOptions{
+		ValidateResponses: cfg.OpenAPI.ValidateResponses,
+		ExtraBasePaths:    []string{"/"}, // The deprecated unversioned aliases
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the OpenAPI document: %w", err)
+	}
+	router.Use(validator)
+
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/openapi.json", openapi.Document) // OpenAPI 3.1 rendition of the Swagger document
+	router.GET("/metrics", metrics.Handler())     // Prometheus scrape endpoint
+
+	// Liveness and readiness probes, and build info, config and pprof for admins who
+	// confirmed their session with a second factor
+	diagnostics.Register(router, cfg, auth.RequireConfirmedAdmin())
+
+	// 3. Define RESTful API routes (CRUD) under each version prefix
+	for _, version := range apiVersions {
+		prefix := fmt.Sprintf("/v%d", version)
+		registerRoutes(router.Group(prefix, limiter.Middleware(prefix), handlers.APIVersion(version)))
+	}
+
+	// Unversioned aliases of /v1, kept for existing clients until the sunset date
+	registerRoutes(router.Group("/", limiter.Middleware("/"), handlers.Deprecated("/v1", legacyDeprecatedSince, cfg.LegacySunset)))
+
+	return router, nil
+}
+
+// registerRoutes adds the API routes of one version to api
+func registerRoutes(api *gin.RouterGroup) {
+	// --- USER ROUTES ---
+	api.POST("/users", handlers.CreateUser)                           // C: Create User
+	api.GET("/users", handlers.FindUsers)                             // R: Read All Users (with Todos)
+	api.GET("/users/:id", handlers.FindUser)                          // R: Read One User (with Todos)
+	api.PATCH("/users/:id", auth.RequireUser(), handlers.UpdateUser)  // U: Update User (self or admin)
+	api.DELETE("/users/:id", auth.RequireUser(), handlers.DeleteUser) // D: Delete User (self or admin)
+
+	// --- TODO ROUTES ---
+	api.POST("/todos", handlers.CreateTodo)                                        // C: Create
+	api.GET("/todos", handlers.FindTodos)                                          // R: Read All
+	api.GET("/todos/search", handlers.SearchTodos)                                 // R: Full-text search
+	api.GET("/todos/:id", handlers.FindTodo)                                       // R: Read One
+	api.PATCH("/todos/:id", handlers.UpdateTodo)                                   // U: Update
+	api.DELETE("/todos/:id", handlers.DeleteTodo)                                  // D: Delete
+	api.GET("/todos/:id/history", handlers.FindTodoHistory)                        // R: Change history
+	api.GET("/ws/todos", auth.RequireUser(), handlers.StreamTodos)                 // R: Live changes of visible todos over WebSocket
+	api.POST("/ws/tickets", auth.RequireSession(), handlers.CreateWebSocketTicket) // C: Ticket for a browser's WebSocket handshake
+
+	// --- COMMENT ROUTES ---
+	api.POST("/todos/:id/comments", auth.RequireUser(), handlers.CreateComment)               // C: Create Comment
+	api.GET("/todos/:id/comments", handlers.FindComments)                                     // R: Read All Comments
+	api.PATCH("/todos/:id/comments/:comment_id", auth.RequireUser(), handlers.UpdateComment)  // U: Update Comment (author only)
+	api.DELETE("/todos/:id/comments/:comment_id", auth.RequireUser(), handlers.DeleteComment) // D: Delete Comment (author only)
+
+	// --- ATTACHMENT ROUTES ---
+	api.POST("/todos/:id/attachments", handlers.CreateAttachment)                  // C: Upload Attachment
+	api.GET("/todos/:id/attachments", handlers.FindAttachments)                    // R: Read All Attachments
+	api.GET("/todos/:id/attachments/:attachment_id", handlers.DownloadAttachment)  // R: Download (Range supported)
+	api.DELETE("/todos/:id/attachments/:attachment_id", handlers.DeleteAttachment) // D: Delete Attachment
+
+	// --- EVENT ROUTES ---
+	api.GET("/events", auth.RequireUser(), handlers.StreamEvents) // R: Change feed of own changes, or all for admins (Server-Sent Events)
+
+	// --- WEBHOOK ROUTES ---
+	hooks := api.Group("/webhooks", auth.RequireUser())
+	hooks.POST("", handlers.CreateWebhook)                                          // C: Register Webhook
+	hooks.GET("", handlers.FindWebhooks)                                            // R: Read Own Webhooks
+	hooks.GET("/:id", handlers.FindWebhook)                                         // R: Read One Webhook
+	hooks.PATCH("/:id", handlers.UpdateWebhook)                                     // U: Update Webhook
+	hooks.DELETE("/:id", handlers.DeleteWebhook)                                    // D: Delete Webhook
+	hooks.GET("/:id/deliveries", handlers.FindWebhookDeliveries)                    // R: Delivery Log
+	hooks.POST("/:id/deliveries/:delivery_id/redeliver", handlers.RedeliverWebhook) // C: Manual Redelivery
+
+	// --- ACCOUNT ROUTES ---
+	api.POST("/auth/email-verification", auth.RequireUser(), handlers.ResendVerification) // C: Resend Verification Email
+	api.GET("/auth/verify-email", handlers.VerifyEmail)                                   // U: Verify Email (emailed link)
+	api.POST("/auth/password-reset", handlers.RequestPasswordReset)                       // C: Email Reset Token
+	api.POST("/auth/password-reset/confirm", handlers.ResetPassword)                      // U: Set New Password
+
+	// --- LOGIN ROUTES ---
+	api.POST("/auth/login", handlers.PasswordLogin)                 // C: Password Login, Create Session
+	api.GET("/auth/oidc/providers", handlers.FindOIDCProviders)     // R: List Identity Providers
+	api.GET("/auth/oidc/:provider/login", handlers.OIDCLogin)       // C: Start Login (browser redirect)
+	api.GET("/auth/oidc/:provider/callback", handlers.OIDCCallback) // C: Finish Login, Create Session
+
+	// --- TWO-FACTOR ROUTES ---
+	api.POST("/auth/2fa/verify", handlers.VerifyTwoFactorLogin) // C: Complete Login with a Code
+	tfa := api.Group("/auth/2fa", auth.RequireSession())
+	tfa.GET("", handlers.GetTwoFactorStatus)                      // R: Two-Factor Status
+	tfa.POST("/enroll", handlers.EnrollTwoFactor)                 // C: Generate TOTP Secret
+	tfa.POST("/activate", handlers.ActivateTwoFactor)             // U: Enable with a Code
+	tfa.POST("/recovery-codes", handlers.RegenerateRecoveryCodes) // U: Replace Recovery Codes
+	tfa.DELETE("", handlers.DisableTwoFactor)                     // D: Disable
+
+	// --- SESSION ROUTES ---
+	sessions := api.Group("/me/sessions", auth.RequireUser())
+	sessions.GET("", handlers.FindSessions)         // R: Read Own Sessions
+	sessions.DELETE("/:id", handlers.DeleteSession) // D: Revoke Session ("current" to log out)
+	sessions.DELETE("", handlers.DeleteSessions)    // D: Log Out Everywhere
+
+	// --- API KEY ROUTES ---
+	keys := api.Group("/api-keys", auth.RequireUser())
+	keys.POST("", handlers.CreateAPIKey)       // C: Create API Key
+	keys.GET("", handlers.FindAPIKeys)         // R: Read Own API Keys
+	keys.PATCH("/:id", handlers.UpdateAPIKey)  // U: Rename or Rescope API Key
+	keys.DELETE("/:id", handlers.DeleteAPIKey) // D: Revoke API Key
+
+	// --- AUDIT ROUTES ---
+	api.GET("/audit", auth.RequireAdmin(), handlers.FindAuditEvents) // R: Query audit trail (admins only)
+
+	// --- GRAPHQL ROUTES ---
+	api.POST("/graphql", graph.Query)                        // Queries and mutations
+	api.GET("/graphql", auth.RequireUser(), graph.Subscribe) // Subscriptions to own changes over WebSocket (graphql-transport-ws)
+}
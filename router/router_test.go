@@ -0,0 +1,394 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-demo-api/config"
+	"gin-demo-api/handlers"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+	"gin-demo-api/openapi"
+	"gin-demo-api/storage"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// specTest sends requests to the full router and records which documented routes they hit
+type specTest struct {
+	t       *testing.T
+	server  *httptest.Server
+	routes  routers.Router
+	covered map[string]bool
+}
+
+// upload is a multipart/form-data body with one file field
+type upload struct {
+	filename, contentType, content string
+}
+
+// startSpecTest serves the router as configured under GIN_MODE=test, which turns on
+// response validation: a response that does not match docs/swagger.json becomes a 500
+func startSpecTest(t *testing.T) *specTest {
+	t.Helper()
+	t.Setenv("GIN_MODE", "test")
+	gin.SetMode(gin.TestMode)
+	cfg := config.Load()
+	cfg.RateLimit.Default = "off"
+	if !cfg.OpenAPI.ValidateResponses {
+		t.Fatal("response validation is off under GIN_MODE=test")
+	}
+
+	if err := storage.ConnectStorage(config.StorageConfig{Driver: "local", Dir: t.TempDir()}); err != nil {
+		t.Fatal(err)
+	}
+	handlers.AttachmentLimits = cfg.Attachments
+
+	handler, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Match on the base path alone, like the validator
+	routing := *spec
+	routing.Servers = openapi3.Servers{{URL: "/v1"}}
+	routes, err := gorillamux.NewRouter(&routing)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return &specTest{t: t, server: server, routes: routes, covered: map[string]bool{}}
+}
+
+// call sends a request to /v1 and checks its status. body is nil, a JSON string or an upload.
+func (s *specTest) call(method, path, token string, body interface{}, want int) []byte {
+	s.t.Helper()
+	var reader io.Reader
+	contentType := ""
+	switch body := body.(type) {
+	case string:
+		reader, contentType = strings.NewReader(body), "application/json"
+	case upload:
+		var buf bytes.Buffer
+		form := multipart.NewWriter(&buf)
+		header := make(map[string][]string)
+		header["Content-Disposition"] = []string{fmt.Sprintf(`form-data; name="file"; filename=%q`, body.filename)}
+		header["Content-Type"] = []string{body.contentType}
+		part, _ := form.CreatePart(header)
+		part.Write([]byte(body.content))
+		form.Close()
+		reader, contentType = &buf, form.FormDataContentType()
+	}
+
+	req, err := http.NewRequest(method, s.server.URL+"/v1"+path, reader)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	s.cover(req)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Do(req)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != want {
+		s.t.Errorf("%s %s: status %d, want %d: %s", method, path, resp.StatusCode, want, data)
+	}
+	return data
+}
+
+// stream opens a Server-Sent Events stream at /v1 and checks its status and type
+func (s *specTest) stream(path, token string) {
+	s.t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, s.server.URL+"/v1"+path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	s.cover(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		s.t.Errorf("GET %s: status %d, type %q; want an event stream", path, resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+}
+
+// dial opens a WebSocket to /v1 and checks that the upgrade succeeds
+func (s *specTest) dial(path, token string, subprotocols ...string) {
+	s.t.Helper()
+	header := http.Header{"Authorization": {"Bearer " + token}}
+	req, _ := http.NewRequest(http.MethodGet, s.server.URL+"/v1"+path, nil)
+	s.cover(req)
+
+	dialer := websocket.Dialer{Subprotocols: subprotocols}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(s.server.URL, "http")+"/v1"+path, header)
+	if err != nil {
+		s.t.Errorf("GET %s: %v", path, err)
+		return
+	}
+	conn.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		s.t.Errorf("GET %s: status %d, want %d", path, resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+}
+
+// cover records the documented route a request matches
+func (s *specTest) cover(req *http.Request) {
+	if route, _, err := s.routes.FindRoute(req); err == nil {
+		s.covered[route.Method+" "+route.Path] = true
+	}
+}
+
+// totp returns the current code for a base32 secret, as an authenticator app would
+func totp(secret string) string {
+	key, _ := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	mac := hmac.New(sha1.New, key)
+	binary.Write(mac, binary.BigEndian, time.Now().Unix()/30)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:])&0x7fffffff)%1_000_000)
+}
+
+// TestDocumentedRoutes calls every route in docs/swagger.json with response validation
+// on, so a handler that drifts from its annotations fails here
+func TestDocumentedRoutes(t *testing.T) {
+	testdb.Open(t)
+	s := startSpecTest(t)
+
+	_, alice := testdb.Login(t, "alice")
+	enabled := time.Now()
+	admin, _ := testdb.Session(t, testdb.CreateUser(t, models.User{Username: "admin", Role: models.RoleAdmin, TwoFactorEnabledAt: &enabled}).ID, true)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("correct horse battery"), bcrypt.MinCost)
+	carol, _ := testdb.Session(t, testdb.CreateUser(t, models.User{Username: "carol", PasswordHash: string(hash)}).ID, false)
+
+	// Users (dave is 4)
+	s.call("GET", "/users", "", nil, 200)
+	s.call("POST", "/users", "", `{"username": "dave", "email": "dave@example.com"}`, 201)
+	s.call("GET", "/users/4", "", nil, 200)
+	s.call("PATCH", "/users/4", "", `{"email": "dave@new.example.com"}`, 401)
+	s.call("PATCH", "/users/4", alice, `{"email": "dave@new.example.com"}`, 403)
+	s.call("PATCH", "/users/4", admin, `{"email": "dave@new.example.com"}`, 200)
+
+	// Webhooks, registered before the todo so it gets a delivery
+	s.call("POST", "/webhooks", alice, `{"url": "https://hooks.example.com/todos", "events": ["*"]}`, 201)
+	s.call("GET", "/webhooks", alice, nil, 200)
+	s.call("GET", "/webhooks/1", alice, nil, 200)
+	s.call("PATCH", "/webhooks/1", alice, `{"events": ["todo.created", "todo.completed"]}`, 200)
+
+	// Todos
+	s.call("POST", "/todos", alice, `{"item": "Buy milk", "description": "- milk\n- **eggs**", "user_id": 1}`, 201)
+	s.call("GET", "/todos?completed=false&limit=10", "", nil, 200)
+	s.call("GET", "/todos/search?q=milk", "", nil, 200)
+	s.call("GET", "/todos/1?render=html", "", nil, 200)
+	s.call("PATCH", "/todos/1", alice, `{"completed": true}`, 200)
+	s.call("GET", "/todos/1/history", "", nil, 200)
+	s.call("GET", "/webhooks/1/deliveries", alice, nil, 200)
+	s.call("POST", "/webhooks/1/deliveries/1/redeliver", alice, nil, 202)
+
+	// Comments and attachments
+	s.call("POST", "/todos/1/comments", alice, `{"body": "@carol can you pick up the eggs?"}`, 201)
+	s.call("GET", "/todos/1/comments", "", nil, 200)
+	s.call("PATCH", "/todos/1/comments/1", alice, `{"body": "@carol never mind"}`, 200)
+	s.call("DELETE", "/todos/1/comments/1", alice, nil, 200)
+	s.call("POST", "/todos/1/attachments", alice, upload{"list.txt", "text/plain", "milk\neggs\n"}, 201)
+	s.call("GET", "/todos/1/attachments", "", nil, 200)
+	s.call("GET", "/todos/1/attachments/1", "", nil, 200)
+	s.call("DELETE", "/todos/1/attachments/1", alice, nil, 200)
+
+	// Change feed, GraphQL and WebSockets
+	s.stream("/events?last_event_id=0&types=todo", alice)
+	s.call("POST", "/graphql", alice, `{"query": "{ users { id username todos(limit: 1) { id item } } }"}`, 200)
+	s.dial("/graphql", alice, "graphql-transport-ws")
+	s.dial("/ws/todos", alice)
+	s.call("POST", "/ws/tickets", alice, nil, 201)
+
+	// API keys
+	s.call("POST", "/api-keys", alice, `{"name": "CI", "scopes": ["todos:read"]}`, 201)
+	s.call("GET", "/api-keys", alice, nil, 200)
+	s.call("PATCH", "/api-keys/1", alice, `{"name": "Nightly CI"}`, 200)
+	s.call("DELETE", "/api-keys/1", alice, nil, 200)
+
+	// Email verification, password reset and OIDC, as far as they go without the emailed tokens
+	s.call("POST", "/auth/email-verification", alice, nil, 202)
+	s.call("GET", "/auth/verify-email?token=invalid", "", nil, 400)
+	s.call("POST", "/auth/password-reset", "", `{"email": "alice@example.com"}`, 202)
+	s.call("POST", "/auth/password-reset/confirm", "", `{"token": "invalid", "password": "correct horse battery"}`, 400)
+	s.call("GET", "/auth/oidc/providers", "", nil, 200)
+	s.call("GET", "/auth/oidc/unknown/login", "", nil, 404)
+	s.call("GET", "/auth/oidc/unknown/callback?state=x&code=y", "", nil, 404)
+
+	// Two-factor authentication: enroll, activate, and log in with a recovery code (session 4)
+	s.call("GET", "/auth/2fa", carol, nil, 200)
+	var enrollment models.TwoFactorEnrollment
+	json.Unmarshal(s.call("POST", "/auth/2fa/enroll", carol, nil, 200), &enrollment)
+	var codes models.RecoveryCodes
+	json.Unmarshal(s.call("POST", "/auth/2fa/activate", carol, fmt.Sprintf(`{"code": %q}`, totp(enrollment.Secret)), 200), &codes)
+	if len(codes.Codes) == 0 {
+		t.Fatal("no recovery codes returned")
+	}
+	json.Unmarshal(s.call("POST", "/auth/2fa/recovery-codes", carol, fmt.Sprintf(`{"code": %q}`, codes.Codes[0]), 200), &codes)
+	var challenge models.LoginResult
+	json.Unmarshal(s.call("POST", "/auth/login", "", `{"login": "carol", "password": "correct horse battery"}`, 200), &challenge)
+	s.call("POST", "/auth/2fa/verify", "", fmt.Sprintf(`{"challenge": %q, "code": %q}`, challenge.Challenge, codes.Codes[0]), 200)
+	s.call("DELETE", "/auth/2fa", carol, fmt.Sprintf(`{"code": %q}`, codes.Codes[1]), 200)
+
+	// Deletes, now the resources above are no longer needed
+	s.call("DELETE", "/todos/1", "", nil, 200)
+	s.call("DELETE", "/users/4", "", nil, 401)
+	s.call("DELETE", "/users/4", alice, nil, 403)
+	s.call("DELETE", "/users/4", admin, nil, 200)
+	s.call("DELETE", "/webhooks/1", alice, nil, 200)
+
+	// Sessions and the audit trail, ending alice's sessions
+	s.call("GET", "/me/sessions", carol, nil, 200)
+	s.call("DELETE", "/me/sessions/4", carol, nil, 200)
+	s.call("DELETE", "/me/sessions", alice, nil, 200)
+	s.call("GET", "/audit?entity_type=todo", admin, nil, 200)
+
+	// Every documented route was called
+	spec, _ := openapi.Load()
+	var missing []string
+	for path, item := range spec.Paths.Map() {
+		for method := range item.Operations() {
+			if !s.covered[method+" "+path] {
+				missing = append(missing, method+" "+path)
+			}
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("documented routes not called: %s", strings.Join(missing, ", "))
+	}
+}
+
+// TestTrustedProxies checks X-Forwarded-For only sets the client IP, and with it the
+// rate limit bucket, when a configured proxy sent it
+func TestTrustedProxies(t *testing.T) {
+	testdb.Open(t)
+	gin.SetMode(gin.TestMode)
+	get := func(handler http.Handler, forwardedFor string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for _, tt := range []struct {
+		name    string
+		proxies []string
+		want    int
+	}{
+		{"no trusted proxies", nil, http.StatusTooManyRequests},
+		{"request from a trusted proxy", []string{"192.0.2.0/24"}, http.StatusOK},
+	} {
+		cfg := config.Load()
+		cfg.RateLimit.Default = "1/h"
+		cfg.Proxies = tt.proxies
+		handler, err := New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code := get(handler, "203.0.113.1"); code != http.StatusOK {
+			t.Fatalf("%s: first request: status %d", tt.name, code)
+		}
+		// A different forwarded address is another client only if the proxy is trusted
+		if code := get(handler, "203.0.113.2"); code != tt.want {
+			t.Errorf("%s: request forwarded for another address: status %d, want %d", tt.name, code, tt.want)
+		}
+	}
+
+	cfg := config.Load()
+	cfg.Proxies = []string{"not an address"}
+	if _, err := New(cfg); err == nil {
+		t.Error("New accepted an invalid trusted proxy")
+	}
+}
+
+func TestLegacyAliasesAreDeprecated(t *testing.T) {
+	testdb.Open(t)
+	testdb.CreateUser(t, models.User{Username: "alice"})
+	gin.SetMode(gin.TestMode)
+	cfg := config.Load()
+	cfg.LegacySunset = time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC)
+	handler, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Request-ID", "legacy-test")
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, tt := range []struct {
+		path   string
+		status int
+	}{
+		{"/users", http.StatusOK},
+		{"/todos/999", http.StatusNotFound},
+	} {
+		w := get(tt.path)
+		if w.Code != tt.status {
+			t.Fatalf("GET %s: status %d, want %d", tt.path, w.Code, tt.status)
+		}
+		for header, want := range map[string]string{
+			"Deprecation": fmt.Sprintf("@%d", legacyDeprecatedSince.Unix()),
+			"Sunset":      "Fri, 30 Apr 2027 00:00:00 GMT",
+			"Link":        `</v1` + tt.path + `>; rel="successor-version"`,
+		} {
+			if got := w.Header().Get(header); got != want {
+				t.Errorf("GET %s: %s = %q, want %q", tt.path, header, got, want)
+			}
+		}
+
+		// The versioned route answers the same, without the headers
+		versioned := get("/v1" + tt.path)
+		if versioned.Code != w.Code || versioned.Body.String() != w.Body.String() {
+			t.Errorf("GET /v1%s differs from its alias: %d %s", tt.path, versioned.Code, versioned.Body)
+		}
+		for _, header := range []string{"Deprecation", "Sunset", "Link"} {
+			if got := versioned.Header().Get(header); got != "" {
+				t.Errorf("GET /v1%s: %s = %q, want none", tt.path, header, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"gin-demo-api/db"
+	"gin-demo-api/models"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var (
+	seedFirstNames = []string{"Alice", "Bruno", "Chen", "Dana", "Elif", "Farid", "Grace", "Hiro", "Ines", "Jonas", "Kofi", "Lena", "Mateo", "Nadia", "Omar", "Priya", "Quinn", "Rosa", "Sven", "Tara", "Umar", "Vera", "Wei", "Ximena", "Yusuf", "Zoe"}
+	seedLastNames  = []string{"Andersen", "Bianchi", "Costa", "Dubois", "Eriksen", "Fischer", "Garcia", "Hughes", "Ivanova", "Jensen", "Kowalski", "Lopez", "Murphy", "Nakamura", "Okafor", "Patel", "Quintero", "Rossi", "Schmidt", "Tanaka", "Usman", "Varga", "Weber", "Yilmaz", "Zhang"}
+	seedDomains    = []string{"example.com", "example.org", "example.net", "mail.example.com"}
+	seedTasks      = []string{
+		"Buy groceries", "Call the dentist", "Renew passport", "Pay electricity bill", "Book flights to %s",
+		"Review pull request #%d", "Write quarterly report", "Prepare slides for %s", "Fix the leaking tap",
+		"Water the plants", "Schedule car service", "Reply to %s's email", "Update resume", "Back up laptop",
+		"Plan %s's birthday party", "Read chapter %d", "Cancel unused subscriptions", "File expense report",
+		"Order new running shoes", "Clean the garage", "Send invoice #%d", "Renew gym membership",
+	}
+	seedPlaces      = []string{"Lisbon", "Tokyo", "Nairobi", "Oslo", "Montreal", "Seoul", "Lima"}
+	seedDescription = []string{
+		"", "", "",
+		"- milk\n- **eggs**\n- bread",
+		"Before **Friday**. See the [shared notes](https://example.com/notes).",
+		"1. Gather receipts\n2. Fill in the form\n3. Submit",
+		"Blocked until we hear back from the vendor.",
+	}
+)
+
+func seedCommand() *cobra.Command {
+	var (
+		users, todos int
+		completed    float64
+		seed         uint64
+	)
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Fill the database with realistic fake users and todos",
+		Long:  "Inserts fake users with a random number of todos each (0 to twice --todos). Seeded rows are not audited and do not trigger events or webhooks.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if users < 0 || todos < 0 {
+				return fmt.Errorf("--users and --todos must not be negative")
+			}
+			if completed < 0 || completed > 1 {
+				return fmt.Errorf("--completed must be between 0 and 1")
+			}
+			if err := openDatabase(); err != nil {
+				return err
+			}
+			if seed == 0 {
+				seed = uint64(time.Now().UnixNano())
+			}
+			rng := rand.New(rand.NewPCG(seed, seed))
+
+			// Usernames and emails are unique; skip the ones already taken
+			taken := map[string]bool{}
+			var existing []models.User
+			if err := db.DB.Unscoped().Select("username", "email").Find(&existing).Error; err != nil {
+				return err
+			}
+			for _, user := range existing {
+				taken[user.Username] = true
+				taken[user.Email] = true
+			}
+
+			var created, createdTodos int
+			err := db.DB.Transaction(func(tx *gorm.DB) error {
+				for i := 0; i < users; i++ {
+					user := fakeUser(rng, taken)
+					if err := tx.Create(&user).Error; err != nil {
+						return err
+					}
+					created++
+
+					var list []models.Todo
+					for j := rng.IntN(2*todos + 1); j > 0; j-- {
+						list = append(list, fakeTodo(rng, user, completed))
+					}
+					if len(list) == 0 {
+						continue
+					}
+					if err := tx.CreateInBatches(list, 100).Error; err != nil {
+						return err
+					}
+					createdTodos += len(list)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Created %d users and %d todos (seed %d)\n", created, createdTodos, seed)
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&users, "users", 10, "Number of users to create")
+	cmd.Flags().IntVar(&todos, "todos", 5, "Average number of todos per user")
+	cmd.Flags().Float64Var(&completed, "completed", 0.3, "Fraction of todos that are completed")
+	cmd.Flags().Uint64Var(&seed, "seed", 0, "Random seed for reproducible data (default: random)")
+	return cmd
+}
+
+// fakeUser returns a user with a username and email not in taken, and marks them taken
+func fakeUser(rng *rand.Rand, taken map[string]bool) models.User {
+	first := seedFirstNames[rng.IntN(len(seedFirstNames))]
+	last := seedLastNames[rng.IntN(len(seedLastNames))]
+	base := strings.ToLower(first + "." + last)
+	domain := seedDomains[rng.IntN(len(seedDomains))]
+
+	username := base
+	for n := 2; taken[username] || taken[username+"@"+domain]; n++ {
+		username = fmt.Sprintf("%s%d", base, n)
+	}
+	email := username + "@" + domain
+	taken[username] = true
+	taken[email] = true
+
+	joined := time.Now().Add(-time.Duration(rng.IntN(365*24)) * time.Hour)
+	return models.User{Username: username, Email: email, Role: models.RoleUser, CreatedAt: joined, UpdatedAt: joined}
+}
+
+// fakeTodo returns a todo for user created some time after they joined
+func fakeTodo(rng *rand.Rand, user models.User, completed float64) models.Todo {
+	item := seedTasks[rng.IntN(len(seedTasks))]
+	switch {
+	case strings.Contains(item, "%s") && strings.HasPrefix(item, "Book"):
+		item = fmt.Sprintf(item, seedPlaces[rng.IntN(len(seedPlaces))])
+	case strings.Contains(item, "%s"):
+		item = fmt.Sprintf(item, seedFirstNames[rng.IntN(len(seedFirstNames))])
+	case strings.Contains(item, "%d"):
+		item = fmt.Sprintf(item, 1+rng.IntN(400))
+	}
+
+	age := time.Since(user.CreatedAt)
+	created := user.CreatedAt.Add(time.Duration(rng.Int64N(int64(age))))
+	todo := models.Todo{
+		Item:        item,
+		Description: seedDescription[rng.IntN(len(seedDescription))],
+		Completed:   rng.Float64() < completed,
+		UserID:      user.ID,
+		CreatedAt:   created,
+		UpdatedAt:   created,
+	}
+	if todo.Completed {
+		todo.UpdatedAt = created.Add(time.Duration(rng.Int64N(int64(time.Since(created)))))
+	}
+	return todo
+}
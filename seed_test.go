@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+)
+
+// runSeed runs the seed command and returns what it printed
+func runSeed(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := seedCommand()
+	cmd.SetArgs(args)
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestSeed(t *testing.T) {
+	testdb.Open(t)
+	testdb.CreateUser(t, models.User{Username: "alice.andersen", Email: "alice.andersen@example.com"})
+
+	out, err := runSeed(t, "--users", "20", "--todos", "3", "--completed", "1", "--seed", "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var users, todos, open int64
+	db.DB.Model(&models.User{}).Count(&users)
+	db.DB.Model(&models.Todo{}).Count(&todos)
+	db.DB.Model(&models.Todo{}).Where("completed = ?", false).Count(&open)
+	if users != 21 {
+		t.Errorf("%d users, want the existing one and 20 seeded", users)
+	}
+	if !strings.HasPrefix(out, "Created 20 users and ") || !strings.Contains(out, "(seed 42)") {
+		t.Errorf("output %q", out)
+	}
+	if todos == 0 || todos > 20*6 || open != 0 {
+		t.Errorf("%d todos, %d open; want 1 to 120, all completed", todos, open)
+	}
+
+	// Seeded usernames and emails never collide, with each other or existing users
+	var duplicates int64
+	db.DB.Raw("SELECT COUNT(*) FROM (SELECT email FROM users GROUP BY email HAVING COUNT(*) > 1)").Scan(&duplicates)
+	if duplicates != 0 {
+		t.Errorf("%d duplicate emails", duplicates)
+	}
+
+	// The same seed gives the same data
+	testdb.Open(t)
+	runSeed(t, "--users", "3", "--seed", "7")
+	var first []models.Todo
+	db.DB.Order("id").Find(&first)
+	testdb.Open(t)
+	runSeed(t, "--users", "3", "--seed", "7")
+	var second []models.Todo
+	db.DB.Order("id").Find(&second)
+	if len(first) != len(second) {
+		t.Fatalf("%d and %d todos from the same seed", len(first), len(second))
+	}
+	for i := range first {
+		if first[i].Item != second[i].Item || first[i].Completed != second[i].Completed {
+			t.Errorf("todo %d differs: %q and %q", i, first[i].Item, second[i].Item)
+		}
+	}
+}
+
+func TestSeedRejectsInvalidFlags(t *testing.T) {
+	testdb.Open(t)
+	for _, args := range [][]string{
+		{"--users", "-1"},
+		{"--todos", "-2"},
+		{"--completed", "1.5"},
+		{"--completed", "-0.1"},
+	} {
+		if _, err := runSeed(t, args...); err == nil {
+			t.Errorf("seed %v succeeded", args)
+		}
+	}
+	var users int64
+	db.DB.Model(&models.User{}).Count(&users)
+	if users != 0 {
+		t.Errorf("%d users created by invalid seed runs", users)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gin-demo-api/auth"
+	"gin-demo-api/config"
+	"gin-demo-api/db"
+	"gin-demo-api/diagnostics"
+	"gin-demo-api/grpcserver"
+	"gin-demo-api/handlers"
+	"gin-demo-api/mail"
+	"gin-demo-api/metrics"
+	"gin-demo-api/ratelimit"
+	"gin-demo-api/router"
+	"gin-demo-api/service"
+	"gin-demo-api/sso"
+	"gin-demo-api/storage"
+	"gin-demo-api/tracing"
+	"gin-demo-api/webhooks"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+func serveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Migrate the database and run the HTTP and gRPC servers",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve()
+		},
+	}
+}
+
+// serve runs the API until a server fails or the process is asked to stop
+func serve() error {
+	cfg := config.Load()
+
+	// Shut down on SIGINT/SIGTERM, so in-flight requests finish and deferred cleanup (flushing spans) runs
+	stop, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	// OpenTelemetry spans for requests, queries and webhook deliveries
+	exporter, err := tracing.NewExporter(context.Background(), cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+	shutdownTracing := tracing.Setup(exporter, cfg.Tracing.ServiceName)
+	defer shutdownTracing(context.Background())
+
+	// 1. Initialize DB connection and run migrations
+	if err := openDatabase(); err != nil {
+		return err
+	}
+	if err := tracing.InstrumentDB(db.DB); err != nil {
+		return fmt.Errorf("failed to instrument the database: %w", err)
+	}
+
+	// Query timings, pool stats and todo counts for GET /metrics
+	if err := metrics.InstrumentDB(db.DB); err != nil {
+		return fmt.Errorf("failed to instrument the database: %w", err)
+	}
+
+	// Blob storage for todo attachments
+	if err := storage.ConnectStorage(cfg.Storage); err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	handlers.AttachmentLimits = cfg.Attachments
+
+	// Email for address verification and password resets
+	if err := mail.ConnectMailer(cfg.Mail); err != nil {
+		return fmt.Errorf("failed to initialize mail: %w", err)
+	}
+	service.PublicURL = cfg.PublicURL
+	if cfg.TokenSecret != "" {
+		auth.TokenSecret = []byte(cfg.TokenSecret)
+	} else {
+		slog.Warn("TOKEN_SECRET is not set; emailed links stop working when the server restarts")
+	}
+
+	// Sign-in with OpenID Connect providers
+	if err := sso.Configure(cfg.OIDC, cfg.PublicURL); err != nil {
+		return fmt.Errorf("failed to configure OIDC: %w", err)
+	}
+	handlers.PublicURL = cfg.PublicURL
+	service.SessionTTL = cfg.SessionTTL
+	auth.SessionCacheTTL = cfg.SessionCache
+	auth.TOTPIssuer = cfg.TwoFactor.Issuer
+	auth.TwoFactorRoles = cfg.TwoFactor.RequiredRoles
+	if auth.TrustUserIDHeader = cfg.DevUserID; cfg.DevUserID {
+		slog.Warn("DEV_TRUST_USER_ID_HEADER is on: anyone can act as any user with X-User-ID; never enable it in production")
+	}
+
+	// Background delivery of queued webhooks, until the process is asked to stop
+	webhooks.AllowPrivateTargets = cfg.Webhooks.AllowPrivateTargets
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		webhooks.NewDispatcher(db.DB).Run(stop)
+	}()
+
+	// gRPC API alongside the HTTP router, sharing the service layer
+	grpcErr := make(chan error, 1)
+	var grpcServer *grpc.Server
+	if cfg.GRPCAddr != "" {
+		// Same limits as the REST API, in buckets of its own
+		limiter, err := ratelimit.FromConfig(cfg.RateLimit)
+		if err != nil {
+			return err
+		}
+		listener, err := net.Listen("tcp", cfg.GRPCAddr)
+		if err != nil {
+			return fmt.Errorf("gRPC server failed: %w", err)
+		}
+		grpcServer = grpcserver.New(limiter)
+		go func() {
+			slog.Info("gRPC server listening", "addr", cfg.GRPCAddr)
+			if err := grpcServer.Serve(listener); err != nil {
+				grpcErr <- fmt.Errorf("gRPC server failed: %w", err)
+			}
+		}()
+	}
+
+	// 4. Start the server
+	handler, err := router.New(cfg)
+	if err != nil {
+		return err
+	}
+	// Request contexts derive from streams, so event streams end when shutdown starts
+	streams, endStreams := context.WithCancel(context.Background())
+	defer endStreams()
+	server := &http.Server{
+		Addr:        cfg.HTTPAddr,
+		Handler:     handler,
+		BaseContext: func(net.Listener) context.Context { return streams },
+	}
+	server.RegisterOnShutdown(endStreams)
+
+	httpErr := make(chan error, 1)
+	go func() {
+		slog.Info("HTTP server listening", "addr", cfg.HTTPAddr)
+		httpErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-grpcErr:
+		return err
+	case err := <-httpErr:
+		return err
+	case <-stop.Done():
+	}
+
+	// Fail /readyz first so load balancers move traffic away, then let in-flight requests finish
+	slog.Info("Shutting down", "delay", cfg.Shutdown.Delay.String(), "timeout", cfg.Shutdown.Timeout.String())
+	diagnostics.Drain()
+	time.Sleep(cfg.Shutdown.Delay)
+
+	ctx, cancelShutdown := context.WithTimeout(context.Background(), cfg.Shutdown.Timeout)
+	defer cancelShutdown()
+	// gRPC stops accepting calls and lets the running ones finish, within the same timeout
+	grpcStopped := make(chan struct{})
+	go func() {
+		defer close(grpcStopped)
+		if grpcServer != nil {
+			grpcServer.GracefulStop()
+		}
+	}()
+	if err := server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("HTTP server did not shut down cleanly: %w", err)
+	}
+	select {
+	case <-dispatched:
+	case <-ctx.Done():
+		return errors.New("webhook dispatcher did not finish its delivery in time")
+	}
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+		return errors.New("gRPC server did not finish its calls in time")
+	}
+	return nil
+}
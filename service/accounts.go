@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"gin-demo-api/audit"
+	"gin-demo-api/auth"
+	"gin-demo-api/db"
+	"gin-demo-api/events"
+	"gin-demo-api/mail"
+	"gin-demo-api/models"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Token purposes and lifetimes of the emailed links
+const (
+	purposeVerifyEmail   = "verify-email"
+	purposeResetPassword = "reset-password"
+	verifyEmailTTL       = 48 * time.Hour
+	resetPasswordTTL     = time.Hour
+)
+
+// Password length limits; bcrypt ignores everything after 72 bytes
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+// PublicURL is the base URL of the API as clients reach it, used for links in emails
+var PublicURL = "http://localhost:8080"
+
+var (
+	// ErrAlreadyVerified is returned when the email address has been verified already
+	ErrAlreadyVerified = errors.New("email address is already verified")
+	// ErrInvalidPassword is returned for passwords outside the length limits
+	ErrInvalidPassword = fmt.Errorf("%w: password must be %d to %d bytes long", ErrInvalidInput, MinPasswordLength, MaxPasswordLength)
+	// ErrInvalidCredentials is returned for an unknown login, a wrong password, or a user without one
+	ErrInvalidCredentials = errors.New("invalid login or password")
+)
+
+// dummyHash is compared against when the login is unknown, so a failed login takes
+// as long whether or not the account exists
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+
+// SendVerification emails the user a link confirming their current email address
+func SendVerification(ctx context.Context, user models.User) error {
+	if user.EmailVerifiedAt != nil {
+		return ErrAlreadyVerified
+	}
+	token := auth.SignToken(purposeVerifyEmail, user.ID, user.Email, verifyEmailTTL)
+	return mail.Send(ctx, user.Email, "verify_email", map[string]interface{}{
+		"Username":  user.Username,
+		"Email":     user.Email,
+		"URL":       PublicURL + "/v1/auth/verify-email?token=" + url.QueryEscape(token),
+		"ExpiresIn": "48 hours",
+	})
+}
+
+// VerifyEmail marks the email address in a verification token as verified. The token
+// stops working once used, or when the user changes their address in the meantime.
+func VerifyEmail(ctx context.Context, token string) (models.User, error) {
+	userID, email, err := auth.VerifyToken(token, purposeVerifyEmail)
+	if err != nil {
+		return models.User{}, err
+	}
+	user, err := GetUser(ctx, userID, false)
+	if err != nil || user.Email != email || user.EmailVerifiedAt != nil {
+		return user, auth.ErrInvalidToken
+	}
+
+	before := user
+	now := time.Now()
+	var changes []events.Event
+	err = db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("email_verified_at", &now).Error; err != nil {
+			return err
+		}
+		// Opening the link proves the user made this change themselves
+		if err := audit.Record(tx, &user.ID, audit.ActionUpdate, "user", user.ID, before, user); err != nil {
+			return err
+		}
+		changes = []events.Event{userEvent(eventUpdated, user)}
+		return logChanges(tx, changes)
+	})
+	if err != nil {
+		return user, err
+	}
+	publish(changes)
+
+	return user, nil
+}
+
+// RequestPasswordReset emails a reset token to the user with this address, matched
+// case-insensitively like logins. Only verified addresses get one, so an address
+// nobody has proven to own cannot take over the account. Unknown and unverified
+// addresses are not an error, so the response does not reveal who has an account.
+func RequestPasswordReset(ctx context.Context, email string) error {
+	var user models.User
+	err := db.DB.WithContext(ctx).Where("LOWER(email) = LOWER(?) AND email_verified_at IS NOT NULL", email).Limit(1).Find(&user).Error
+	if err != nil || user.ID == 0 {
+		return err
+	}
+
+	token := auth.SignToken(purposeResetPassword, user.ID, passwordState(user), resetPasswordTTL)
+	return mail.Send(ctx, user.Email, "reset_password", map[string]interface{}{
+		"Username":  user.Username,
+		"Token":     token,
+		"URL":       PublicURL + "/v1/auth/password-reset/confirm",
+		"ExpiresIn": "1 hour",
+	})
+}
+
+// ResetPassword sets a new password with a reset token. Changing the password
+// invalidates the token and any other reset token sent before.
+func ResetPassword(ctx context.Context, token, password string) error {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return ErrInvalidPassword
+	}
+	userID, state, err := auth.VerifyToken(token, purposeResetPassword)
+	if err != nil {
+		return err
+	}
+	user, err := GetUser(ctx, userID, false)
+	if err != nil || passwordState(user) != state {
+		return auth.ErrInvalidToken
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	// The hash is not part of the user's JSON, so there is no audit diff or change event to record
+	if err := db.DB.WithContext(ctx).Model(&user).Update("password_hash", string(hash)).Error; err != nil {
+		return err
+	}
+	// Whoever knew the old password may still be logged in
+	revoked, err := RevokeUserSessions(ctx, &user.ID, user.ID, 0)
+	if err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "Password reset", "user_id", user.ID, "sessions_revoked", revoked)
+	return nil
+}
+
+// LoginWithPassword signs in the user with this username or email address. Users
+// with two-factor authentication enabled get a challenge instead of a session.
+func LoginWithPassword(ctx context.Context, account, password string, client models.ClientInfo) (models.LoginResult, error) {
+	var user models.User
+	err := db.DB.WithContext(ctx).Where("username = ? OR LOWER(email) = LOWER(?)", account, account).Limit(1).Find(&user).Error
+	if err != nil {
+		return models.LoginResult{}, err
+	}
+
+	hash := dummyHash
+	if user.PasswordHash != "" {
+		hash = []byte(user.PasswordHash)
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil || user.PasswordHash == "" {
+		return models.LoginResult{}, ErrInvalidCredentials
+	}
+	return login(ctx, user, "password", client)
+}
+
+// passwordState changes whenever the password or email address does, which makes reset tokens single-use
+func passwordState(user models.User) string {
+	sum := sha256.Sum256([]byte(user.PasswordHash + "\x00" + user.Email))
+	return hex.EncodeToString(sum[:8])
+}
+
+// sendVerification emails a verification link after a change, logging rather than
+// failing when the mail cannot be sent; the user can ask for another link
+func sendVerification(ctx context.Context, user models.User) {
+	if err := SendVerification(ctx, user); err != nil {
+		slog.WarnContext(ctx, "Failed to send verification email", "user_id", user.ID, "err", err)
+	}
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	netmail "net/mail"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-demo-api/auth"
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/mail"
+	"gin-demo-api/models"
+)
+
+// smtpStandIn is a minimal SMTP server that accepts every message and keeps it
+type smtpStandIn struct {
+	listener net.Listener
+	messages chan string
+}
+
+// startSMTP listens on a free local port until the test ends
+func startSMTP(t *testing.T) *smtpStandIn {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &smtpStandIn{listener: listener, messages: make(chan string, 10)}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+// serve speaks just enough SMTP for net/smtp: no STARTTLS and no AUTH
+func (s *smtpStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP stand-in")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch verb := strings.ToUpper(strings.Fields(line + " x")[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			s.messages <- data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// textBody returns the decoded plain-text part of a sent message
+func textBody(t *testing.T, raw string) string {
+	t.Helper()
+	msg, err := netmail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err != nil {
+			t.Fatalf("no text/plain part: %v", err)
+		}
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain") {
+			body, err := io.ReadAll(part)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(body)
+		}
+	}
+}
+
+// resetTokenPattern finds the token on its own line in the reset email
+var resetTokenPattern = regexp.MustCompile(`(?m)^\s*([\w-]+\.[\w-]+)\s*$`)
+
+func TestPasswordResetByEmail(t *testing.T) {
+	testdb.Open(t)
+	ctx := context.Background()
+	smtp := startSMTP(t)
+	defer func(outbox mail.Mailer) { mail.Outbox = outbox }(mail.Outbox)
+	mail.Outbox = &mail.SMTPMailer{Addr: smtp.listener.Addr().String()}
+
+	user := testdb.CreateUser(t, models.User{Username: "alice", Email: "alice@example.com"})
+	if _, err := LoginWithPassword(ctx, "alice", "anything", models.ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("login without a password: got %v, want ErrInvalidCredentials", err)
+	}
+	session, err := StartSession(ctx, user, "test", false, models.ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Until alice proves she owns the address, nobody gets a reset token for it
+	if err := RequestPasswordReset(ctx, "alice@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case raw := <-smtp.messages:
+		t.Fatalf("reset email sent to an unverified address:\n%s", raw)
+	default:
+	}
+	verified := time.Now()
+	if err := db.DB.Model(&user).Update("email_verified_at", &verified).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Addresses match whatever their case
+	if err := RequestPasswordReset(ctx, "Alice@EXAMPLE.com"); err != nil {
+		t.Fatal(err)
+	}
+	raw := <-smtp.messages
+	if !strings.Contains(raw, "To: alice@example.com") {
+		t.Errorf("message not addressed to alice:\n%s", raw)
+	}
+	match := resetTokenPattern.FindStringSubmatch(textBody(t, raw))
+	if match == nil {
+		t.Fatalf("no token in the email:\n%s", textBody(t, raw))
+	}
+	token := match[1]
+
+	if err := ResetPassword(ctx, token, "short"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("short password: got %v, want ErrInvalidInput", err)
+	}
+	if err := ResetPassword(ctx, token, "correct horse battery"); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if err := ResetPassword(ctx, token, "another good password"); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("reusing the token: got %v, want ErrInvalidToken", err)
+	}
+
+	// The reset logs out every session
+	if _, err := auth.IdentifySession(ctx, session.Token, ""); err == nil {
+		t.Error("session still valid after the password reset")
+	}
+
+	if _, err := LoginWithPassword(ctx, "alice", "wrong password", models.ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong password: got %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := LoginWithPassword(ctx, "nobody", "correct horse battery", models.ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown login: got %v, want ErrInvalidCredentials", err)
+	}
+	result, err := LoginWithPassword(ctx, "ALICE@example.com", "correct horse battery", models.ClientInfo{})
+	if err != nil || result.Token == "" {
+		t.Fatalf("login by email: got %+v, %v", result, err)
+	}
+	if _, err := auth.IdentifySession(ctx, result.Token, ""); err != nil {
+		t.Errorf("new session: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+
+	"gin-demo-api/audit"
+	"gin-demo-api/auth"
+	"gin-demo-api/db"
+	"gin-demo-api/events"
+	"gin-demo-api/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateAPIKey generates a key for the user with the name and scopes of input. The
+// returned record holds the key itself, which is never stored.
+func CreateAPIKey(ctx context.Context, actorID *uint, userID uint, input models.APIKey) (models.APIKey, error) {
+	key, prefix, hash := auth.NewAPIKey()
+	apiKey := models.APIKey{UserID: userID, Name: input.Name, Prefix: prefix, Hash: hash, Scopes: input.Scopes}
+	var changes []events.Event
+	err := db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&apiKey).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, actorID, audit.ActionCreate, "api_key", apiKey.ID, nil, apiKey); err != nil {
+			return err
+		}
+		changes = []events.Event{apiKeyEvent(eventCreated, apiKey)}
+		return logChanges(tx, changes)
+	})
+	if err != nil {
+		return apiKey, err
+	}
+	publish(changes)
+
+	apiKey.Key = key
+	return apiKey, nil
+}
+
+// UpdateAPIKey renames a stored key and replaces its scopes with those of input
+func UpdateAPIKey(ctx context.Context, actorID *uint, apiKey, input models.APIKey) (models.APIKey, error) {
+	apiKey.Key = "" // Never recorded, even when the caller still holds it
+	before := apiKey
+	var changes []events.Event
+	err := db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&apiKey).Updates(models.APIKey{Name: input.Name, Scopes: input.Scopes}).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, actorID, audit.ActionUpdate, "api_key", apiKey.ID, before, apiKey); err != nil {
+			return err
+		}
+		changes = []events.Event{apiKeyEvent(eventUpdated, apiKey)}
+		return logChanges(tx, changes)
+	})
+	if err != nil {
+		return apiKey, err
+	}
+	publish(changes)
+
+	return apiKey, nil
+}
+
+// RevokeAPIKey soft-deletes a key; requests using it are rejected from then on
+func RevokeAPIKey(ctx context.Context, actorID *uint, apiKey models.APIKey) error {
+	apiKey.Key = ""
+	var changes []events.Event
+	err := db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&apiKey).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, actorID, audit.ActionDelete, "api_key", apiKey.ID, apiKey, nil); err != nil {
+			return err
+		}
+		changes = []events.Event{apiKeyEvent(eventDeleted, apiKey)}
+		return logChanges(tx, changes)
+	})
+	if err != nil {
+		return err
+	}
+	publish(changes)
+
+	return nil
+}
+
+// apiKeyEvent describes an API key change
+func apiKeyEvent(action string, apiKey models.APIKey) events.Event {
+	return ownedEvent("api_key", action, apiKey.ID, apiKey.UserID, apiKey)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"encoding/json"
+
+	"gin-demo-api/events"
+	"gin-demo-api/models"
+	"gin-demo-api/webhooks"
+
+	"gorm.io/gorm"
+)
+
+// Event actions published for todos and users
+const (
+	eventCreated   = "created"
+	eventUpdated   = "updated"
+	eventCompleted = "completed"
+	eventDeleted   = "deleted"
+)
+
+// todoEvent describes a todo change
+func todoEvent(action string, todo models.Todo) events.Event {
+	return events.Event{
+		Type:       "todo." + action,
+		EntityType: "todo",
+		EntityID:   todo.ID,
+		UserID:     todo.UserID,
+		Data:       todo,
+	}
+}
+
+// userEvent describes a user change
+func userEvent(action string, user models.User) events.Event {
+	return events.Event{
+		Type:       "user." + action,
+		EntityType: "user",
+		EntityID:   user.ID,
+		UserID:     user.ID,
+		Data:       user,
+	}
+}
+
+// ownedEvent describes a change to a record that concerns only its owner, such as a
+// webhook, API key or session
+func ownedEvent(entityType, action string, id, userID uint, data interface{}) events.Event {
+	return events.Event{
+		Type:       entityType + "." + action,
+		EntityType: entityType,
+		EntityID:   id,
+		UserID:     userID,
+		Data:       data,
+	}
+}
+
+// logChanges appends the events to the change log within tx, assigning their IDs,
+// and queues the matching webhook deliveries
+func logChanges(tx *gorm.DB, changes []events.Event) error {
+	for i, change := range changes {
+		data, err := json.Marshal(change.Data)
+		if err != nil {
+			return err
+		}
+
+		entry := models.ChangeEvent{
+			Type:       change.Type,
+			EntityType: change.EntityType,
+			EntityID:   change.EntityID,
+			UserID:     change.UserID,
+			Data:       string(data),
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+		changes[i].ID = entry.ID
+		changes[i].Time = entry.CreatedAt
+
+		if err := webhooks.Enqueue(tx, changes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publish announces committed changes on the event bus
+func publish(changes []events.Event) {
+	for _, change := range changes {
+		events.Default.Publish(change)
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gin-demo-api/audit"
+	"gin-demo-api/auth"
+	"gin-demo-api/db"
+	"gin-demo-api/events"
+	"gin-demo-api/models"
+	"gin-demo-api/sso"
+
+	"gorm.io/gorm"
+)
+
+// SessionTTL is how long a login stays valid
+var SessionTTL = 30 * 24 * time.Hour
+
+var (
+	// ErrUnverifiedEmail is returned when a new identity's email address is not verified by its provider
+	ErrUnverifiedEmail = errors.New("the identity provider has not verified this email address")
+	// ErrUnverifiedAccount is returned when the user with a new identity's email address has not verified it
+	ErrUnverifiedAccount = errors.New("an account with this email address exists, but has not verified it; verify the address, then sign in again")
+	// ErrSignupDisabled is returned when no user matches a new identity and the provider may not create one
+	ErrSignupDisabled = errors.New("no account has this email address, and sign-up is disabled for this provider")
+)
+
+// usernameChars matches what is dropped when deriving a username from an identity
+var usernameChars = regexp.MustCompile(`[^\w.-]+`)
+
+// LoginWithOIDC signs in the user behind a provider identity. A new identity is linked
+// to the user with the same email address, if both the provider and the user have
+// verified it, or, when signup is allowed, to a newly created user.
+func LoginWithOIDC(ctx context.Context, provider string, claims sso.Claims, signup bool, client models.ClientInfo) (models.LoginResult, error) {
+	var identity models.Identity
+	err := db.DB.WithContext(ctx).Where("provider = ? AND subject = ?", provider, claims.Subject).Limit(1).Find(&identity).Error
+	if err != nil {
+		return models.LoginResult{}, err
+	}
+	if identity.ID != 0 {
+		user, err := GetUser(ctx, identity.UserID, false)
+		if err != nil {
+			return models.LoginResult{}, err
+		}
+		if claims.Email != "" && claims.Email != identity.Email {
+			if err := db.DB.WithContext(ctx).Model(&identity).Update("email", claims.Email).Error; err != nil {
+				return models.LoginResult{}, err
+			}
+		}
+		return login(ctx, user, "oidc:"+provider, client)
+	}
+
+	// Only a verified address may claim an existing account
+	if claims.Email == "" || !claims.EmailVerified {
+		return models.LoginResult{}, ErrUnverifiedEmail
+	}
+	identity = models.Identity{Provider: provider, Subject: claims.Subject, Email: claims.Email}
+
+	var user models.User
+	if err := db.DB.WithContext(ctx).Where("LOWER(email) = LOWER(?)", claims.Email).Limit(1).Find(&user).Error; err != nil {
+		return models.LoginResult{}, err
+	}
+	switch {
+	case user.ID != 0 && user.EmailVerifiedAt == nil:
+		// Anyone can sign up with someone else's address; only its owner may link to the account
+		err = ErrUnverifiedAccount
+	case user.ID != 0:
+		err = linkIdentity(ctx, user, identity)
+	case signup:
+		user, err = provisionUser(ctx, claims, identity)
+	default:
+		err = ErrSignupDisabled
+	}
+	if err != nil {
+		return models.LoginResult{}, err
+	}
+	return login(ctx, user, "oidc:"+provider, client)
+}
+
+// StartSession creates a session for the user on the client's device and returns its
+// bearer token. twoFactor records whether the login was confirmed with a second factor.
+func StartSession(ctx context.Context, user models.User, method string, twoFactor bool, client models.ClientInfo) (models.LoginResult, error) {
+	token, hash := auth.NewSessionToken()
+	now := time.Now()
+	session := models.Session{
+		CreatedAt:    now,
+		UserID:       user.ID,
+		TokenHash:    hash,
+		Method:       method,
+		TwoFactor:    twoFactor,
+		ExpiresAt:    now.Add(SessionTTL),
+		UserAgent:    client.UserAgent,
+		Device:       auth.DescribeDevice(client.UserAgent),
+		IP:           client.IP,
+		LastActiveAt: now,
+	}
+	if err := db.DB.WithContext(ctx).Create(&session).Error; err != nil {
+		return models.LoginResult{}, err
+	}
+	return models.LoginResult{Token: token, ExpiresAt: session.ExpiresAt, User: &user}, nil
+}
+
+// login finishes a first-factor login: with a session, or with a challenge to
+// confirm with a code when the user has two-factor authentication enabled
+func login(ctx context.Context, user models.User, method string, client models.ClientInfo) (models.LoginResult, error) {
+	if user.TwoFactorEnabledAt == nil {
+		return StartSession(ctx, user, method, false, client)
+	}
+	challenge, err := newTwoFactorChallenge(ctx, user, method)
+	if err != nil {
+		return models.LoginResult{}, err
+	}
+	return models.LoginResult{
+		TwoFactorRequired: true,
+		Challenge:         challenge,
+		ExpiresAt:         time.Now().Add(twoFactorLoginTTL),
+	}, nil
+}
+
+// linkIdentity attaches a new identity to an existing user
+func linkIdentity(ctx context.Context, user models.User, identity models.Identity) error {
+	identity.UserID = user.ID
+	return db.DB.WithContext(ctx).Create(&identity).Error
+}
+
+// provisionUser creates a user for a new identity, with its email already verified
+func provisionUser(ctx context.Context, claims sso.Claims, identity models.Identity) (models.User, error) {
+	now := time.Now()
+	user := models.User{Email: claims.Email, Role: models.RoleUser, EmailVerifiedAt: &now}
+	var changes []events.Event
+	err := db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		username, err := freeUsername(tx, claims)
+		if err != nil {
+			return err
+		}
+		user.Username = username
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		if err := tx.Create(&identity).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, nil, audit.ActionCreate, "user", user.ID, nil, user); err != nil {
+			return err
+		}
+		changes = []events.Event{userEvent(eventCreated, user)}
+		return logChanges(tx, changes)
+	})
+	if err != nil {
+		return user, err
+	}
+	publish(changes)
+	return user, nil
+}
+
+// freeUsername derives a username from the identity's preferred username or email,
+// adding a number when it is taken (by deleted users too, as usernames stay unique)
+func freeUsername(tx *gorm.DB, claims sso.Claims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	if base = strings.Trim(usernameChars.ReplaceAllString(base, "_"), "_"); base == "" {
+		base = "user"
+	}
+
+	for i := 1; ; i++ {
+		candidate := base
+		if i > 1 {
+			candidate = fmt.Sprintf("%s%d", base, i)
+		}
+		var count int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+	"gin-demo-api/sso"
+)
+
+func TestLoginWithOIDCLinksOnlyVerifiedAccounts(t *testing.T) {
+	testdb.Open(t)
+	ctx := context.Background()
+	now := time.Now()
+	verified := testdb.CreateUser(t, models.User{Username: "alice", Email: "alice@example.com", EmailVerifiedAt: &now})
+	unverified := testdb.CreateUser(t, models.User{Username: "bob", Email: "bob@example.com"})
+
+	// An attacker's provider account with bob's address must not take over bob's account
+	_, err := LoginWithOIDC(ctx, "mock", sso.Claims{Subject: "attacker", Email: "BOB@example.com", EmailVerified: true}, true, models.ClientInfo{})
+	if !errors.Is(err, ErrUnverifiedAccount) {
+		t.Fatalf("unverified account: got %v, want ErrUnverifiedAccount", err)
+	}
+	var count int64
+	db.DB.Model(&models.Identity{}).Where("user_id = ?", unverified.ID).Count(&count)
+	user, _ := GetUser(ctx, unverified.ID, false)
+	if count != 0 || user.EmailVerifiedAt != nil {
+		t.Errorf("unverified account changed: %d identities, email_verified_at %v", count, user.EmailVerifiedAt)
+	}
+
+	result, err := LoginWithOIDC(ctx, "mock", sso.Claims{Subject: "alice-sub", Email: "alice@example.com", EmailVerified: true}, false, models.ClientInfo{})
+	if err != nil || result.User == nil || result.User.ID != verified.ID {
+		t.Fatalf("verified account: got %+v, %v", result, err)
+	}
+
+	// Once linked, the identity signs in by its subject whatever its email
+	result, err = LoginWithOIDC(ctx, "mock", sso.Claims{Subject: "alice-sub", Email: "alice@new.example.com"}, false, models.ClientInfo{})
+	if err != nil || result.User.ID != verified.ID {
+		t.Errorf("linked identity: got %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,25 @@
+// Package service holds the todo and user operations shared by the REST
+// handlers, GraphQL and any other transport. Every write records its audit
+// event and change log entry in the same transaction and publishes the change
+// on the event bus once committed.
+package service
+
+import (
+	"errors"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidUser is returned when a todo references a user that does not exist
+	ErrInvalidUser = errors.New("invalid user ID")
+	// ErrInvalidInput wraps validation failures
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrUnauthenticated is returned when an operation needs to know who the caller is
+	ErrUnauthenticated = errors.New("authentication required")
+	// ErrForbidden is returned when the caller may not change another user's account
+	ErrForbidden = errors.New("only the user or an admin can change this account")
+)
+
+// MaxDescriptionLength is the longest todo description accepted, in characters
+const MaxDescriptionLength = 20000
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+
+	"gin-demo-api/audit"
+	"gin-demo-api/auth"
+	"gin-demo-api/db"
+	"gin-demo-api/events"
+	"gin-demo-api/models"
+
+	"gorm.io/gorm"
+)
+
+// RevokeSession logs out one of the user's sessions, reporting whether it existed
+func RevokeSession(ctx context.Context, actorID *uint, userID, id uint) (bool, error) {
+	revoked, err := revokeSessions(ctx, actorID, "id = ? AND user_id = ?", id, userID)
+	return revoked > 0, err
+}
+
+// RevokeUserSessions logs out all of the user's sessions except keep (0 keeps none),
+// returning how many there were
+func RevokeUserSessions(ctx context.Context, actorID *uint, userID, keep uint) (int64, error) {
+	return revokeSessions(ctx, actorID, "user_id = ? AND id <> ?", userID, keep)
+}
+
+// revokeSessions deletes the sessions matching the condition, recording each one
+func revokeSessions(ctx context.Context, actorID *uint, condition string, args ...interface{}) (int64, error) {
+	var sessions []models.Session
+	var changes []events.Event
+	err := db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(condition, args...).Find(&sessions).Error; err != nil {
+			return err
+		}
+		for _, session := range sessions {
+			if err := tx.Delete(&session).Error; err != nil {
+				return err
+			}
+			if err := audit.Record(tx, actorID, audit.ActionDelete, "session", session.ID, session, nil); err != nil {
+				return err
+			}
+			changes = append(changes, ownedEvent("session", eventDeleted, session.ID, session.UserID, session))
+		}
+		return logChanges(tx, changes)
+	})
+	if err != nil {
+		return 0, err
+	}
+	publish(changes)
+	for _, session := range sessions {
+		auth.ForgetUserSessions(session.UserID) // Revoked tokens stop working on this server at once
+	}
+
+	return int64(len(sessions)), nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+)
+
+func TestWebhookChangesAreAuditedWithoutSecrets(t *testing.T) {
+	testdb.Open(t)
+	ctx := context.Background()
+	alice := testdb.CreateUser(t, models.User{Username: "alice"})
+	active := true
+
+	hook, err := CreateWebhook(ctx, &alice.ID, models.Webhook{UserID: alice.ID, URL: "https://example.com/a", Events: models.StringList{"*"}, Secret: "whsec_first", Active: &active})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := hook
+	input.Secret = "whsec_second"
+	if hook, err = UpdateWebhook(ctx, &alice.ID, hook, input); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteWebhook(ctx, &alice.ID, hook); err != nil {
+		t.Fatal(err)
+	}
+
+	var trail []models.AuditEvent
+	db.DB.Where("entity_type = ? AND entity_id = ?", "webhook", hook.ID).Order("id").Find(&trail)
+	if len(trail) != 3 || trail[0].Action != "create" || trail[1].Action != "update" || trail[2].Action != "delete" {
+		t.Fatalf("audit trail = %+v, want create, update and delete", trail)
+	}
+	secret := trail[1].Changes["secret"]
+	if secret.Before == nil || secret.Before == secret.After {
+		t.Errorf("secret change = %+v, want two different fingerprints", secret)
+	}
+	if *trail[1].ActorID != alice.ID || len(trail[1].Changes) != 1 {
+		t.Errorf("update = %+v, want only alice's change of the secret", trail[1])
+	}
+
+	var changes []models.ChangeEvent
+	db.DB.Where("entity_type = ?", "webhook").Order("id").Find(&changes)
+	if len(changes) != 3 || changes[0].Type != "webhook.created" || changes[2].Type != "webhook.deleted" || changes[0].UserID != alice.ID {
+		t.Fatalf("change log = %+v, want alice's three webhook changes", changes)
+	}
+
+	var stored []string
+	db.DB.Model(&models.AuditEvent{}).Pluck("changes", &stored)
+	db.DB.Model(&models.ChangeEvent{}).Pluck("data", &stored)
+	for _, text := range stored {
+		if strings.Contains(text, "whsec_") {
+			t.Errorf("a secret was recorded: %s", text)
+		}
+	}
+}
+
+func TestAPIKeyChangesAreAudited(t *testing.T) {
+	testdb.Open(t)
+	ctx := context.Background()
+	alice := testdb.CreateUser(t, models.User{Username: "alice"})
+
+	apiKey, err := CreateAPIKey(ctx, &alice.ID, alice.ID, models.APIKey{Name: "CI", Scopes: models.StringList{models.ScopeAll}})
+	if err != nil || apiKey.Key == "" {
+		t.Fatalf("CreateAPIKey: %+v, %v; want a key", apiKey, err)
+	}
+	key := apiKey.Key
+	if apiKey, err = UpdateAPIKey(ctx, &alice.ID, apiKey, models.APIKey{Name: "Nightly CI", Scopes: apiKey.Scopes}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RevokeAPIKey(ctx, &alice.ID, apiKey); err != nil {
+		t.Fatal(err)
+	}
+
+	var trail []models.AuditEvent
+	db.DB.Where("entity_type = ? AND entity_id = ?", "api_key", apiKey.ID).Order("id").Find(&trail)
+	if len(trail) != 3 || trail[1].Changes["name"] != (models.FieldChange{Before: "CI", After: "Nightly CI"}) || trail[2].Action != "delete" {
+		t.Fatalf("audit trail = %+v, want the creation, the rename and the revocation", trail)
+	}
+	var stored []string
+	db.DB.Model(&models.AuditEvent{}).Pluck("changes", &stored)
+	db.DB.Model(&models.ChangeEvent{}).Pluck("data", &stored)
+	for _, text := range stored {
+		if strings.Contains(text, key) {
+			t.Errorf("the key was recorded: %s", text)
+		}
+	}
+}
+
+func TestRevokedSessionsAreAudited(t *testing.T) {
+	testdb.Open(t)
+	ctx := context.Background()
+	alice := testdb.CreateUser(t, models.User{Username: "alice"})
+	bob := testdb.CreateUser(t, models.User{Username: "bob"})
+	_, first := testdb.Session(t, alice.ID, false)
+	_, second := testdb.Session(t, alice.ID, false)
+	_, current := testdb.Session(t, alice.ID, false)
+	_, bobs := testdb.Session(t, bob.ID, false)
+
+	if found, err := RevokeSession(ctx, &alice.ID, alice.ID, bobs.ID); err != nil || found {
+		t.Errorf("revoking bob's session as alice: %v, %v; want not found", found, err)
+	}
+	if found, err := RevokeSession(ctx, &alice.ID, alice.ID, first.ID); err != nil || !found {
+		t.Fatalf("RevokeSession: %v, %v", found, err)
+	}
+	if revoked, err := RevokeUserSessions(ctx, &alice.ID, alice.ID, current.ID); err != nil || revoked != 1 {
+		t.Fatalf("RevokeUserSessions: %d, %v; want the second session", revoked, err)
+	}
+
+	var revokedIDs []uint
+	db.DB.Model(&models.AuditEvent{}).Where("entity_type = ? AND action = ? AND actor_id = ?", "session", "delete", alice.ID).Order("entity_id").Pluck("entity_id", &revokedIDs)
+	if len(revokedIDs) != 2 || revokedIDs[0] != first.ID || revokedIDs[1] != second.ID {
+		t.Errorf("audited session revocations = %v, want %d and %d", revokedIDs, first.ID, second.ID)
+	}
+	var logged int64
+	db.DB.Model(&models.ChangeEvent{}).Where("type = ? AND user_id = ?", "session.deleted", alice.ID).Count(&logged)
+	if logged != 2 {
+		t.Errorf("change log has %d session.deleted events, want 2", logged)
+	}
+	var left []uint
+	db.DB.Model(&models.Session{}).Order("id").Pluck("id", &left)
+	if len(left) != 2 || left[0] != current.ID || left[1] != bobs.ID {
+		t.Errorf("sessions left = %v, want alice's current one and bob's", left)
+	}
+}
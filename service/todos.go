@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"gin-demo-api/audit"
+	"gin-demo-api/db"
+	"gin-demo-api/events"
+	"gin-demo-api/models"
+
+	"gorm.io/gorm"
+)
+
+// MaxTodoPage is the most todos ListTodos returns for a Limit; larger limits are lowered to it
+const MaxTodoPage = 500
+
+// TodoFilter narrows ListTodos; zero values mean no restriction
+type TodoFilter struct {
+	UserIDs   []uint
+	Completed *bool
+	Limit     int
+	Offset    int
+}
+
+// ListTodos returns the todos matching the filter, ordered by ID. Limit is capped at
+// MaxTodoPage, for every transport alike.
+func ListTodos(ctx context.Context, filter TodoFilter) ([]models.Todo, error) {
+	query := db.DB.WithContext(ctx).Order("id")
+	if len(filter.UserIDs) > 0 {
+		query = query.Where("user_id IN ?", filter.UserIDs)
+	}
+	if filter.Completed != nil {
+		query = query.Where("completed = ?", *filter.Completed)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(min(filter.Limit, MaxTodoPage))
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	todos := []models.Todo{}
+	err := query.Find(&todos).Error
+	return todos, err
+}
+
+// ListTodosByUser returns the todos of each user in filter.UserIDs, ordered by ID.
+// Limit and Offset page each user's todos separately, in one query; Limit is capped at
+// MaxTodoPage like ListTodos.
+func ListTodosByUser(ctx context.Context, filter TodoFilter) (map[uint][]models.Todo, error) {
+	filter.Limit = min(filter.Limit, MaxTodoPage)
+	ranked := db.DB.WithContext(ctx).Model(&models.Todo{}).
+		Select("todos.*, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY id) AS position").
+		Where("user_id IN ?", filter.UserIDs)
+	if filter.Completed != nil {
+		ranked = ranked.Where("completed = ?", *filter.Completed)
+	}
+
+	query := db.DB.WithContext(ctx).Table("(?) AS todos", ranked).Where("position > ?", filter.Offset).Order("id")
+	if filter.Limit > 0 {
+		query = query.Where("position <= ?", filter.Offset+filter.Limit)
+	}
+
+	var todos []models.Todo
+	if err := query.Find(&todos).Error; err != nil {
+		return nil, err
+	}
+	byUser := map[uint][]models.Todo{}
+	for _, todo := range todos {
+		byUser[todo.UserID] = append(byUser[todo.UserID], todo)
+	}
+	return byUser, nil
+}
+
+// GetTodo returns the todo with the given ID
+func GetTodo(ctx context.Context, id uint) (models.Todo, error) {
+	var todo models.Todo
+	err := db.DB.WithContext(ctx).Where("id = ?", id).First(&todo).Error
+	return todo, notFound(err)
+}
+
+// CreateTodo saves a new todo for an existing user
+func CreateTodo(ctx context.Context, actorID *uint, input models.Todo) (models.Todo, error) {
+	if utf8.RuneCountInString(input.Description) > MaxDescriptionLength {
+		return input, fmt.Errorf("%w: description exceeds %d characters", ErrInvalidInput, MaxDescriptionLength)
+	}
+
+	// Validate UserID exists before creating todo
+	var user models.User
+	if err := db.DB.WithContext(ctx).First(&user, input.UserID).Error; err != nil {
+		return input, ErrInvalidUser
+	}
+
+	input.ID = 0
+	var changes []events.Event
+	err := db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&input).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, actorID, audit.ActionCreate, "todo", input.ID, nil, input); err != nil {
+			return err
+		}
+		changes = []events.Event{todoEvent(eventCreated, input)}
+		return logChanges(tx, changes)
+	})
+	if err != nil {
+		return input, err
+	}
+	publish(changes)
+
+	return input, nil
+}
+
+// TodoChanges lists the fields to change in UpdateTodo; nil fields are left unchanged
+type TodoChanges struct {
+	Item        *string `json:"item"`
+	Description *string `json:"description" binding:"omitempty,max=20000"`
+	Completed   *bool   `json:"completed"`
+	UserID      *uint   `json:"user_id"`
+}
+
+// UpdateTodo applies the given changes to the todo
+func UpdateTodo(ctx context.Context, actorID *uint, id uint, changes TodoChanges) (models.Todo, error) {
+	todo, err := GetTodo(ctx, id)
+	if err != nil {
+		return todo, err
+	}
+	if changes.Description != nil && utf8.RuneCountInString(*changes.Description) > MaxDescriptionLength {
+		return todo, fmt.Errorf("%w: description exceeds %d characters", ErrInvalidInput, MaxDescriptionLength)
+	}
+	if changes.UserID != nil {
+		var user models.User
+		if err := db.DB.WithContext(ctx).First(&user, *changes.UserID).Error; err != nil {
+			return todo, ErrInvalidUser
+		}
+	}
+
+	updates := map[string]interface{}{}
+	if changes.Item != nil {
+		updates["item"] = *changes.Item
+	}
+	if changes.Description != nil {
+		updates["description"] = *changes.Description
+	}
+	if changes.Completed != nil {
+		updates["completed"] = *changes.Completed
+	}
+	if changes.UserID != nil {
+		updates["user_id"] = *changes.UserID
+	}
+
+	before := todo
+	var published []events.Event
+	err = db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := tx.Model(&todo).Updates(updates).Error; err != nil {
+				return err
+			}
+			// Reload so the returned todo reflects the stored values
+			if err := tx.First(&todo, todo.ID).Error; err != nil {
+				return err
+			}
+		}
+		if err := audit.Record(tx, actorID, audit.ActionUpdate, "todo", todo.ID, before, todo); err != nil {
+			return err
+		}
+		published = []events.Event{todoEvent(eventUpdated, todo)}
+		if !before.Completed && todo.Completed {
+			published = append(published, todoEvent(eventCompleted, todo))
+		}
+		return logChanges(tx, published)
+	})
+	if err != nil {
+		return todo, err
+	}
+	publish(published)
+
+	return todo, nil
+}
+
+// DeleteTodo soft-deletes the todo and returns its final state
+func DeleteTodo(ctx context.Context, actorID *uint, id uint) (models.Todo, error) {
+	todo, err := GetTodo(ctx, id)
+	if err != nil {
+		return todo, err
+	}
+
+	var changes []events.Event
+	err = db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&todo).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, actorID, audit.ActionDelete, "todo", todo.ID, todo, nil); err != nil {
+			return err
+		}
+		changes = []events.Event{todoEvent(eventDeleted, todo)}
+		return logChanges(tx, changes)
+	})
+	if err != nil {
+		return todo, err
+	}
+	publish(changes)
+
+	return todo, nil
+}
+
+// notFound maps GORM's record-not-found error to ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
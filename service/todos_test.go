@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+)
+
+func TestListTodosCapsTheLimit(t *testing.T) {
+	testdb.Open(t)
+	ctx := context.Background()
+	alice := testdb.CreateUser(t, models.User{Username: "alice"})
+	todos := make([]models.Todo, MaxTodoPage+1)
+	for i := range todos {
+		todos[i] = models.Todo{Item: "todo", UserID: alice.ID}
+	}
+	if err := db.DB.CreateInBatches(&todos, 100).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		limit, want int
+	}{
+		{0, MaxTodoPage + 1},
+		{10, 10},
+		{MaxTodoPage + 100, MaxTodoPage},
+	}
+	for _, tt := range tests {
+		listed, err := ListTodos(ctx, TodoFilter{Limit: tt.limit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(listed) != tt.want {
+			t.Errorf("limit %d: got %d todos, want %d", tt.limit, len(listed), tt.want)
+		}
+		byUser, err := ListTodosByUser(ctx, TodoFilter{UserIDs: []uint{alice.ID}, Limit: tt.limit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(byUser[alice.ID]); got != tt.want {
+			t.Errorf("limit %d by user: got %d todos, want %d", tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"gin-demo-api/audit"
+	"gin-demo-api/auth"
+	"gin-demo-api/db"
+	"gin-demo-api/events"
+	"gin-demo-api/models"
+
+	"gorm.io/gorm"
+)
+
+// Token purpose and lifetime of the challenge between a login and its second factor
+const (
+	purposeTwoFactorLogin = "2fa-login"
+	twoFactorLoginTTL     = 5 * time.Minute
+)
+
+// recoveryCodeCount is how many recovery codes are generated at a time
+const recoveryCodeCount = 10
+
+// Limits on guessing codes: a login challenge takes a few wrong codes, and a user a few
+// more in a row across challenges and settings before codes are refused for a while
+const (
+	maxChallengeFailures = 3
+	maxTwoFactorFailures = 10
+	twoFactorLockout     = 15 * time.Minute
+)
+
+var (
+	// ErrTwoFactorEnabled is returned when enrolling a user who already has two-factor authentication
+	ErrTwoFactorEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorNotEnabled is returned when the user has not set up two-factor authentication
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not set up")
+	// ErrTwoFactorRequired is returned when the policy does not let the user's role disable two-factor authentication
+	ErrTwoFactorRequired = errors.New("two-factor authentication is required for this role")
+	// ErrInvalidCode is returned for wrong, expired and already used codes
+	ErrInvalidCode = errors.New("invalid or already used code")
+	// ErrTwoFactorLocked is returned while codes are refused after too many wrong ones
+	ErrTwoFactorLocked = errors.New("too many wrong codes; try again later")
+)
+
+// GetTwoFactorStatus reports whether the user has two-factor authentication enabled
+// and how many recovery codes they have left
+func GetTwoFactorStatus(ctx context.Context, userID uint) (models.TwoFactorStatus, error) {
+	user, err := GetUser(ctx, userID, false)
+	if err != nil {
+		return models.TwoFactorStatus{}, err
+	}
+	status := models.TwoFactorStatus{
+		Enabled:   user.TwoFactorEnabledAt != nil,
+		EnabledAt: user.TwoFactorEnabledAt,
+		Required:  auth.RequiresTwoFactor(user.Role),
+	}
+	var left int64
+	err = db.DB.WithContext(ctx).Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&left).Error
+	status.RecoveryCodesLeft = int(left)
+	return status, err
+}
+
+// EnrollTwoFactor generates a new TOTP secret for the user. It takes effect once
+// ActivateTwoFactor confirms the authenticator app produces matching codes.
+func EnrollTwoFactor(ctx context.Context, userID uint) (models.TwoFactorEnrollment, error) {
+	user, err := GetUser(ctx, userID, false)
+	if err != nil {
+		return models.TwoFactorEnrollment{}, err
+	}
+	if user.TwoFactorEnabledAt != nil {
+		return models.TwoFactorEnrollment{}, ErrTwoFactorEnabled
+	}
+
+	secret := auth.NewTOTPSecret()
+	if err := db.DB.WithContext(ctx).Model(&user).Update("totp_secret", secret).Error; err != nil {
+		return models.TwoFactorEnrollment{}, err
+	}
+	return models.TwoFactorEnrollment{Secret: secret, OTPAuthURL: auth.TOTPURL(user.Email, secret)}, nil
+}
+
+// ActivateTwoFactor enables two-factor authentication with a code from the enrolled
+// secret and returns the first recovery codes. The session the code was sent with,
+// if any, counts as confirmed with a second factor from then on.
+func ActivateTwoFactor(ctx context.Context, userID, sessionID uint, code string) (models.RecoveryCodes, error) {
+	user, err := GetUser(ctx, userID, false)
+	if err != nil {
+		return models.RecoveryCodes{}, err
+	}
+	if user.TwoFactorEnabledAt != nil {
+		return models.RecoveryCodes{}, ErrTwoFactorEnabled
+	}
+	if user.TOTPSecret == "" {
+		return models.RecoveryCodes{}, ErrTwoFactorNotEnabled
+	}
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return models.RecoveryCodes{}, ErrInvalidCode
+	}
+
+	before := user
+	now := time.Now()
+	var codes []string
+	var changes []events.Event
+	err = db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{"two_factor_enabled_at": &now, "totp_last_step": step}).Error; err != nil {
+			return err
+		}
+		user.TwoFactorEnabledAt = &now
+		if codes, err = replaceRecoveryCodes(tx, user.ID); err != nil {
+			return err
+		}
+		if sessionID != 0 {
+			if err := tx.Model(&models.Session{}).Where("id = ? AND user_id = ?", sessionID, user.ID).Update("two_factor", true).Error; err != nil {
+				return err
+			}
+		}
+		if err := audit.Record(tx, &user.ID, audit.ActionUpdate, "user", user.ID, before, user); err != nil {
+			return err
+		}
+		changes = []events.Event{userEvent(eventUpdated, user)}
+		return logChanges(tx, changes)
+	})
+	if err != nil {
+		return models.RecoveryCodes{}, err
+	}
+	publish(changes)
+	auth.ForgetUserSessions(user.ID) // The confirmed session is cached without its second factor
+
+	return models.RecoveryCodes{Codes: codes}, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off, confirmed with a current code
+// or a recovery code. Roles the policy requires it for cannot turn it off.
+func DisableTwoFactor(ctx context.Context, userID uint, code string) error {
+	user, err := GetUser(ctx, userID, false)
+	if err != nil {
+		return err
+	}
+	if user.TwoFactorEnabledAt == nil {
+		return ErrTwoFactorNotEnabled
+	}
+	if auth.RequiresTwoFactor(user.Role) {
+		return ErrTwoFactorRequired
+	}
+
+	before := user
+	var changes []events.Event
+	err = withSecondFactor(ctx, user, code, func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{"two_factor_enabled_at": nil, "totp_secret": "", "totp_last_step": 0}).Error; err != nil {
+			return err
+		}
+		user.TwoFactorEnabledAt = nil
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, &user.ID, audit.ActionUpdate, "user", user.ID, before, user); err != nil {
+			return err
+		}
+		changes = []events.Event{userEvent(eventUpdated, user)}
+		return logChanges(tx, changes)
+	})
+	if err != nil {
+		return err
+	}
+	publish(changes)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes, confirmed with a current
+// code or a recovery code
+func RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) (models.RecoveryCodes, error) {
+	user, err := GetUser(ctx, userID, false)
+	if err != nil {
+		return models.RecoveryCodes{}, err
+	}
+	if user.TwoFactorEnabledAt == nil {
+		return models.RecoveryCodes{}, ErrTwoFactorNotEnabled
+	}
+
+	var codes []string
+	err = withSecondFactor(ctx, user, code, func(tx *gorm.DB) error {
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return models.RecoveryCodes{}, err
+	}
+	// The codes are not part of the user's JSON, so there is no audit diff or change event to record
+	slog.InfoContext(ctx, "Recovery codes regenerated", "user_id", user.ID)
+	return models.RecoveryCodes{Codes: codes}, nil
+}
+
+// VerifyTwoFactorLogin completes a login challenge with a code and starts the session.
+// A challenge works once, and is given up after a few wrong codes.
+func VerifyTwoFactorLogin(ctx context.Context, challenge, code string, client models.ClientInfo) (models.LoginResult, error) {
+	userID, state, err := auth.VerifyToken(challenge, purposeTwoFactorLogin)
+	if err != nil {
+		return models.LoginResult{}, err
+	}
+	var pending models.TwoFactorChallenge
+	err = db.DB.WithContext(ctx).Where("id = ? AND user_id = ?", state, userID).Limit(1).Find(&pending).Error
+	if err != nil {
+		return models.LoginResult{}, err
+	}
+	if pending.ID == 0 || pending.UsedAt != nil || pending.Failures >= maxChallengeFailures {
+		return models.LoginResult{}, auth.ErrInvalidToken
+	}
+	user, err := GetUser(ctx, userID, false)
+	if err != nil || user.TwoFactorEnabledAt == nil {
+		return models.LoginResult{}, auth.ErrInvalidToken
+	}
+
+	err = withSecondFactor(ctx, user, code, func(tx *gorm.DB) error {
+		// Conditional, so of two requests racing with one challenge only one succeeds
+		result := tx.Model(&pending).Where("used_at IS NULL").Update("used_at", time.Now())
+		if result.Error == nil && result.RowsAffected == 0 {
+			return auth.ErrInvalidToken
+		}
+		return result.Error
+	})
+	if errors.Is(err, ErrInvalidCode) {
+		if err := db.DB.WithContext(ctx).Model(&pending).UpdateColumn("failures", gorm.Expr("failures + 1")).Error; err != nil {
+			return models.LoginResult{}, err
+		}
+	}
+	if err != nil {
+		return models.LoginResult{}, err
+	}
+	return StartSession(ctx, user, pending.Method, true, client)
+}
+
+// newTwoFactorChallenge stores a pending login for the user and signs its token.
+// The user's expired challenges are dropped on the way.
+func newTwoFactorChallenge(ctx context.Context, user models.User, method string) (string, error) {
+	now := time.Now()
+	pending := models.TwoFactorChallenge{UserID: user.ID, Method: method, ExpiresAt: now.Add(twoFactorLoginTTL)}
+	err := db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND expires_at < ?", user.ID, now).Delete(&models.TwoFactorChallenge{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&pending).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return auth.SignToken(purposeTwoFactorLogin, user.ID, strconv.FormatUint(uint64(pending.ID), 10), twoFactorLoginTTL), nil
+}
+
+// withSecondFactor verifies code as the user's second factor, then runs fn in the
+// same transaction. Wrong codes are counted outside it, so they stick, and too many
+// in a row refuse every code for a while.
+func withSecondFactor(ctx context.Context, user models.User, code string, fn func(tx *gorm.DB) error) error {
+	if user.TwoFactorLockedUntil != nil && time.Now().Before(*user.TwoFactorLockedUntil) {
+		return ErrTwoFactorLocked
+	}
+	err := db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := verifySecondFactor(ctx, tx, user, code); err != nil {
+			return err
+		}
+		if user.TwoFactorFailures > 0 {
+			if err := tx.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumn("two_factor_failures", 0).Error; err != nil {
+				return err
+			}
+		}
+		return fn(tx)
+	})
+	if errors.Is(err, ErrInvalidCode) {
+		if err := recordTwoFactorFailure(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// recordTwoFactorFailure counts a wrong code, and locks the user's codes once there
+// have been too many in a row
+func recordTwoFactorFailure(ctx context.Context, userID uint) error {
+	query := db.DB.WithContext(ctx).Model(&models.User{})
+	if err := query.Where("id = ?", userID).UpdateColumn("two_factor_failures", gorm.Expr("two_factor_failures + 1")).Error; err != nil {
+		return err
+	}
+	until := time.Now().Add(twoFactorLockout)
+	result := db.DB.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND two_factor_failures >= ?", userID, maxTwoFactorFailures).
+		UpdateColumns(map[string]interface{}{"two_factor_failures": 0, "two_factor_locked_until": until})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		slog.WarnContext(ctx, "Two-factor codes locked after repeated wrong ones", "user_id", userID, "until", until)
+	}
+	return nil
+}
+
+// verifySecondFactor accepts a TOTP code or an unused recovery code, and uses it up
+func verifySecondFactor(ctx context.Context, tx *gorm.DB, user models.User, code string) error {
+	if step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep); ok {
+		// Conditional, so of two requests racing with the same code only one succeeds
+		result := tx.Model(&models.User{}).Where("id = ? AND totp_last_step < ?", user.ID, step).Update("totp_last_step", step)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidCode
+		}
+		return nil
+	}
+
+	result := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND hash = ? AND used_at IS NULL", user.ID, auth.HashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidCode
+	}
+	slog.InfoContext(ctx, "Recovery code used", "user_id", user.ID)
+	return nil
+}
+
+// replaceRecoveryCodes deletes the user's recovery codes and stores new ones, returning them
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code, hash := auth.NewRecoveryCode()
+		codes[i] = code
+		records[i] = models.RecoveryCode{UserID: userID, Hash: hash}
+	}
+	return codes, tx.Create(&records).Error
+}
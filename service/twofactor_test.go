@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-demo-api/auth"
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// totp computes the code an authenticator app shows for secret, steps periods from now
+func totp(secret string, steps int64) string {
+	key, _ := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	mac := hmac.New(sha1.New, key)
+	binary.Write(mac, binary.BigEndian, time.Now().Unix()/30+steps)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:])&0x7fffffff)%1_000_000)
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	testdb.Open(t)
+	ctx := context.Background()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	user := testdb.CreateUser(t, models.User{Username: "alice", Email: "alice@example.com", PasswordHash: string(hash)})
+
+	enrollment, err := EnrollTwoFactor(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Until it is activated, logins do not ask for a code
+	if result, err := LoginWithPassword(ctx, "alice", "correct horse", models.ClientInfo{}); err != nil || result.Token == "" {
+		t.Fatalf("login before activation: %+v, %v; want a session", result, err)
+	}
+	wrong := "000000"
+	for wrong == totp(enrollment.Secret, -1) || wrong == totp(enrollment.Secret, 0) || wrong == totp(enrollment.Secret, 1) {
+		wrong = "111111"
+	}
+	if _, err := ActivateTwoFactor(ctx, user.ID, 0, wrong); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("activating with a wrong code: got %v, want ErrInvalidCode", err)
+	}
+	activationCode := totp(enrollment.Secret, 0)
+	recovery, err := ActivateTwoFactor(ctx, user.ID, 0, activationCode)
+	if err != nil || len(recovery.Codes) != recoveryCodeCount {
+		t.Fatalf("ActivateTwoFactor = %+v, %v", recovery, err)
+	}
+	if _, err := EnrollTwoFactor(ctx, user.ID); !errors.Is(err, ErrTwoFactorEnabled) {
+		t.Errorf("enrolling again: got %v, want ErrTwoFactorEnabled", err)
+	}
+
+	// The password alone now only gets a challenge
+	login := func() string {
+		t.Helper()
+		result, err := LoginWithPassword(ctx, "alice@example.com", "correct horse", models.ClientInfo{})
+		if err != nil || !result.TwoFactorRequired || result.Challenge == "" || result.Token != "" {
+			t.Fatalf("password login: %+v, %v; want a challenge", result, err)
+		}
+		return result.Challenge
+	}
+	challenge := login()
+
+	// The code used for activation cannot be used again
+	if _, err := VerifyTwoFactorLogin(ctx, challenge, activationCode, models.ClientInfo{}); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("replayed activation code: got %v, want ErrInvalidCode", err)
+	}
+	// The next code, one step early, is accepted once
+	next := totp(enrollment.Secret, 1)
+	result, err := VerifyTwoFactorLogin(ctx, challenge, next, models.ClientInfo{})
+	if err != nil || result.Token == "" {
+		t.Fatalf("VerifyTwoFactorLogin = %+v, %v; want a session", result, err)
+	}
+	if _, err := VerifyTwoFactorLogin(ctx, login(), next, models.ClientInfo{}); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("replayed code: got %v, want ErrInvalidCode", err)
+	}
+
+	// The session is marked as confirmed with a second factor
+	session, err := auth.IdentifySession(ctx, result.Token, "")
+	if err != nil || !session.TwoFactor || session.Method != "password" {
+		t.Errorf("session = %+v, %v; want a two-factor password session", session, err)
+	}
+
+	// A recovery code works once, typed in any case
+	challenge = login()
+	if _, err := VerifyTwoFactorLogin(ctx, challenge, strings.ToUpper(recovery.Codes[3]), models.ClientInfo{}); err != nil {
+		t.Fatalf("recovery code: %v", err)
+	}
+	if _, err := VerifyTwoFactorLogin(ctx, login(), recovery.Codes[3], models.ClientInfo{}); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("used recovery code: got %v, want ErrInvalidCode", err)
+	}
+	if status, err := GetTwoFactorStatus(ctx, user.ID); err != nil || !status.Enabled || status.RecoveryCodesLeft != recoveryCodeCount-1 {
+		t.Errorf("status = %+v, %v; want %d recovery codes left", status, err, recoveryCodeCount-1)
+	}
+
+	// Challenges are signed for this purpose; a forged or other token is no challenge
+	if _, err := VerifyTwoFactorLogin(ctx, challenge+"x", recovery.Codes[4], models.ClientInfo{}); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("forged challenge: got %v, want ErrInvalidToken", err)
+	}
+	other := auth.SignToken("password-reset", user.ID, "password", time.Minute)
+	if _, err := VerifyTwoFactorLogin(ctx, other, recovery.Codes[4], models.ClientInfo{}); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("token for another purpose: got %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestTwoFactorGuessing(t *testing.T) {
+	testdb.Open(t)
+	ctx := context.Background()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	user := testdb.CreateUser(t, models.User{Username: "alice", PasswordHash: string(hash)})
+	enrollment, err := EnrollTwoFactor(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovery, err := ActivateTwoFactor(ctx, user.ID, 0, totp(enrollment.Secret, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong := "000000"
+	for wrong == totp(enrollment.Secret, -1) || wrong == totp(enrollment.Secret, 0) || wrong == totp(enrollment.Secret, 1) {
+		wrong = "111111"
+	}
+	login := func() string {
+		t.Helper()
+		result, err := LoginWithPassword(ctx, "alice", "correct horse", models.ClientInfo{})
+		if err != nil || result.Challenge == "" {
+			t.Fatalf("password login: %+v, %v; want a challenge", result, err)
+		}
+		return result.Challenge
+	}
+	guess := func(challenge string, times int) {
+		t.Helper()
+		for i := 0; i < times; i++ {
+			if _, err := VerifyTwoFactorLogin(ctx, challenge, wrong, models.ClientInfo{}); !errors.Is(err, ErrInvalidCode) {
+				t.Fatalf("wrong code %d: got %v, want ErrInvalidCode", i+1, err)
+			}
+		}
+	}
+
+	// A challenge is given up after three wrong codes, even for the right one
+	challenge := login()
+	guess(challenge, maxChallengeFailures)
+	if _, err := VerifyTwoFactorLogin(ctx, challenge, recovery.Codes[0], models.ClientInfo{}); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("right code after %d wrong ones: got %v, want ErrInvalidToken", maxChallengeFailures, err)
+	}
+
+	// A challenge works once
+	challenge = login()
+	if _, err := VerifyTwoFactorLogin(ctx, challenge, recovery.Codes[1], models.ClientInfo{}); err != nil {
+		t.Fatalf("right code: %v", err)
+	}
+	if _, err := VerifyTwoFactorLogin(ctx, challenge, recovery.Codes[2], models.ClientInfo{}); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("reused challenge: got %v, want ErrInvalidToken", err)
+	}
+
+	// That success started the count again, so ten more wrong codes, over fresh
+	// challenges, lock every code out, here and in the settings
+	for left := maxTwoFactorFailures; left > 0; left -= maxChallengeFailures {
+		guess(login(), min(left, maxChallengeFailures))
+	}
+	if _, err := VerifyTwoFactorLogin(ctx, login(), recovery.Codes[2], models.ClientInfo{}); !errors.Is(err, ErrTwoFactorLocked) {
+		t.Errorf("right code while locked: got %v, want ErrTwoFactorLocked", err)
+	}
+	if err := DisableTwoFactor(ctx, user.ID, recovery.Codes[2]); !errors.Is(err, ErrTwoFactorLocked) {
+		t.Errorf("disabling while locked: got %v, want ErrTwoFactorLocked", err)
+	}
+
+	// Once the lockout is over, the right code works again
+	if err := db.DB.Model(&user).Update("two_factor_locked_until", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyTwoFactorLogin(ctx, login(), recovery.Codes[2], models.ClientInfo{}); err != nil {
+		t.Errorf("right code after the lockout: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+
+	"gin-demo-api/audit"
+	"gin-demo-api/auth"
+	"gin-demo-api/db"
+	"gin-demo-api/events"
+	"gin-demo-api/models"
+
+	"gorm.io/gorm"
+)
+
+// ListUsers returns all users, optionally with their todos preloaded
+func ListUsers(ctx context.Context, withTodos bool) ([]models.User, error) {
+	query := db.DB.WithContext(ctx).Order("id")
+	if withTodos {
+		query = query.Preload("Todos")
+	}
+
+	users := []models.User{}
+	err := query.Find(&users).Error
+	return users, err
+}
+
+// GetUsers returns the users with the given IDs, ordered by ID; unknown IDs are skipped
+func GetUsers(ctx context.Context, ids []uint) ([]models.User, error) {
+	users := []models.User{}
+	err := db.DB.WithContext(ctx).Where("id IN ?", ids).Order("id").Find(&users).Error
+	return users, err
+}
+
+// GetUser returns the user with the given ID, optionally with their todos preloaded
+func GetUser(ctx context.Context, id uint, withTodos bool) (models.User, error) {
+	query := db.DB.WithContext(ctx)
+	if withTodos {
+		query = query.Preload("Todos")
+	}
+
+	var user models.User
+	err := query.Where("id = ?", id).First(&user).Error
+	return user, notFound(err)
+}
+
+// CreateUser saves a new user; duplicate usernames or emails are returned as the database error
+func CreateUser(ctx context.Context, actorID *uint, input models.User) (models.User, error) {
+	input.ID = 0
+	input.Role = models.RoleUser // Admins are only made with the create-admin command
+	input.EmailVerifiedAt = nil
+	input.TwoFactorEnabledAt = nil
+	var changes []events.Event
+	err := db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&input).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, actorID, audit.ActionCreate, "user", input.ID, nil, input); err != nil {
+			return err
+		}
+		changes = []events.Event{userEvent(eventCreated, input)}
+		return logChanges(tx, changes)
+	})
+	if err != nil {
+		return input, err
+	}
+	publish(changes)
+	sendVerification(ctx, input)
+
+	return input, nil
+}
+
+// UpdateUser changes the username and/or email; empty values are left unchanged.
+// A new email address has to be verified again. Only the user or an admin may do it.
+func UpdateUser(ctx context.Context, actorID *uint, id uint, input models.User) (models.User, error) {
+	user, err := GetUser(ctx, id, false)
+	if err != nil {
+		return user, err
+	}
+	if err := checkAccountAccess(ctx, actorID, user.ID); err != nil {
+		return user, err
+	}
+
+	before := user
+	emailChanged := input.Email != "" && input.Email != user.Email
+	var changes []events.Event
+	err = db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(models.User{Username: input.Username, Email: input.Email}).Error; err != nil {
+			return err
+		}
+		if emailChanged {
+			if err := tx.Model(&user).Update("email_verified_at", nil).Error; err != nil {
+				return err
+			}
+		}
+		if err := audit.Record(tx, actorID, audit.ActionUpdate, "user", user.ID, before, user); err != nil {
+			return err
+		}
+		changes = []events.Event{userEvent(eventUpdated, user)}
+		return logChanges(tx, changes)
+	})
+	if err != nil {
+		return user, err
+	}
+	publish(changes)
+	if emailChanged {
+		sendVerification(ctx, user)
+	}
+
+	return user, nil
+}
+
+// DeleteUser soft-deletes the user and returns their final state.
+// Their todos are kept. Only the user or an admin may do it.
+func DeleteUser(ctx context.Context, actorID *uint, id uint) (models.User, error) {
+	user, err := GetUser(ctx, id, false)
+	if err != nil {
+		return user, err
+	}
+	if err := checkAccountAccess(ctx, actorID, user.ID); err != nil {
+		return user, err
+	}
+
+	var changes []events.Event
+	err = db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&user).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, actorID, audit.ActionDelete, "user", user.ID, user, nil); err != nil {
+			return err
+		}
+		changes = []events.Event{userEvent(eventDeleted, user)}
+		return logChanges(tx, changes)
+	})
+	if err != nil {
+		return user, err
+	}
+	publish(changes)
+	auth.ForgetUserSessions(user.ID) // Sessions of deleted users stop working
+
+	return user, nil
+}
+
+// checkAccountAccess allows changes to a user's account by that user and by admins
+func checkAccountAccess(ctx context.Context, actorID *uint, userID uint) error {
+	if actorID == nil {
+		return ErrUnauthenticated
+	}
+	if *actorID == userID {
+		return nil
+	}
+	var actor models.User
+	if err := db.DB.WithContext(ctx).Select("role").Where("id = ?", *actorID).Limit(1).Find(&actor).Error; err != nil {
+		return err
+	}
+	if actor.Role != models.RoleAdmin {
+		return ErrForbidden
+	}
+	return nil
+}
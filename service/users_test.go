@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+)
+
+func TestOnlyTheUserOrAnAdminChangesAnAccount(t *testing.T) {
+	testdb.Open(t)
+	ctx := context.Background()
+	alice := testdb.CreateUser(t, models.User{Username: "alice"})
+	bob := testdb.CreateUser(t, models.User{Username: "bob"})
+	admin := testdb.CreateUser(t, models.User{Username: "root", Role: models.RoleAdmin})
+
+	tests := []struct {
+		name  string
+		actor *uint
+		want  error
+	}{
+		{"anonymous", nil, ErrUnauthenticated},
+		{"another user", &bob.ID, ErrForbidden},
+		{"the user", &alice.ID, nil},
+		{"an admin", &admin.ID, nil},
+	}
+	for _, tt := range tests {
+		_, err := UpdateUser(ctx, tt.actor, alice.ID, models.User{Email: tt.name + "@example.com"})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("update by %s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if user, _ := GetUser(ctx, alice.ID, false); user.Email != "an admin@example.com" {
+		t.Errorf("email = %q, want the admin's change", user.Email)
+	}
+
+	if _, err := DeleteUser(ctx, nil, alice.ID); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("anonymous delete: got %v, want ErrUnauthenticated", err)
+	}
+	if _, err := DeleteUser(ctx, &bob.ID, alice.ID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("delete by another user: got %v, want ErrForbidden", err)
+	}
+	if _, err := DeleteUser(ctx, &alice.ID, alice.ID); err != nil {
+		t.Errorf("delete by the user: %v", err)
+	}
+}
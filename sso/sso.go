@@ -0,0 +1,142 @@
+// Package sso signs users in with OpenID Connect providers, using the
+// authorization code flow with PKCE.
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"gin-demo-api/config"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// ErrUnavailable is returned when a provider's configuration cannot be discovered
+var ErrUnavailable = errors.New("identity provider unavailable")
+
+// Claims are what a provider tells us about the user who signed in
+type Claims struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	Name              string `json:"name"`
+}
+
+// Provider is one configured identity provider
+type Provider struct {
+	config.OIDCProviderConfig
+	redirectURL string
+
+	mu       sync.Mutex
+	provider *oidc.Provider // Discovered on first use, so the API starts while a provider is down
+}
+
+var providers []*Provider
+
+// Configure sets up the providers; redirects come back to publicURL
+func Configure(cfgs []config.OIDCProviderConfig, publicURL string) error {
+	providers = nil
+	for _, cfg := range cfgs {
+		if cfg.Issuer == "" || cfg.ClientID == "" {
+			return fmt.Errorf("OIDC provider %q needs an issuer and a client ID", cfg.Name)
+		}
+		if _, ok := Lookup(cfg.Name); ok {
+			return fmt.Errorf("OIDC provider %q is configured twice", cfg.Name)
+		}
+		providers = append(providers, &Provider{
+			OIDCProviderConfig: cfg,
+			redirectURL:        publicURL + "/v1/auth/oidc/" + cfg.Name + "/callback",
+		})
+	}
+	return nil
+}
+
+// Providers returns the configured providers in configuration order
+func Providers() []*Provider {
+	return providers
+}
+
+// Lookup returns the provider with the given name
+func Lookup(name string) (*Provider, bool) {
+	i := slices.IndexFunc(providers, func(p *Provider) bool { return p.Name == name })
+	if i < 0 {
+		return nil, false
+	}
+	return providers[i], true
+}
+
+// AuthCodeURL returns where to send the user to sign in. state and nonce tie the
+// callback and ID token to this attempt; verifier is the PKCE secret.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	oauthConfig, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return oauthConfig.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Exchange redeems an authorization code and returns the verified ID token's claims
+func (p *Provider) Exchange(ctx context.Context, code, nonce, verifier string) (Claims, error) {
+	var claims Claims
+	oauthConfig, provider, err := p.discover(ctx)
+	if err != nil {
+		return claims, err
+	}
+
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return claims, fmt.Errorf("exchanging the authorization code: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return claims, errors.New("the token response has no ID token")
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return claims, fmt.Errorf("verifying the ID token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return claims, errors.New("the ID token nonce does not match")
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return claims, err
+	}
+
+	// Some providers only put the email in the UserInfo response
+	if claims.Email == "" && provider.UserInfoEndpoint() != "" {
+		info, err := provider.UserInfo(ctx, oauth2.StaticTokenSource(token))
+		if err != nil {
+			return claims, fmt.Errorf("fetching user info: %w", err)
+		}
+		if info.Subject == claims.Subject {
+			claims.Email, claims.EmailVerified = info.Email, info.EmailVerified
+		}
+	}
+	return claims, nil
+}
+
+// discover fetches the provider's configuration once it is first needed
+func (p *Provider) discover(ctx context.Context) (*oauth2.Config, *oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.provider == nil {
+		provider, err := oidc.NewProvider(ctx, p.Issuer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		p.provider = provider
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		Endpoint:     p.provider.Endpoint(),
+		RedirectURL:  p.redirectURL,
+		Scopes:       append([]string{oidc.ScopeOpenID}, p.Scopes...),
+	}, p.provider, nil
+}
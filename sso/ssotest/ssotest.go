@@ -0,0 +1,268 @@
+// Package ssotest provides a mock OpenID Connect provider for tests and local
+// development. It approves every login without asking, as the user given by the
+// login_hint parameter or DefaultEmail.
+//
+// It supports discovery, the authorization code flow with PKCE (S256),
+// RS256-signed ID tokens, JWKS and UserInfo. Keys and codes live in memory.
+package ssotest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+// grant is an issued authorization code or access token
+type grant struct {
+	ClientID    string
+	RedirectURI string
+	Challenge   string
+	Nonce       string
+	Email       string
+	Verified    bool
+	Expires     time.Time
+}
+
+// Provider is the mock identity provider, an http.Handler
+type Provider struct {
+	// Issuer is the provider's URL as the API reaches it
+	Issuer string
+	// DefaultEmail is who logs in when the login has no login_hint
+	DefaultEmail string
+	// Logger, if set, logs every approved login
+	Logger *log.Logger
+
+	key     jose.JSONWebKey
+	handler http.Handler
+
+	mu     sync.Mutex
+	codes  map[string]grant
+	tokens map[string]grant
+}
+
+// New creates a provider with a fresh signing key
+func New(issuer string) (*Provider, error) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	p := &Provider{
+		Issuer:       strings.TrimSuffix(issuer, "/"),
+		DefaultEmail: "dev@example.com",
+		key:          jose.JSONWebKey{Key: rsaKey, KeyID: randomHex(8), Algorithm: string(jose.RS256), Use: "sig"},
+		codes:        map[string]grant{},
+		tokens:       map[string]grant{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /authorize", p.authorize)
+	mux.HandleFunc("POST /token", p.token)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	mux.HandleFunc("GET /userinfo", p.userinfo)
+	p.handler = mux
+	return p, nil
+}
+
+// NewServer starts a provider on a local port; its Issuer is the server's URL.
+// The caller closes the server when done.
+func NewServer() (*Provider, *httptest.Server, error) {
+	p, err := New("")
+	if err != nil {
+		return nil, nil, err
+	}
+	server := httptest.NewServer(p)
+	p.Issuer = server.URL
+	return p, server, nil
+}
+
+func (p *Provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.handler.ServeHTTP(w, r)
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.Issuer,
+		"authorization_endpoint":                p.Issuer + "/authorize",
+		"token_endpoint":                        p.Issuer + "/token",
+		"jwks_uri":                              p.Issuer + "/jwks",
+		"userinfo_endpoint":                     p.Issuer + "/userinfo",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{string(jose.RS256)},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "email", "profile"},
+	})
+}
+
+// authorize approves the login straight away and redirects back with a code.
+// login_hint picks the user; email_verified=false simulates an unverified address.
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || redirect.Scheme == "" || q.Get("client_id") == "" {
+		http.Error(w, "client_id and an absolute redirect_uri are required", http.StatusBadRequest)
+		return
+	}
+	back := redirect.Query()
+	back.Set("state", q.Get("state"))
+	if q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		back.Set("error", "invalid_request")
+		back.Set("error_description", "response_type=code and an S256 code_challenge are required")
+		redirect.RawQuery = back.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+		return
+	}
+
+	email := p.DefaultEmail
+	if hint := q.Get("login_hint"); hint != "" {
+		email = hint
+	}
+	code := randomHex(16)
+	p.mu.Lock()
+	p.codes[code] = grant{
+		ClientID:    q.Get("client_id"),
+		RedirectURI: q.Get("redirect_uri"),
+		Challenge:   q.Get("code_challenge"),
+		Nonce:       q.Get("nonce"),
+		Email:       email,
+		Verified:    q.Get("email_verified") != "false",
+		Expires:     time.Now().Add(time.Minute),
+	}
+	p.mu.Unlock()
+
+	if p.Logger != nil {
+		p.Logger.Printf("Approved login for %s", email)
+	}
+	back.Set("code", code)
+	redirect.RawQuery = back.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// token redeems a code, once, for an access token and a signed ID token
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	clientID, _, ok := r.BasicAuth()
+	if !ok {
+		clientID = r.PostFormValue("client_id")
+	}
+
+	p.mu.Lock()
+	g, found := p.codes[r.PostFormValue("code")]
+	delete(p.codes, r.PostFormValue("code"))
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	switch {
+	case r.PostFormValue("grant_type") != "authorization_code":
+		tokenError(w, "unsupported_grant_type", "only authorization_code is supported")
+		return
+	case !found || time.Now().After(g.Expires) || g.ClientID != clientID || g.RedirectURI != r.PostFormValue("redirect_uri"):
+		tokenError(w, "invalid_grant", "unknown, expired or mismatched code")
+		return
+	case base64.RawURLEncoding.EncodeToString(sum[:]) != g.Challenge:
+		tokenError(w, "invalid_grant", "code_verifier does not match the code_challenge")
+		return
+	}
+
+	now := time.Now()
+	idToken, err := p.sign(map[string]interface{}{
+		"iss":                p.Issuer,
+		"sub":                Subject(g.Email),
+		"aud":                g.ClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Hour).Unix(),
+		"nonce":              g.Nonce,
+		"email":              g.Email,
+		"email_verified":     g.Verified,
+		"preferred_username": strings.SplitN(g.Email, "@", 2)[0],
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	accessToken := randomHex(16)
+	g.Expires = now.Add(time.Hour)
+	p.mu.Lock()
+	p.tokens[accessToken] = g
+	p.mu.Unlock()
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{p.key.Public()}})
+}
+
+func (p *Provider) userinfo(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	g, ok := p.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	p.mu.Unlock()
+	if !ok || time.Now().After(g.Expires) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, "invalid access token", http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sub":            Subject(g.Email),
+		"email":          g.Email,
+		"email_verified": g.Verified,
+	})
+}
+
+// sign returns the claims as a compact RS256 JWT
+func (p *Provider) sign(claims map[string]interface{}) (string, error) {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: p.key}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		return "", err
+	}
+	return jws.CompactSerialize()
+}
+
+// Subject is the subject the provider gives the user with an email address. It is
+// stable, so logging in again finds the same identity.
+func Subject(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(email)))
+	return hex.EncodeToString(sum[:12])
+}
+
+func tokenError(w http.ResponseWriter, code, description string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code, "error_description": description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
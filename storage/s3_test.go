@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// fakeS3 is a local stand-in for an S3 bucket. It checks every request's signature
+// with the AWS SDK's SigV4 signer, which S3 itself is compatible with.
+type fakeS3 struct {
+	t         *testing.T
+	accessKey string
+	secretKey string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	ranges  []string
+}
+
+var authorization = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=([^/]+)/(\d{8})/([^/]+)/s3/aws4_request, SignedHeaders=([a-z0-9;-]+), Signature=[0-9a-f]{64}$`)
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f.verify(r); err != nil {
+		f.t.Logf("%s %s: %v", r.Method, r.URL.Path, err)
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	object, found := f.objects[r.URL.Path]
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		if int64(len(body)) != r.ContentLength {
+			http.Error(w, "IncompleteBody", http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = body
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodHead, http.MethodGet:
+		if !found {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			f.ranges = append(f.ranges, r.Header.Get("Range"))
+		}
+		// ServeContent answers Range requests with 206, as S3 does
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(string(object)))
+	default:
+		http.Error(w, "MethodNotAllowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// verify signs a copy of the request, with only the headers it claims to have signed,
+// and compares the result with its Authorization header
+func (f *fakeS3) verify(r *http.Request) error {
+	match := authorization.FindStringSubmatch(r.Header.Get("Authorization"))
+	if match == nil {
+		return fmt.Errorf("malformed Authorization %q", r.Header.Get("Authorization"))
+	}
+	if match[1] != f.accessKey {
+		return fmt.Errorf("unknown access key %q", match[1])
+	}
+	signedAt, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil || signedAt.Format("20060102") != match[2] || time.Since(signedAt).Abs() > 15*time.Minute {
+		return fmt.Errorf("bad X-Amz-Date %q", r.Header.Get("X-Amz-Date"))
+	}
+
+	u := &url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path, RawPath: r.URL.RawPath, RawQuery: r.URL.RawQuery}
+	check, _ := http.NewRequest(r.Method, u.String(), nil)
+	for _, name := range strings.Split(match[4], ";") {
+		if name != "host" {
+			check.Header.Set(name, r.Header.Get(name))
+		}
+	}
+	signer := v4.NewSigner(func(o *v4.SignerOptions) { o.DisableURIPathEscaping = true })
+	credentials := aws.Credentials{AccessKeyID: f.accessKey, SecretAccessKey: f.secretKey}
+	err = signer.SignHTTP(context.Background(), credentials, check, r.Header.Get("X-Amz-Content-Sha256"), "s3", match[3], signedAt)
+	if err != nil {
+		return err
+	}
+	if got, want := r.Header.Get("Authorization"), check.Header.Get("Authorization"); got != want {
+		return fmt.Errorf("signature mismatch:\n got  %s\n want %s", got, want)
+	}
+	return nil
+}
+
+// startS3 serves a fake bucket and returns a store for it
+func startS3(t *testing.T) (*fakeS3, *S3Store) {
+	t.Helper()
+	fake := &fakeS3{t: t, accessKey: "AKIDEXAMPLE", secretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	store, err := NewS3Store(server.URL+"/", "eu-central-1", "attachments", fake.accessKey, fake.secretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, store
+}
+
+func TestS3Store(t *testing.T) {
+	fake, store := startS3(t)
+	ctx := context.Background()
+	// The key needs escaping, so the path in the signature must match the one sent
+	const key = "todos/1/shopping list (v2) ü+.txt"
+	const content = "milk, eggs, flour, butter"
+
+	if err := store.Put(ctx, key, strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.objects["/attachments/"+key]; !ok {
+		t.Fatalf("objects = %v, want %q in the bucket", fake.objects, key)
+	}
+
+	blob, err := store.Open(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blob.Close()
+	if got, _ := io.ReadAll(blob); string(got) != content {
+		t.Errorf("read %q, want %q", got, content)
+	}
+	// A seek reopens the object from the new offset
+	if _, err := blob.Seek(-6, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(blob); string(got) != "butter" {
+		t.Errorf("read %q after seeking, want %q", got, "butter")
+	}
+	if strings.Join(fake.ranges, ",") != "bytes=0-,bytes=19-" {
+		t.Errorf("ranges = %q, want bytes=0- and bytes=19-", fake.ranges)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("deleting a missing blob: %v", err)
+	}
+	if _, err := store.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open after Delete: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestS3StoreWrongSecret(t *testing.T) {
+	fake, store := startS3(t)
+	store.secretKey = "wrong"
+
+	err := store.Put(context.Background(), "todos/1/a.txt", strings.NewReader("a"), 1)
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Put = %v, want a 403 error", err)
+	}
+	if len(fake.objects) != 0 {
+		t.Errorf("objects = %v, want none", fake.objects)
+	}
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"gin-demo-api/config"
+)
+
+// ErrNotFound is returned when a blob does not exist
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores opaque file contents under string keys
+type BlobStore interface {
+	// Put writes size bytes from r under key, replacing any existing blob
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Open returns a seekable reader over the blob, for streaming and Range requests
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete removes the blob; deleting a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// Blobs is the blob store used for attachments
+var Blobs BlobStore
+
+// ConnectStorage initializes the blob store selected by the configuration
+func ConnectStorage(cfg config.StorageConfig) error {
+	switch cfg.Driver {
+	case "local":
+		store, err := NewLocalStore(cfg.Dir)
+		if err != nil {
+			return err
+		}
+		Blobs = store
+	case "s3":
+		store, err := NewS3Store(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey)
+		if err != nil {
+			return err
+		}
+		Blobs = store
+	default:
+		return fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Package tracing sets up OpenTelemetry tracing: a span per HTTP request, child spans
+// for database statements and W3C trace context propagation in and out of the service.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"gin-demo-api/config"
+	"gin-demo-api/models"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Instrumentation scope of the spans created by this service
+const tracerName = "gin-demo-api"
+
+// tracer returns the tracer of the global provider; spans are no-ops until Setup installs an exporter
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// NewExporter creates the span exporter selected by cfg.Exporter: "stdout", "otlp"
+// (OTLP over HTTP, configured by the standard OTEL_EXPORTER_OTLP_* variables) or "none"
+func NewExporter(ctx context.Context, cfg config.TracingConfig) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "otlp":
+		return otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+}
+
+// Setup installs W3C trace context propagation and, if exporter is not nil, a tracer provider
+// sending spans to it. Tests can pass an in-memory exporter (go.opentelemetry.io/otel/sdk/trace/tracetest).
+// The returned function flushes and stops the provider.
+func Setup(exporter sdktrace.SpanExporter, serviceName string) func(context.Context) error {
+	// Incoming trace context is passed on even when this service records nothing
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if exporter == nil {
+		return func(context.Context) error { return nil }
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown
+}
+
+// Inject writes the trace context of ctx into outgoing request headers (traceparent, tracestate)
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// TraceParent returns the W3C traceparent of the span in ctx, or "" if there is none.
+// It lets work that outlives the request, like webhook deliveries, continue the trace later.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get("traceparent")
+}
+
+// WithTraceParent returns ctx with the remote span described by a traceparent from TraceParent
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	return propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{"traceparent": traceParent})
+}
+
+// StartWebhookSpan starts a client span for one delivery attempt, as a child of the
+// span that queued the delivery
+func StartWebhookSpan(ctx context.Context, delivery *models.WebhookDelivery) (context.Context, trace.Span) {
+	ctx = WithTraceParent(ctx, delivery.TraceParent)
+	return tracer().Start(ctx, "webhook "+delivery.EventType,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(http.MethodPost),
+			attribute.Int64("webhook.id", int64(delivery.WebhookID)),
+			attribute.Int64("webhook.delivery.id", int64(delivery.ID)),
+			attribute.Int("webhook.delivery.attempt", delivery.Attempts+1),
+		),
+	)
+}
+
+// EndWebhookSpan records the outcome of the attempt and ends its span
+func EndWebhookSpan(span trace.Span, delivery *models.WebhookDelivery) {
+	if delivery.ResponseStatus != 0 {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(delivery.ResponseStatus))
+	}
+	if delivery.Error != "" {
+		span.SetStatus(codes.Error, delivery.Error)
+	}
+	span.End()
+}
@@ -0,0 +1,176 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+	"gin-demo-api/service"
+	"gin-demo-api/tracing"
+	"gin-demo-api/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setup installs a tracer provider recording into an in-memory exporter and an
+// instrumented database. The returned function flushes and returns the ended spans.
+func setup(t *testing.T) func() tracetest.SpanStubs {
+	t.Helper()
+	previous := otel.GetTracerProvider()
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown := tracing.Setup(exporter, "test")
+	provider := otel.GetTracerProvider().(*sdktrace.TracerProvider)
+	t.Cleanup(func() {
+		shutdown(context.Background())
+		otel.SetTracerProvider(previous)
+	})
+
+	testdb.Open(t)
+	if err := tracing.InstrumentDB(db.DB); err != nil {
+		t.Fatal(err)
+	}
+
+	return func() tracetest.SpanStubs {
+		if err := provider.ForceFlush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		spans := exporter.GetSpans()
+		exporter.Reset()
+		return spans
+	}
+}
+
+// find returns the span with the given name, failing the test if there is none
+func find(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	var names []string
+	for _, span := range spans {
+		names = append(names, span.Name)
+	}
+	t.Fatalf("no span %q in %q", name, names)
+	return tracetest.SpanStub{}
+}
+
+// attribute returns the value of a span attribute as a string
+func attribute(span tracetest.SpanStub, key string) string {
+	for _, kv := range span.Attributes {
+		if string(kv.Key) == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestRequestAndQuerySpans(t *testing.T) {
+	spans := setup(t)
+	user := models.User{Username: "alice", Email: "alice@example.com", Role: models.RoleUser}
+	if err := db.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(tracing.Middleware())
+	router.GET("/todos/:id", func(c *gin.Context) {
+		todo, err := service.GetTodo(c.Request.Context(), 42)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+			return
+		}
+		c.JSON(http.StatusOK, todo)
+	})
+
+	// Statements outside a traced request are not recorded
+	if got := spans(); len(got) != 0 {
+		t.Fatalf("got %d spans before the request, want none", len(got))
+	}
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/todos/42", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	recorded := spans()
+	request := find(t, recorded, "GET /todos/:id")
+	if request.SpanKind != trace.SpanKindServer || request.SpanContext.TraceID().String() != traceID || request.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("request span = %s in trace %s with parent %s, want a server span continuing the incoming traceparent",
+			request.SpanKind, request.SpanContext.TraceID(), request.Parent.SpanID())
+	}
+	if got := attribute(request, string(semconv.HTTPResponseStatusCodeKey)); got != "404" {
+		t.Errorf("%s = %q, want 404", semconv.HTTPResponseStatusCodeKey, got)
+	}
+
+	query := find(t, recorded, "query todos")
+	if query.Parent.SpanID() != request.SpanContext.SpanID() || query.SpanContext.TraceID() != request.SpanContext.TraceID() {
+		t.Errorf("query span is not a child of the request span")
+	}
+	text := attribute(query, string(semconv.DBQueryTextKey))
+	if !strings.Contains(text, "SELECT") || !strings.Contains(text, "?") || strings.Contains(text, "42") {
+		t.Errorf("%s = %q, want the statement with placeholders", semconv.DBQueryTextKey, text)
+	}
+}
+
+func TestWebhookDeliveryContinuesTrace(t *testing.T) {
+	spans := setup(t)
+	webhooks.AllowPrivateTargets = true
+	t.Cleanup(func() { webhooks.AllowPrivateTargets = false })
+
+	traceParents := make(chan string, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceParents <- r.Header.Get("traceparent")
+	}))
+	t.Cleanup(receiver.Close)
+
+	user := models.User{Username: "alice", Email: "alice@example.com", Role: models.RoleUser}
+	if err := db.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	active := true
+	hook := models.Webhook{UserID: user.ID, URL: receiver.URL, Events: models.StringList{"todo.created"}, Secret: "whsec_test", Active: &active}
+	if err := db.DB.Create(&hook).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// The todo is created in a request span; the delivery is sent later, outside it
+	ctx, span := otel.Tracer("test").Start(context.Background(), "POST /todos")
+	if _, err := service.CreateTodo(ctx, &user.ID, models.Todo{Item: "Buy milk", UserID: user.ID}); err != nil {
+		t.Fatal(err)
+	}
+	span.End()
+	request := span.SpanContext()
+
+	var delivery models.WebhookDelivery
+	if err := db.DB.Preload("Webhook").First(&delivery).Error; err != nil {
+		t.Fatal(err)
+	}
+	spans()
+	webhooks.NewDispatcher(db.DB).Attempt(context.Background(), &delivery)
+
+	sent := find(t, spans(), "webhook todo.created")
+	if sent.SpanKind != trace.SpanKindClient || sent.Parent.SpanID() != request.SpanID() || sent.SpanContext.TraceID() != request.TraceID() {
+		t.Errorf("delivery span is not a child of the span that queued it")
+	}
+	want := "00-" + request.TraceID().String() + "-" + sent.SpanContext.SpanID().String() + "-01"
+	if got := <-traceParents; got != want {
+		t.Errorf("traceparent = %q, want %q", got, want)
+	}
+}
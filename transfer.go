@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gin-demo-api/db"
+	"gin-demo-api/models"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dumpVersion is bumped when the export format changes incompatibly
+const dumpVersion = 1
+
+// dump is the export/import file format
+type dump struct {
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Users      []models.User    `json:"users"`
+	Todos      []models.Todo    `json:"todos"`
+	Comments   []models.Comment `json:"comments"`
+}
+
+// Columns import overwrites in existing rows: those the export carries. Passwords,
+// two-factor state and deleted_at are left alone, so an import cannot blank a
+// password or restore a deleted row.
+var (
+	userColumns    = []string{"created_at", "updated_at", "username", "email", "role", "email_verified_at"}
+	todoColumns    = []string{"created_at", "updated_at", "item", "description", "completed", "user_id"}
+	commentColumns = []string{"created_at", "updated_at", "todo_id", "user_id", "body", "edited_at"}
+)
+
+// upsert inserts new rows and updates only the given columns of rows with the same ID
+func upsert(columns []string) clause.OnConflict {
+	return clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}
+}
+
+func exportCommand() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write all users, todos and comments as JSON",
+		Long:  "Writes the users, todos and comments (with mentions) that are not deleted to a JSON file, or to stdout. Attachments, audit events and webhooks are not exported, and neither are passwords and two-factor secrets.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := openDatabase(); err != nil {
+				return err
+			}
+
+			data := dump{Version: dumpVersion, ExportedAt: time.Now().UTC()}
+			if err := db.DB.Order("id").Find(&data.Users).Error; err != nil {
+				return err
+			}
+			if err := db.DB.Order("id").Find(&data.Todos).Error; err != nil {
+				return err
+			}
+			if err := db.DB.Preload("Mentions").Order("id").Find(&data.Comments).Error; err != nil {
+				return err
+			}
+
+			var w io.Writer = cmd.OutOrStdout()
+			if output != "" && output != "-" {
+				file, err := os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				w = file
+			}
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(data); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d users, %d todos and %d comments\n", len(data.Users), len(data.Todos), len(data.Comments))
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "-", "File to write, - for stdout")
+	return cmd
+}
+
+func importCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <file>",
+		Short: "Load users, todos and comments from an export file",
+		Long:  "Loads a file written by export (- reads stdin) in one transaction. Records are matched by ID: the exported fields of existing ones are overwritten, missing ones are created. Passwords, two-factor state and deletions of existing users are kept; new users start without a password or two-factor authentication. Imported rows are not audited.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var r io.Reader = cmd.InOrStdin()
+			if args[0] != "-" {
+				file, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				r = file
+			}
+
+			var data dump
+			if err := json.NewDecoder(r).Decode(&data); err != nil {
+				return fmt.Errorf("reading %s: %w", args[0], err)
+			}
+			if data.Version != dumpVersion {
+				return fmt.Errorf("unsupported export version %d, expected %d", data.Version, dumpVersion)
+			}
+			if err := openDatabase(); err != nil {
+				return err
+			}
+
+			for i := range data.Users {
+				data.Users[i].Todos = nil // Imported separately
+				// The TOTP secret is not exported, so new users have to enroll again
+				data.Users[i].TwoFactorEnabledAt = nil
+			}
+			err := db.DB.Transaction(func(tx *gorm.DB) error {
+				if len(data.Users) > 0 {
+					if err := tx.Clauses(upsert(userColumns)).CreateInBatches(data.Users, 100).Error; err != nil {
+						return fmt.Errorf("importing users: %w", err)
+					}
+				}
+				if len(data.Todos) > 0 {
+					if err := tx.Clauses(upsert(todoColumns)).CreateInBatches(data.Todos, 100).Error; err != nil {
+						return fmt.Errorf("importing todos: %w", err)
+					}
+				}
+				if len(data.Comments) > 0 {
+					if err := tx.Clauses(upsert(commentColumns)).CreateInBatches(data.Comments, 100).Error; err != nil {
+						return fmt.Errorf("importing comments: %w", err)
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Imported %d users, %d todos and %d comments\n", len(data.Users), len(data.Todos), len(data.Comments))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gin-demo-api/db"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+)
+
+func TestImportKeepsCredentialsAndDeletions(t *testing.T) {
+	testdb.Open(t)
+	enabled := time.Now()
+	user := models.User{Username: "alice", Email: "alice@example.com", Role: models.RoleAdmin, PasswordHash: "$2a$10$hash", TOTPSecret: "SECRET", TwoFactorEnabledAt: &enabled}
+	if err := db.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	deleted := models.Todo{Item: "Deleted", UserID: user.ID}
+	if err := db.DB.Create(&deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	db.DB.Delete(&deleted)
+
+	// An export of alice and her todo from before it was deleted, plus a new 2FA user
+	file := filepath.Join(t.TempDir(), "export.json")
+	data := dump{
+		Version: dumpVersion,
+		Users: []models.User{
+			{ID: user.ID, Username: "alice", Email: "alice@new.example.com", Role: models.RoleAdmin},
+			{ID: user.ID + 1, Username: "bob", Email: "bob@example.com", Role: models.RoleUser, TwoFactorEnabledAt: &enabled},
+		},
+		Todos: []models.Todo{{ID: deleted.ID, Item: "Renamed", UserID: user.ID}},
+	}
+	content, _ := json.Marshal(data)
+	if err := os.WriteFile(file, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := importCommand()
+	cmd.SetArgs([]string{file})
+	cmd.SetOut(&bytes.Buffer{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	var alice, bob models.User
+	db.DB.First(&alice, user.ID)
+	db.DB.First(&bob, user.ID+1)
+	if alice.Email != "alice@new.example.com" {
+		t.Errorf("email = %q, want the imported one", alice.Email)
+	}
+	if alice.PasswordHash != user.PasswordHash || alice.TOTPSecret != user.TOTPSecret || alice.TwoFactorEnabledAt == nil {
+		t.Errorf("import changed alice's credentials: %+v", alice)
+	}
+	if bob.ID == 0 || bob.TwoFactorEnabledAt != nil {
+		t.Errorf("new user should be created without two-factor authentication: %+v", bob)
+	}
+
+	var todo models.Todo
+	if err := db.DB.Unscoped().First(&todo, deleted.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if todo.Item != "Renamed" || !todo.DeletedAt.Valid {
+		t.Errorf("todo = %q, deleted %v; want the imported item, still deleted", todo.Item, todo.DeletedAt.Valid)
+	}
+}
@@ -0,0 +1,171 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gin-demo-api/events"
+	"gin-demo-api/models"
+	"gin-demo-api/tracing"
+
+	"gorm.io/gorm"
+)
+
+// Dispatcher works through the delivery queue, retrying failures with exponential backoff
+type Dispatcher struct {
+	DB           *gorm.DB
+	Client       *http.Client
+	PollInterval time.Duration // How often to look for due retries
+	MaxAttempts  int           // Deliveries fail permanently after this many attempts
+	BaseDelay    time.Duration // Delay before the first retry; doubled for each further attempt
+	MaxDelay     time.Duration
+}
+
+// wake prompts a running dispatcher to check the queue right away
+var wake = make(chan struct{}, 1)
+
+// Notify tells the dispatcher that new deliveries are queued (e.g. after a manual redelivery)
+func Notify() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// NewDispatcher returns a dispatcher with default timeouts and retry policy
+func NewDispatcher(db *gorm.DB) *Dispatcher {
+	return &Dispatcher{
+		DB:           db,
+		Client:       &http.Client{Timeout: 10 * time.Second, Transport: newTransport()},
+		PollInterval: 5 * time.Second,
+		MaxAttempts:  8,
+		BaseDelay:    10 * time.Second,
+		MaxDelay:     time.Hour,
+	}
+}
+
+// Run delivers due webhooks until ctx is cancelled. New events wake it immediately.
+// A delivery in progress when ctx is cancelled is finished and recorded first.
+func (d *Dispatcher) Run(ctx context.Context) {
+	sub := events.Default.Subscribe(1)
+	defer func() { sub.Close() }()
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.deliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.Events():
+		case <-wake:
+		case <-sub.Done():
+			sub = events.Default.Subscribe(1)
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverDue attempts every pending delivery whose next attempt is due
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		var due []models.WebhookDelivery
+		err := d.DB.WithContext(ctx).Preload("Webhook").
+			Where("status = ? AND next_attempt_at <= ?", StatusPending, time.Now()).
+			Order("next_attempt_at, id").Limit(20).Find(&due).Error
+		if err != nil {
+			slog.ErrorContext(ctx, "webhooks: loading due deliveries", "err", err)
+			return
+		}
+		if len(due) == 0 {
+			return
+		}
+
+		for i := range due {
+			d.Attempt(ctx, &due[i])
+		}
+	}
+}
+
+// Attempt sends one delivery and records the outcome, scheduling a retry on failure.
+// Each attempt is a span in the trace of the change that queued the delivery. It is
+// not interrupted when ctx is cancelled, so a receiver never gets an event whose
+// delivery is not recorded; the client's timeout bounds it.
+func (d *Dispatcher) Attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	ctx, span := tracing.StartWebhookSpan(context.WithoutCancel(ctx), delivery)
+	defer func() { tracing.EndWebhookSpan(span, delivery) }()
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = 0
+	delivery.ResponseBody = ""
+	delivery.Error = ""
+
+	if delivery.Webhook.ID == 0 {
+		// The webhook was deleted while the delivery was queued
+		delivery.Status = StatusFailed
+		delivery.Error = "webhook deleted"
+	} else if err := d.send(ctx, delivery, now); err != nil {
+		delivery.Error = err.Error()
+		if delivery.Attempts >= d.MaxAttempts {
+			delivery.Status = StatusFailed
+		} else {
+			delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+		}
+	} else {
+		delivery.Status = StatusSucceeded
+	}
+
+	if err := d.DB.WithContext(ctx).Omit("Webhook").Save(delivery).Error; err != nil {
+		slog.ErrorContext(ctx, "webhooks: saving delivery", "delivery_id", delivery.ID, "err", err)
+	}
+}
+
+// send POSTs the signed payload; any non-2xx response is an error
+func (d *Dispatcher) send(ctx context.Context, delivery *models.WebhookDelivery, now time.Time) error {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gin-demo-api-webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set("X-Webhook-Signature", Sign(delivery.Webhook.Secret, now, body))
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	delivery.ResponseStatus = resp.StatusCode
+	delivery.ResponseBody = string(response)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return nil
+}
+
+// backoff returns the delay before the next attempt: BaseDelay * 2^(attempts-1), capped, with ±10% jitter
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.BaseDelay << (attempts - 1)
+	if delay <= 0 || delay > d.MaxDelay {
+		delay = d.MaxDelay
+	}
+	jitter := time.Duration(rand.Int64N(int64(delay)/5+1)) - delay/10
+	return delay + jitter
+}
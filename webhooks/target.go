@@ -0,0 +1,97 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// AllowPrivateTargets lets webhooks deliver to loopback, private and link-local
+// addresses. It is off by default, so users cannot make the server call internal
+// services or cloud metadata endpoints on their behalf.
+var AllowPrivateTargets = false
+
+var (
+	// ErrInvalidURL is returned for webhook URLs that are not absolute http or https URLs
+	ErrInvalidURL = errors.New("webhook URL must be http or https")
+	// ErrPrivateTarget is returned for webhook URLs pointing at a non-public address
+	ErrPrivateTarget = errors.New("webhook URL must not point to a private, loopback or link-local address")
+)
+
+// reservedPrefixes are non-public ranges that netip has no predicate for
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "This network"
+	netip.MustParsePrefix("100.64.0.0/10"), // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // Benchmarking
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, which can reach IPv4 private ranges
+}
+
+// ValidateURL checks that a webhook URL is http or https and, unless
+// AllowPrivateTargets is set, that its host does not resolve to a non-public address.
+// Hosts that do not resolve yet are accepted; the dispatcher checks every connection.
+func ValidateURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidURL
+	}
+	if AllowPrivateTargets {
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return ErrPrivateTarget
+		}
+	}
+	return nil
+}
+
+// publicAddr reports whether addr is routable on the public internet
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkDial refuses connections to non-public addresses. It runs after DNS
+// resolution, so a hostname re-pointed after ValidateURL is caught too.
+func checkDial(network, address string, _ syscall.RawConn) error {
+	if AllowPrivateTargets {
+		return nil
+	}
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil || !publicAddr(addrPort.Addr()) {
+		return ErrPrivateTarget
+	}
+	return nil
+}
+
+// newTransport returns the transport deliveries are sent with: no proxy, since the
+// address check must see the receiver, and every connection (redirects too) checked
+func newTransport() *http.Transport {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: checkDial}
+	return &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	}
+}
@@ -0,0 +1,200 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-demo-api/db"
+	"gin-demo-api/events"
+	"gin-demo-api/internal/testdb"
+	"gin-demo-api/models"
+
+	"gorm.io/gorm"
+)
+
+// received is one request the test receiver got
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// startReceiver answers every webhook with 200 and hands the request to the test
+func startReceiver(t *testing.T) (*httptest.Server, chan received) {
+	t.Helper()
+	requests := make(chan received, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{header: r.Header, body: body}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+// createWebhook stores a webhook for a test
+func createWebhook(t *testing.T, userID uint, url, secret string, active bool, eventTypes ...string) models.Webhook {
+	t.Helper()
+	hook := models.Webhook{UserID: userID, URL: url, Events: eventTypes, Secret: secret, Active: &active}
+	if err := db.DB.Create(&hook).Error; err != nil {
+		t.Fatal(err)
+	}
+	return hook
+}
+
+// enqueue queues an event the way the service layer does, inside a transaction
+func enqueue(t *testing.T, event events.Event) {
+	t.Helper()
+	if err := db.DB.Transaction(func(tx *gorm.DB) error { return Enqueue(tx, event) }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeliveryToLocalReceiver(t *testing.T) {
+	testdb.Open(t)
+	AllowPrivateTargets = true
+	defer func() { AllowPrivateTargets = false }()
+	receiver, requests := startReceiver(t)
+
+	const alice, bob = 1, 2
+	hook := createWebhook(t, alice, receiver.URL, "whsec_alice", true, "todo.created")
+	createWebhook(t, alice, receiver.URL, "whsec_inactive", false, "*")
+	createWebhook(t, alice, receiver.URL, "whsec_other_events", true, "todo.deleted")
+	createWebhook(t, bob, receiver.URL, "whsec_bob", true, "*")
+
+	enqueue(t, events.Event{ID: 7, Type: "todo.created", EntityType: "todo", EntityID: 3, UserID: alice, Data: map[string]string{"item": "Buy milk"}})
+
+	var deliveries []models.WebhookDelivery
+	db.DB.Find(&deliveries)
+	if len(deliveries) != 1 || deliveries[0].WebhookID != hook.ID {
+		t.Fatalf("got %d deliveries (%+v), want one to alice's subscribed webhook", len(deliveries), deliveries)
+	}
+
+	NewDispatcher(db.DB).deliverDue(context.Background())
+
+	select {
+	case req := <-requests:
+		if got := req.header.Get("X-Webhook-Event"); got != "todo.created" {
+			t.Errorf("X-Webhook-Event = %q", got)
+		}
+		if got := req.header.Get("X-Webhook-Delivery"); got != strconv.FormatUint(uint64(deliveries[0].ID), 10) {
+			t.Errorf("X-Webhook-Delivery = %q", got)
+		}
+		seconds, _ := strconv.ParseInt(req.header.Get("X-Webhook-Timestamp"), 10, 64)
+		if want := Sign("whsec_alice", time.Unix(seconds, 0), req.body); req.header.Get("X-Webhook-Signature") != want {
+			t.Errorf("X-Webhook-Signature = %q, want %q", req.header.Get("X-Webhook-Signature"), want)
+		}
+		if !strings.Contains(string(req.body), `"item":"Buy milk"`) {
+			t.Errorf("body = %s", req.body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the receiver got no request")
+	}
+	select {
+	case req := <-requests:
+		t.Errorf("unexpected second request: %s", req.body)
+	default:
+	}
+
+	var delivery models.WebhookDelivery
+	db.DB.First(&delivery, deliveries[0].ID)
+	if delivery.Status != StatusSucceeded || delivery.Attempts != 1 || delivery.ResponseStatus != http.StatusOK || delivery.ResponseBody != "ok" {
+		t.Errorf("delivery = %+v, want one successful attempt", delivery)
+	}
+}
+
+func TestPrivateTargetsAreRefused(t *testing.T) {
+	ctx := context.Background()
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://10.1.2.3/hook",
+		"http://192.168.0.10/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"http://[fd00::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://100.64.0.1/hook",
+		"http://0.0.0.0/hook",
+	} {
+		if err := ValidateURL(ctx, url); !errors.Is(err, ErrPrivateTarget) {
+			t.Errorf("ValidateURL(%q) = %v, want ErrPrivateTarget", url, err)
+		}
+	}
+	for _, url := range []string{"ftp://example.com/hook", "/relative", "http://"} {
+		if err := ValidateURL(ctx, url); !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("ValidateURL(%q) = %v, want ErrInvalidURL", url, err)
+		}
+	}
+	if err := ValidateURL(ctx, "https://93.184.216.34/hook"); err != nil {
+		t.Errorf("public address: %v", err)
+	}
+
+	// A webhook saved before the check, or a host re-pointed since, fails on delivery
+	testdb.Open(t)
+	receiver, requests := startReceiver(t)
+	createWebhook(t, 1, receiver.URL, "whsec_alice", true, "*")
+	enqueue(t, events.Event{ID: 1, Type: "user.updated", EntityType: "user", EntityID: 1, UserID: 1})
+	NewDispatcher(db.DB).deliverDue(ctx)
+
+	var delivery models.WebhookDelivery
+	db.DB.First(&delivery)
+	if delivery.Status != StatusPending || !strings.Contains(delivery.Error, ErrPrivateTarget.Error()) {
+		t.Errorf("delivery = %+v, want a failed attempt refused as private", delivery)
+	}
+	select {
+	case req := <-requests:
+		t.Errorf("the receiver got a request: %s", req.body)
+	default:
+	}
+}
+
+func TestAccountEventsGoToAdmins(t *testing.T) {
+	testdb.Open(t)
+	admin := testdb.CreateUser(t, models.User{Username: "root", Role: models.RoleAdmin})
+	alice := testdb.CreateUser(t, models.User{Username: "alice"})
+	bob := testdb.CreateUser(t, models.User{Username: "bob"})
+	adminHook := createWebhook(t, admin.ID, "https://example.com/admin", "whsec_admin", true, "*")
+	aliceHook := createWebhook(t, alice.ID, "https://example.com/alice", "whsec_alice", true, "*")
+	createWebhook(t, bob.ID, "https://example.com/bob", "whsec_bob", true, "user.created", "user.deleted")
+
+	tests := []struct {
+		event events.Event
+		want  []uint
+	}{
+		{events.Event{ID: 1, Type: "user.created", EntityType: "user", EntityID: bob.ID, UserID: bob.ID}, []uint{adminHook.ID}},
+		{events.Event{ID: 2, Type: "user.updated", EntityType: "user", EntityID: alice.ID, UserID: alice.ID}, []uint{aliceHook.ID}},
+		{events.Event{ID: 3, Type: "user.deleted", EntityType: "user", EntityID: alice.ID, UserID: alice.ID}, []uint{adminHook.ID}},
+	}
+	for _, tt := range tests {
+		enqueue(t, tt.event)
+		var got []uint
+		db.DB.Model(&models.WebhookDelivery{}).Where("event_id = ?", tt.event.ID).Order("webhook_id").Pluck("webhook_id", &got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s of %d: delivered to webhooks %v, want %v", tt.event.Type, tt.event.UserID, got, tt.want)
+		}
+	}
+}
+
+func TestRunStopsWithItsContext(t *testing.T) {
+	testdb.Open(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		NewDispatcher(db.DB).Run(ctx)
+	}()
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after its context was cancelled")
+	}
+}